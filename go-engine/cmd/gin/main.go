@@ -1,7 +1,9 @@
 package main
 
 import (
+	"fmt"
 	"net/http"
+	"strconv"
 
 	"won/sim-lab/go-engine/internal/sim"
 
@@ -16,6 +18,14 @@ var starter = []map[string]string{
 
 var simCache = make(map[string]*sim.Simulation)
 
+// a four-loop plant, like most of the big Westinghouse units, with one
+// reactor coolant pump on each loop
+const PLANT_COOLANT_LOOPS = 4
+const PLANT_PUMPS_PER_LOOP = 1
+
+// the condenser rejects its heat through a cooling tower
+const PLANT_HEAT_SINK = sim.HEAT_SINK_COOLING_TOWER
+
 func main() {
 
 	// bootstrap starter simulations, something to work with
@@ -80,14 +90,84 @@ func main() {
 	router.PUT("/api/sims/:id/interrupt", interruptSim)
 	router.PUT("/api/sims/:id/primary-pump/on", turnOnPrimaryPump)
 	router.PUT("/api/sims/:id/primary-pump/off", turnOffPrimaryPump)
+	router.GET("/api/sims/:id/primary-loop/temperatures", getPrimaryLoopTemperatures)
 	router.PUT("/api/sims/:id/feedwater-pump/on", turnOnFeedwaterPump)
 	router.PUT("/api/sims/:id/feedwater-pump/off", turnOffFeedwaterPump)
 	router.PUT("/api/sims/:id/feedheaters/on", turnOnFeedheaters)
 	router.PUT("/api/sims/:id/feedheaters/off", turnOffFeedheaters)
+	router.GET("/api/sims/:id/loops/:loop", getLoopStatus)
+	router.PUT("/api/sims/:id/loops/:loop/pump/on", turnOnLoopPump)
+	router.PUT("/api/sims/:id/loops/:loop/pump/off", turnOffLoopPump)
+	router.PUT("/api/sims/:id/loops/:loop/pumps/:pump/start", startReactorCoolantPump)
+	router.PUT("/api/sims/:id/loops/:loop/pumps/:pump/stop", stopReactorCoolantPump)
+	router.PUT("/api/sims/:id/loops/:loop/pumps/:pump/speed", setReactorCoolantPumpSpeed)
+	router.PUT("/api/sims/:id/loops/:loop/feedwater/isolate", isolateFeedwater)
+	router.PUT("/api/sims/:id/loops/:loop/feedwater/restore", restoreFeedwater)
+	router.PUT("/api/sims/:id/loops/:loop/feedwater-control/automatic", switchToAutomaticFeedwaterControl)
+	router.PUT("/api/sims/:id/loops/:loop/feedwater-control/manual", switchToManualFeedwaterControl)
+	router.PUT("/api/sims/:id/loops/:loop/feedwater-control/valve", positionFeedwaterValve)
+	router.PUT("/api/sims/:id/loops/:loop/feedwater-control/level-setpoint", setSteamGeneratorLevelSetpoint)
+	router.PUT("/api/sims/:id/loops/:loop/steam-generator/blowdown/open", openBlowdown)
+	router.PUT("/api/sims/:id/loops/:loop/steam-generator/blowdown/close", closeBlowdown)
+	router.PUT("/api/sims/:id/main-steam/porv/open", openSteamLinePORV)
+	router.PUT("/api/sims/:id/main-steam/porv/close", closeSteamLinePORV)
+	router.PUT("/api/sims/:id/feedwater-heaters/:heater/in-service", returnFeedwaterHeaterToService)
+	router.PUT("/api/sims/:id/feedwater-heaters/:heater/out-of-service", takeFeedwaterHeaterOutOfService)
 	router.PUT("/api/sims/:id/pressurizer/heater/on", turnOnHeater)
 	router.PUT("/api/sims/:id/pressurizer/heater/off", turnOffHeater)
 	router.PUT("/api/sims/:id/pressurizer/spray-nozzle/open", openSprayNozzle)
 	router.PUT("/api/sims/:id/pressurizer/spray-nozzle/close", closeSprayNozzle)
+	router.PUT("/api/sims/:id/pressurizer/backup-heaters/on", turnOnBackupHeaters)
+	router.PUT("/api/sims/:id/pressurizer/backup-heaters/off", turnOffBackupHeaters)
+	router.PUT("/api/sims/:id/pressurizer/porv/open", openPORV)
+	router.PUT("/api/sims/:id/pressurizer/porv/close", closePORV)
+	router.PUT("/api/sims/:id/pressurizer/pressure-setpoint", setPressurizerPressureSetpoint)
+	router.PUT("/api/sims/:id/pressurizer/charging", setChargingFlowRate)
+	router.PUT("/api/sims/:id/pressurizer/pressure-control/automatic", switchToAutomaticPressureControl)
+	router.PUT("/api/sims/:id/pressurizer/pressure-control/manual", switchToManualPressureControl)
+	router.PUT("/api/sims/:id/pressurizer/level-control/automatic", switchToAutomaticLevelControl)
+	router.PUT("/api/sims/:id/pressurizer/level-control/manual", switchToManualLevelControl)
+	router.PUT("/api/sims/:id/rod-control/automatic", switchToAutomaticRodControl)
+	router.PUT("/api/sims/:id/rod-control/manual", switchToManualRodControl)
+	router.PUT("/api/sims/:id/control-banks/sequence", moveControlBanksInSequence)
+	router.PUT("/api/sims/:id/control-banks/overlap", setBankOverlap)
+	router.PUT("/api/sims/:id/control-banks/:bank", positionControlBank)
+	router.PUT("/api/sims/:id/gray-banks/:bank", positionGrayBank)
+	router.PUT("/api/sims/:id/shutdown-banks/withdraw", withdrawShutdownBanks)
+	router.PUT("/api/sims/:id/shutdown-banks/insert", insertShutdownBanks)
+	router.PUT("/api/sims/:id/reactor-protection/trip", tripReactor)
+	router.PUT("/api/sims/:id/reactor-protection/reset", resetReactorTrip)
+	router.PUT("/api/sims/:id/reactor-protection/setpoints/:trip", setTripSetpoint)
+	router.PUT("/api/sims/:id/reactor-protection/channels/:trip/:channel/bypass", bypassTripChannel)
+	router.PUT("/api/sims/:id/reactor-protection/channels/:trip/:channel/restore", restoreTripChannel)
+	router.GET("/api/sims/:id/turbine/stages", getTurbineStages)
+	router.PUT("/api/sims/:id/turbine/trip", tripTurbine)
+	router.PUT("/api/sims/:id/turbine/reset", resetTurbineTrip)
+	router.PUT("/api/sims/:id/turbine/load-setpoint", setTurbineLoadSetpoint)
+	router.PUT("/api/sims/:id/turbine/load-ramp-rate", setTurbineLoadRampRate)
+	router.PUT("/api/sims/:id/turbine/steam-dump/arm", armSteamDump)
+	router.PUT("/api/sims/:id/turbine/steam-dump/disarm", disarmSteamDump)
+	router.PUT("/api/sims/:id/turbine/reheater/in-service", returnReheaterToService)
+	router.PUT("/api/sims/:id/turbine/reheater/out-of-service", takeReheaterOutOfService)
+	router.PUT("/api/sims/:id/condenser/vacuum-pumps/on", switchOnVacuumPumps)
+	router.PUT("/api/sims/:id/condenser/vacuum-pumps/off", switchOffVacuumPumps)
+	router.PUT("/api/sims/:id/condenser/vacuum-breaker/open", openVacuumBreaker)
+	router.PUT("/api/sims/:id/condenser/vacuum-breaker/close", closeVacuumBreaker)
+	router.PUT("/api/sims/:id/circulating-water/pumps/:pump/start", startCirculatingWaterPump)
+	router.PUT("/api/sims/:id/circulating-water/pumps/:pump/stop", stopCirculatingWaterPump)
+	router.PUT("/api/sims/:id/generator/breaker/close", closeGeneratorBreaker)
+	router.PUT("/api/sims/:id/generator/breaker/open", openGeneratorBreaker)
+	router.PUT("/api/sims/:id/generator/excitation/automatic", switchToAutomaticExcitation)
+	router.PUT("/api/sims/:id/generator/excitation/manual", switchToManualExcitation)
+	router.PUT("/api/sims/:id/generator/excitation", setGeneratorExcitation)
+	router.PUT("/api/sims/:id/generator/voltage-setpoint", setGeneratorVoltageSetpoint)
+	router.PUT("/api/sims/:id/generator/grid", configureGrid)
+	router.PUT("/api/sims/:id/weather/profile", setWeatherProfile)
+	router.PUT("/api/sims/:id/weather/file", loadWeatherFile)
+	router.GET("/api/sims/:id/alarms", getAlarms)
+	router.PUT("/api/sims/:id/alarms/acknowledge", acknowledgeAllAlarms)
+	router.PUT("/api/sims/:id/alarms/:alarm/acknowledge", acknowledgeAlarm)
+	router.PUT("/api/sims/:id/alarms/reset", resetAlarms)
 
 	router.Run(":8080")
 }
@@ -95,32 +175,41 @@ func main() {
 func spawnSimulation(name, motto string) *sim.Simulation {
 	simmy := sim.NewSimulation(name, motto)
 
-	primaryLoop := sim.NewPrimaryLoop("Primary Loop")
-	// primaryLoop.SwitchOnPump()
-	simmy.AddComponent(primaryLoop)
+	primaryLoops := sim.AddCoolantLoops(simmy, PLANT_COOLANT_LOOPS)
+	for _, primaryLoop := range primaryLoops {
+		primaryLoop.InstallPumps(PLANT_PUMPS_PER_LOOP)
+	}
 
 	secondaryLoop := sim.NewSecondaryLoop("Secondary Loop")
 	simmy.AddComponent(secondaryLoop)
 
 	reactorCore := sim.NewReactorCore("Reactor Core")
-	reactorCore.ConnectToPrimaryLoop(primaryLoop)
+	for _, primaryLoop := range primaryLoops {
+		reactorCore.ConnectToPrimaryLoop(primaryLoop)
+	}
+	reactorCore.BorateToShutdown()
 	simmy.AddComponent(reactorCore)
 
 	pressurizer := sim.NewPressurizer("Pressurizer")
 	simmy.AddComponent(pressurizer)
 
-	steamGenerator := sim.NewSteamGenerator("Steam Generator")
-	simmy.AddComponent(steamGenerator)
-
 	steamTurbine := sim.NewSteamTurbine("Steam Turbine")
 	simmy.AddComponent(steamTurbine)
 
 	condenser := sim.NewCondenser("Condenser")
 	simmy.AddComponent(condenser)
 
+	tertiaryLoop := sim.NewTertiaryLoop("Tertiary Loop", PLANT_HEAT_SINK)
+	tertiaryLoop.StartAllPumps()
+	simmy.AddComponent(tertiaryLoop)
+
 	generator := sim.NewGenerator("Generator")
 	simmy.AddComponent(generator)
 
+	// last, so it sees the plant as it stands at the end of the tick
+	reactorProtection := sim.NewReactorProtection("Reactor Protection")
+	simmy.AddComponent(reactorProtection)
+
 	return simmy
 }
 
@@ -198,8 +287,9 @@ func getComponents(c *gin.Context) {
 
 func createSimulation(c *gin.Context) {
 	var simData struct {
-		Name  string `json:"name" binding:"required"`
-		Motto string `json:"motto" binding:"required"`
+		Name        string `json:"name" binding:"required"`
+		Motto       string `json:"motto" binding:"required"`
+		CoreLoading string `json:"coreLoading"` // beginning, middle or end of the fuel cycle
 	}
 
 	if err := c.ShouldBindJSON(&simData); err != nil {
@@ -208,6 +298,12 @@ func createSimulation(c *gin.Context) {
 	}
 
 	newSim := spawnSimulation(simData.Name, simData.Motto)
+	if simData.CoreLoading != "" {
+		if err := newSim.FindReactorCore().LoadFuel(simData.CoreLoading); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
 	simCache[newSim.ID()] = newSim
 
 	c.JSON(http.StatusCreated, newSim.Info())
@@ -252,6 +348,8 @@ func getComponentStatus(c *gin.Context) {
 		componentInfo = simulation.FindSteamTurbine().Status()
 	case "Condenser":
 		componentInfo = simulation.FindCondenser().Status()
+	case "TertiaryLoop":
+		componentInfo = simulation.FindTertiaryLoop().Status()
 	case "Generator":
 		componentInfo = simulation.FindGenerator().Status()
 	default:
@@ -274,7 +372,9 @@ func turnOnPrimaryPump(c *gin.Context) {
 		return
 	}
 
-	simulation.FindPrimaryLoop().SwitchOnPump()
+	for _, primaryLoop := range simulation.FindPrimaryLoops() {
+		primaryLoop.SwitchOnPump()
+	}
 	c.JSON(http.StatusOK, simulation.Status())
 }
 
@@ -285,11 +385,43 @@ func turnOffPrimaryPump(c *gin.Context) {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Simulation not found"})
 		return
 	}
-	simulation.FindPrimaryLoop().SwitchOffPump()
+	for _, primaryLoop := range simulation.FindPrimaryLoops() {
+		primaryLoop.SwitchOffPump()
+	}
 	c.JSON(http.StatusOK, simulation.Status())
 
 }
 
+func getPrimaryLoopTemperatures(c *gin.Context) {
+	simulationID := c.Param("id")
+	simulation, exists := simCache[simulationID]
+	if !exists {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Simulation not found"})
+		return
+	}
+
+	// the first loop up top, as for a single-loop plant, and every loop after
+	loops := make([]gin.H, 0)
+	for _, primaryLoop := range simulation.FindPrimaryLoops() {
+		loops = append(loops, gin.H{
+			"loop":            primaryLoop.LoopNumber(),
+			"hotLeg":          primaryLoop.HotLegTemperature(),
+			"coldLeg":         primaryLoop.ColdLegTemperature(),
+			"average":         primaryLoop.AverageTemperature(),
+			"temperatureRise": primaryLoop.TemperatureRise(),
+		})
+	}
+	primaryLoop := simulation.FindPrimaryLoop()
+	c.JSON(http.StatusOK, gin.H{
+		"hotLeg":          primaryLoop.HotLegTemperature(),
+		"coldLeg":         primaryLoop.ColdLegTemperature(),
+		"average":         primaryLoop.AverageTemperature(),
+		"temperatureRise": primaryLoop.TemperatureRise(),
+		"loops":           loops,
+		"unit":            "°C",
+	})
+}
+
 func turnOnFeedwaterPump(c *gin.Context) {
 	simulationID := c.Param("id")
 	simulation, exists := simCache[simulationID]
@@ -338,7 +470,7 @@ func turnOffFeedheaters(c *gin.Context) {
 
 }
 
-func turnOnHeater(c *gin.Context) {
+func openSteamLinePORV(c *gin.Context) {
 	simulationID := c.Param("id")
 	simulation, exists := simCache[simulationID]
 	if !exists {
@@ -346,39 +478,1364 @@ func turnOnHeater(c *gin.Context) {
 		return
 	}
 
-	simulation.FindPressurizer().SwitchOnHeater()
+	var porvData struct {
+		TargetPressure float64 `json:"targetPressure"` // MPa
+	}
+	if err := c.ShouldBindJSON(&porvData); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if porvData.TargetPressure <= 0 || porvData.TargetPressure >= sim.STEAM_LINE_PORV_SETPOINT {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Target pressure must be between 0 and %.1f MPa", sim.STEAM_LINE_PORV_SETPOINT)})
+		return
+	}
+
+	simulation.FindSecondaryLoop().OpenPowerOperatedReliefValue(porvData.TargetPressure)
 	c.JSON(http.StatusOK, simulation.Status())
 }
 
-func turnOffHeater(c *gin.Context) {
+func closeSteamLinePORV(c *gin.Context) {
 	simulationID := c.Param("id")
 	simulation, exists := simCache[simulationID]
 	if !exists {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Simulation not found"})
 		return
 	}
-	simulation.FindPressurizer().SwitchOffHeater()
+
+	simulation.FindSecondaryLoop().ClosePowerOperatedReliefValve()
 	c.JSON(http.StatusOK, simulation.Status())
 }
 
-func openSprayNozzle(c *gin.Context) {
+// findFeedwaterHeater looks up the heater numbered in the path, answering 404 if there is none
+func findFeedwaterHeater(c *gin.Context, simulation *sim.Simulation) *sim.FeedwaterHeater {
+	secondaryLoop := simulation.FindSecondaryLoop()
+	number, err := strconv.Atoi(c.Param("heater"))
+	heater := secondaryLoop.FeedwaterHeater(number)
+	if err != nil || heater == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": fmt.Sprintf("Heater must be 1 through %d", len(secondaryLoop.FeedwaterHeaters()))})
+		return nil
+	}
+	return heater
+}
+
+func returnFeedwaterHeaterToService(c *gin.Context) {
 	simulationID := c.Param("id")
 	simulation, exists := simCache[simulationID]
 	if !exists {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Simulation not found"})
 		return
 	}
-	simulation.FindPressurizer().OpenSprayNozzle()
+
+	heater := findFeedwaterHeater(c, simulation)
+	if heater == nil {
+		return
+	}
+	heater.ReturnToService()
 	c.JSON(http.StatusOK, simulation.Status())
 }
 
-func closeSprayNozzle(c *gin.Context) {
+func takeFeedwaterHeaterOutOfService(c *gin.Context) {
+	simulationID := c.Param("id")
+	simulation, exists := simCache[simulationID]
+	if !exists {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Simulation not found"})
+		return
+	}
+
+	heater := findFeedwaterHeater(c, simulation)
+	if heater == nil {
+		return
+	}
+	heater.TakeOutOfService()
+	c.JSON(http.StatusOK, simulation.Status())
+}
+
+// findPrimaryLoop looks up the loop numbered in the path, answering 404 if there is none
+func findPrimaryLoop(c *gin.Context, simulation *sim.Simulation) *sim.PrimaryLoop {
+	primaryLoops := simulation.FindPrimaryLoops()
+	number, err := strconv.Atoi(c.Param("loop"))
+	if err != nil || number < 1 || number > len(primaryLoops) {
+		c.JSON(http.StatusNotFound, gin.H{"error": fmt.Sprintf("Loop must be 1 through %d", len(primaryLoops))})
+		return nil
+	}
+	return primaryLoops[number-1]
+}
+
+func findReactorCoolantPump(c *gin.Context, simulation *sim.Simulation) *sim.ReactorCoolantPump {
+	primaryLoop := findPrimaryLoop(c, simulation)
+	if primaryLoop == nil {
+		return nil
+	}
+	number, err := strconv.Atoi(c.Param("pump"))
+	pump := primaryLoop.Pump(number)
+	if err != nil || pump == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": fmt.Sprintf("Pump must be 1 through %d", len(primaryLoop.Pumps()))})
+		return nil
+	}
+	return pump
+}
+
+func findSteamGenerator(c *gin.Context, simulation *sim.Simulation) *sim.SteamGenerator {
+	primaryLoop := findPrimaryLoop(c, simulation)
+	if primaryLoop == nil {
+		return nil
+	}
+	steamGenerator := primaryLoop.SteamGenerator(simulation)
+	if steamGenerator == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Steam generator not found"})
+	}
+	return steamGenerator
+}
+
+func getLoopStatus(c *gin.Context) {
+	simulationID := c.Param("id")
+	simulation, exists := simCache[simulationID]
+	if !exists {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Simulation not found"})
+		return
+	}
+
+	primaryLoop := findPrimaryLoop(c, simulation)
+	if primaryLoop == nil {
+		return
+	}
+	loopStatus := gin.H{"primaryLoop": primaryLoop.Status()}
+	if steamGenerator := primaryLoop.SteamGenerator(simulation); steamGenerator != nil {
+		loopStatus["steamGenerator"] = steamGenerator.Status()
+	}
+	c.JSON(http.StatusOK, loopStatus)
+}
+
+func turnOnLoopPump(c *gin.Context) {
+	simulationID := c.Param("id")
+	simulation, exists := simCache[simulationID]
+	if !exists {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Simulation not found"})
+		return
+	}
+
+	primaryLoop := findPrimaryLoop(c, simulation)
+	if primaryLoop == nil {
+		return
+	}
+
+	primaryLoop.SwitchOnPump()
+	c.JSON(http.StatusOK, simulation.Status())
+}
+
+func turnOffLoopPump(c *gin.Context) {
+	simulationID := c.Param("id")
+	simulation, exists := simCache[simulationID]
+	if !exists {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Simulation not found"})
+		return
+	}
+
+	primaryLoop := findPrimaryLoop(c, simulation)
+	if primaryLoop == nil {
+		return
+	}
+
+	primaryLoop.SwitchOffPump()
+	c.JSON(http.StatusOK, simulation.Status())
+}
+
+func startReactorCoolantPump(c *gin.Context) {
+	simulationID := c.Param("id")
+	simulation, exists := simCache[simulationID]
+	if !exists {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Simulation not found"})
+		return
+	}
+
+	pump := findReactorCoolantPump(c, simulation)
+	if pump == nil {
+		return
+	}
+
+	pump.Start()
+	c.JSON(http.StatusOK, simulation.Status())
+}
+
+func stopReactorCoolantPump(c *gin.Context) {
+	simulationID := c.Param("id")
+	simulation, exists := simCache[simulationID]
+	if !exists {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Simulation not found"})
+		return
+	}
+
+	pump := findReactorCoolantPump(c, simulation)
+	if pump == nil {
+		return
+	}
+
+	pump.Stop()
+	c.JSON(http.StatusOK, simulation.Status())
+}
+
+func setReactorCoolantPumpSpeed(c *gin.Context) {
+	simulationID := c.Param("id")
+	simulation, exists := simCache[simulationID]
+	if !exists {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Simulation not found"})
+		return
+	}
+
+	var speedData struct {
+		Speed float64 `json:"speed"` // percent of rated
+	}
+	if err := c.ShouldBindJSON(&speedData); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if speedData.Speed < sim.RCP_MIN_SPEED || speedData.Speed > 100 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Pump speed must be between %.0f and 100 percent", sim.RCP_MIN_SPEED)})
+		return
+	}
+
+	pump := findReactorCoolantPump(c, simulation)
+	if pump == nil {
+		return
+	}
+
+	pump.SetSpeedSetpoint(speedData.Speed)
+	c.JSON(http.StatusOK, simulation.Status())
+}
+
+func isolateFeedwater(c *gin.Context) {
+	simulationID := c.Param("id")
+	simulation, exists := simCache[simulationID]
+	if !exists {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Simulation not found"})
+		return
+	}
+
+	steamGenerator := findSteamGenerator(c, simulation)
+	if steamGenerator == nil {
+		return
+	}
+
+	steamGenerator.IsolateFeedwater()
+	c.JSON(http.StatusOK, simulation.Status())
+}
+
+func restoreFeedwater(c *gin.Context) {
+	simulationID := c.Param("id")
+	simulation, exists := simCache[simulationID]
+	if !exists {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Simulation not found"})
+		return
+	}
+
+	steamGenerator := findSteamGenerator(c, simulation)
+	if steamGenerator == nil {
+		return
+	}
+
+	steamGenerator.RestoreFeedwater()
+	c.JSON(http.StatusOK, simulation.Status())
+}
+
+func switchToAutomaticFeedwaterControl(c *gin.Context) {
+	simulationID := c.Param("id")
+	simulation, exists := simCache[simulationID]
+	if !exists {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Simulation not found"})
+		return
+	}
+
+	steamGenerator := findSteamGenerator(c, simulation)
+	if steamGenerator == nil {
+		return
+	}
+
+	steamGenerator.FeedwaterControl().SwitchToAutomatic()
+	c.JSON(http.StatusOK, simulation.Status())
+}
+
+func switchToManualFeedwaterControl(c *gin.Context) {
+	simulationID := c.Param("id")
+	simulation, exists := simCache[simulationID]
+	if !exists {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Simulation not found"})
+		return
+	}
+
+	steamGenerator := findSteamGenerator(c, simulation)
+	if steamGenerator == nil {
+		return
+	}
+
+	steamGenerator.FeedwaterControl().SwitchToManual()
+	c.JSON(http.StatusOK, simulation.Status())
+}
+
+func positionFeedwaterValve(c *gin.Context) {
+	simulationID := c.Param("id")
+	simulation, exists := simCache[simulationID]
+	if !exists {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Simulation not found"})
+		return
+	}
+
+	var positionData struct {
+		Position float64 `json:"position"` // percent open
+	}
+	if err := c.ShouldBindJSON(&positionData); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	steamGenerator := findSteamGenerator(c, simulation)
+	if steamGenerator == nil {
+		return
+	}
+	feedwaterControl := steamGenerator.FeedwaterControl()
+	if feedwaterControl.Mode() == sim.FEEDWATER_CONTROL_AUTOMATIC {
+		c.JSON(http.StatusConflict, gin.H{"error": "Feedwater control is in automatic"})
+		return
+	}
+	if positionData.Position < 0 || positionData.Position > 100 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Position must be between 0 and 100 percent"})
+		return
+	}
+
+	feedwaterControl.SetValvePosition(positionData.Position)
+	c.JSON(http.StatusOK, simulation.Status())
+}
+
+func setSteamGeneratorLevelSetpoint(c *gin.Context) {
+	simulationID := c.Param("id")
+	simulation, exists := simCache[simulationID]
+	if !exists {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Simulation not found"})
+		return
+	}
+
+	var setpointData struct {
+		Level float64 `json:"level"` // percent, narrow range
+	}
+	if err := c.ShouldBindJSON(&setpointData); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if setpointData.Level < sim.STEAM_GENERATOR_LOW_LEVEL_ALARM || setpointData.Level > sim.STEAM_GENERATOR_HIGH_LEVEL_ALARM {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Level setpoint must be between %.0f and %.0f percent", sim.STEAM_GENERATOR_LOW_LEVEL_ALARM, sim.STEAM_GENERATOR_HIGH_LEVEL_ALARM)})
+		return
+	}
+
+	steamGenerator := findSteamGenerator(c, simulation)
+	if steamGenerator == nil {
+		return
+	}
+
+	steamGenerator.FeedwaterControl().SetLevelSetpoint(setpointData.Level)
+	c.JSON(http.StatusOK, simulation.Status())
+}
+
+func openBlowdown(c *gin.Context) {
 	simulationID := c.Param("id")
 	simulation, exists := simCache[simulationID]
 	if !exists {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Simulation not found"})
 		return
 	}
-	simulation.FindPressurizer().CloseSprayNozzle()
+
+	steamGenerator := findSteamGenerator(c, simulation)
+	if steamGenerator == nil {
+		return
+	}
+	feedwaterControl := steamGenerator.FeedwaterControl()
+	if feedwaterControl.Mode() == sim.FEEDWATER_CONTROL_AUTOMATIC {
+		c.JSON(http.StatusConflict, gin.H{"error": "Feedwater control is in automatic"})
+		return
+	}
+
+	feedwaterControl.OpenBlowdown()
 	c.JSON(http.StatusOK, simulation.Status())
 }
+
+func closeBlowdown(c *gin.Context) {
+	simulationID := c.Param("id")
+	simulation, exists := simCache[simulationID]
+	if !exists {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Simulation not found"})
+		return
+	}
+
+	steamGenerator := findSteamGenerator(c, simulation)
+	if steamGenerator == nil {
+		return
+	}
+	feedwaterControl := steamGenerator.FeedwaterControl()
+	if feedwaterControl.Mode() == sim.FEEDWATER_CONTROL_AUTOMATIC {
+		c.JSON(http.StatusConflict, gin.H{"error": "Feedwater control is in automatic"})
+		return
+	}
+
+	feedwaterControl.CloseBlowdown()
+	c.JSON(http.StatusOK, simulation.Status())
+}
+
+func turnOnHeater(c *gin.Context) {
+	simulationID := c.Param("id")
+	simulation, exists := simCache[simulationID]
+	if !exists {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Simulation not found"})
+		return
+	}
+
+	simulation.FindPressurizer().SwitchOnHeater()
+	c.JSON(http.StatusOK, simulation.Status())
+}
+
+func turnOffHeater(c *gin.Context) {
+	simulationID := c.Param("id")
+	simulation, exists := simCache[simulationID]
+	if !exists {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Simulation not found"})
+		return
+	}
+	simulation.FindPressurizer().SwitchOffHeater()
+	c.JSON(http.StatusOK, simulation.Status())
+}
+
+func openSprayNozzle(c *gin.Context) {
+	simulationID := c.Param("id")
+	simulation, exists := simCache[simulationID]
+	if !exists {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Simulation not found"})
+		return
+	}
+	pressurizer := simulation.FindPressurizer()
+	if pressurizer.PressureControlMode() == sim.PRESSURIZER_CONTROL_AUTOMATIC {
+		c.JSON(http.StatusConflict, gin.H{"error": "Pressure control is in automatic"})
+		return
+	}
+	pressurizer.OpenSprayNozzle()
+	c.JSON(http.StatusOK, simulation.Status())
+}
+
+func closeSprayNozzle(c *gin.Context) {
+	simulationID := c.Param("id")
+	simulation, exists := simCache[simulationID]
+	if !exists {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Simulation not found"})
+		return
+	}
+	pressurizer := simulation.FindPressurizer()
+	if pressurizer.PressureControlMode() == sim.PRESSURIZER_CONTROL_AUTOMATIC {
+		c.JSON(http.StatusConflict, gin.H{"error": "Pressure control is in automatic"})
+		return
+	}
+	pressurizer.CloseSprayNozzle()
+	c.JSON(http.StatusOK, simulation.Status())
+}
+
+func turnOnBackupHeaters(c *gin.Context) {
+	simulationID := c.Param("id")
+	simulation, exists := simCache[simulationID]
+	if !exists {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Simulation not found"})
+		return
+	}
+
+	pressurizer := simulation.FindPressurizer()
+	if pressurizer.PressureControlMode() == sim.PRESSURIZER_CONTROL_AUTOMATIC {
+		c.JSON(http.StatusConflict, gin.H{"error": "Pressure control is in automatic"})
+		return
+	}
+
+	pressurizer.SwitchOnBackupHeaters()
+	c.JSON(http.StatusOK, simulation.Status())
+}
+
+func turnOffBackupHeaters(c *gin.Context) {
+	simulationID := c.Param("id")
+	simulation, exists := simCache[simulationID]
+	if !exists {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Simulation not found"})
+		return
+	}
+
+	pressurizer := simulation.FindPressurizer()
+	if pressurizer.PressureControlMode() == sim.PRESSURIZER_CONTROL_AUTOMATIC {
+		c.JSON(http.StatusConflict, gin.H{"error": "Pressure control is in automatic"})
+		return
+	}
+
+	pressurizer.SwitchOffBackupHeaters()
+	c.JSON(http.StatusOK, simulation.Status())
+}
+
+func openPORV(c *gin.Context) {
+	simulationID := c.Param("id")
+	simulation, exists := simCache[simulationID]
+	if !exists {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Simulation not found"})
+		return
+	}
+
+	pressurizer := simulation.FindPressurizer()
+	if pressurizer.PressureControlMode() == sim.PRESSURIZER_CONTROL_AUTOMATIC {
+		c.JSON(http.StatusConflict, gin.H{"error": "Pressure control is in automatic"})
+		return
+	}
+
+	pressurizer.OpenPORV()
+	c.JSON(http.StatusOK, simulation.Status())
+}
+
+func closePORV(c *gin.Context) {
+	simulationID := c.Param("id")
+	simulation, exists := simCache[simulationID]
+	if !exists {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Simulation not found"})
+		return
+	}
+
+	pressurizer := simulation.FindPressurizer()
+	if pressurizer.PressureControlMode() == sim.PRESSURIZER_CONTROL_AUTOMATIC {
+		c.JSON(http.StatusConflict, gin.H{"error": "Pressure control is in automatic"})
+		return
+	}
+
+	pressurizer.ClosePORV()
+	c.JSON(http.StatusOK, simulation.Status())
+}
+
+func setPressurizerPressureSetpoint(c *gin.Context) {
+	simulationID := c.Param("id")
+	simulation, exists := simCache[simulationID]
+	if !exists {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Simulation not found"})
+		return
+	}
+
+	var setpointData struct {
+		Pressure float64 `json:"pressure"` // MPa
+	}
+	if err := c.ShouldBindJSON(&setpointData); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if setpointData.Pressure <= 0 || setpointData.Pressure >= sim.PORV_OPEN_PRESSURE {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Pressure setpoint must be between 0 and %.1f MPa", sim.PORV_OPEN_PRESSURE)})
+		return
+	}
+
+	simulation.FindPressurizer().SetTargetPressure(setpointData.Pressure)
+	c.JSON(http.StatusOK, simulation.Status())
+}
+
+func setChargingFlowRate(c *gin.Context) {
+	simulationID := c.Param("id")
+	simulation, exists := simCache[simulationID]
+	if !exists {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Simulation not found"})
+		return
+	}
+
+	var chargingData struct {
+		FlowRate float64 `json:"flowRate"` // kg/s
+	}
+	if err := c.ShouldBindJSON(&chargingData); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	pressurizer := simulation.FindPressurizer()
+	if pressurizer.LevelControlMode() == sim.PRESSURIZER_CONTROL_AUTOMATIC {
+		c.JSON(http.StatusConflict, gin.H{"error": "Level control is in automatic"})
+		return
+	}
+	if chargingData.FlowRate < 0 || chargingData.FlowRate > sim.MAX_CHARGING_FLOW_RATE {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Charging flow must be between 0 and %.1f kg/s", sim.MAX_CHARGING_FLOW_RATE)})
+		return
+	}
+
+	pressurizer.SetChargingFlowRate(chargingData.FlowRate)
+	c.JSON(http.StatusOK, simulation.Status())
+}
+
+func switchToAutomaticPressureControl(c *gin.Context) {
+	simulationID := c.Param("id")
+	simulation, exists := simCache[simulationID]
+	if !exists {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Simulation not found"})
+		return
+	}
+
+	simulation.FindPressurizer().SwitchToAutomaticPressureControl()
+	c.JSON(http.StatusOK, simulation.Status())
+}
+
+func switchToManualPressureControl(c *gin.Context) {
+	simulationID := c.Param("id")
+	simulation, exists := simCache[simulationID]
+	if !exists {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Simulation not found"})
+		return
+	}
+
+	simulation.FindPressurizer().SwitchToManualPressureControl()
+	c.JSON(http.StatusOK, simulation.Status())
+}
+
+func switchToAutomaticLevelControl(c *gin.Context) {
+	simulationID := c.Param("id")
+	simulation, exists := simCache[simulationID]
+	if !exists {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Simulation not found"})
+		return
+	}
+
+	simulation.FindPressurizer().SwitchToAutomaticLevelControl()
+	c.JSON(http.StatusOK, simulation.Status())
+}
+
+func switchToManualLevelControl(c *gin.Context) {
+	simulationID := c.Param("id")
+	simulation, exists := simCache[simulationID]
+	if !exists {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Simulation not found"})
+		return
+	}
+
+	simulation.FindPressurizer().SwitchToManualLevelControl()
+	c.JSON(http.StatusOK, simulation.Status())
+}
+
+func switchToAutomaticRodControl(c *gin.Context) {
+	simulationID := c.Param("id")
+	simulation, exists := simCache[simulationID]
+	if !exists {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Simulation not found"})
+		return
+	}
+
+	simulation.FindReactorCore().ControlRods().SwitchToAutomaticRodControl()
+	c.JSON(http.StatusOK, simulation.Status())
+}
+
+func switchToManualRodControl(c *gin.Context) {
+	simulationID := c.Param("id")
+	simulation, exists := simCache[simulationID]
+	if !exists {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Simulation not found"})
+		return
+	}
+
+	simulation.FindReactorCore().ControlRods().SwitchToManualRodControl()
+	c.JSON(http.StatusOK, simulation.Status())
+}
+
+func moveControlBanksInSequence(c *gin.Context) {
+	simulationID := c.Param("id")
+	simulation, exists := simCache[simulationID]
+	if !exists {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Simulation not found"})
+		return
+	}
+
+	var sequenceData struct {
+		Demand int `json:"demand"` // steps along the overlapped bank sequence
+	}
+	if err := c.ShouldBindJSON(&sequenceData); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	controlRods := simulation.FindReactorCore().ControlRods()
+	if controlRods.RodControlMode() == sim.ROD_CONTROL_AUTOMATIC {
+		c.JSON(http.StatusConflict, gin.H{"error": "Rod control is in automatic"})
+		return
+	}
+	if sequenceData.Demand < 0 || sequenceData.Demand > controlRods.MaxBankDemand() {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Demand must be between 0 and %d steps", controlRods.MaxBankDemand())})
+		return
+	}
+
+	controlRods.MoveControlBanksInSequence(sequenceData.Demand)
+	c.JSON(http.StatusOK, simulation.Status())
+}
+
+func setBankOverlap(c *gin.Context) {
+	simulationID := c.Param("id")
+	simulation, exists := simCache[simulationID]
+	if !exists {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Simulation not found"})
+		return
+	}
+
+	var overlapData struct {
+		Steps int `json:"steps"`
+	}
+	if err := c.ShouldBindJSON(&overlapData); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if overlapData.Steps < 0 || overlapData.Steps >= sim.MAX_WITHDRAWAL_STEPS {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Overlap must be between 0 and %d steps", sim.MAX_WITHDRAWAL_STEPS-1)})
+		return
+	}
+
+	simulation.FindReactorCore().ControlRods().SetBankOverlap(overlapData.Steps)
+	c.JSON(http.StatusOK, simulation.Status())
+}
+
+func positionControlBank(c *gin.Context) {
+	simulationID := c.Param("id")
+	simulation, exists := simCache[simulationID]
+	if !exists {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Simulation not found"})
+		return
+	}
+
+	bank, err := strconv.Atoi(c.Param("bank"))
+	if err != nil || bank < 1 || bank > 4 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Control bank must be 1 through 4"})
+		return
+	}
+
+	var positionData struct {
+		Position int `json:"position"` // steps withdrawn
+	}
+	if err := c.ShouldBindJSON(&positionData); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if positionData.Position < 0 || positionData.Position > sim.MAX_WITHDRAWAL_STEPS {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Position must be between 0 and %d steps", sim.MAX_WITHDRAWAL_STEPS)})
+		return
+	}
+
+	controlRods := simulation.FindReactorCore().ControlRods()
+	if controlRods.RodControlMode() == sim.ROD_CONTROL_AUTOMATIC {
+		c.JSON(http.StatusConflict, gin.H{"error": "Rod control is in automatic"})
+		return
+	}
+
+	controlRods.AdjustControlBankPosition(bank, positionData.Position)
+	c.JSON(http.StatusOK, simulation.Status())
+}
+
+func positionGrayBank(c *gin.Context) {
+	simulationID := c.Param("id")
+	simulation, exists := simCache[simulationID]
+	if !exists {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Simulation not found"})
+		return
+	}
+
+	bank, err := strconv.Atoi(c.Param("bank"))
+	if err != nil || bank < 1 || bank > 2 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Gray bank must be 1 or 2"})
+		return
+	}
+
+	var positionData struct {
+		Position int `json:"position"` // steps withdrawn
+	}
+	if err := c.ShouldBindJSON(&positionData); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if positionData.Position < 0 || positionData.Position > sim.MAX_WITHDRAWAL_STEPS {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Position must be between 0 and %d steps", sim.MAX_WITHDRAWAL_STEPS)})
+		return
+	}
+
+	simulation.FindReactorCore().ControlRods().AdjustGrayBankPosition(bank, positionData.Position)
+	c.JSON(http.StatusOK, simulation.Status())
+}
+
+func withdrawShutdownBanks(c *gin.Context) {
+	simulationID := c.Param("id")
+	simulation, exists := simCache[simulationID]
+	if !exists {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Simulation not found"})
+		return
+	}
+
+	simulation.FindReactorCore().WithdrawShutdownBanks()
+	c.JSON(http.StatusOK, simulation.Status())
+}
+
+func insertShutdownBanks(c *gin.Context) {
+	simulationID := c.Param("id")
+	simulation, exists := simCache[simulationID]
+	if !exists {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Simulation not found"})
+		return
+	}
+
+	simulation.FindReactorCore().InsertShutdownBanks()
+	c.JSON(http.StatusOK, simulation.Status())
+}
+
+func tripReactor(c *gin.Context) {
+	simulationID := c.Param("id")
+	simulation, exists := simCache[simulationID]
+	if !exists {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Simulation not found"})
+		return
+	}
+
+	simulation.FindReactorProtection().ManualTrip()
+	c.JSON(http.StatusOK, simulation.Status())
+}
+
+func resetReactorTrip(c *gin.Context) {
+	simulationID := c.Param("id")
+	simulation, exists := simCache[simulationID]
+	if !exists {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Simulation not found"})
+		return
+	}
+
+	if err := simulation.FindReactorProtection().Reset(simulation); err != nil {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, simulation.Status())
+}
+
+func setTripSetpoint(c *gin.Context) {
+	simulationID := c.Param("id")
+	simulation, exists := simCache[simulationID]
+	if !exists {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Simulation not found"})
+		return
+	}
+
+	var setpointData struct {
+		Setpoint float64 `json:"setpoint" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&setpointData); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := simulation.FindReactorProtection().SetSetpoint(c.Param("trip"), setpointData.Setpoint); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, simulation.Status())
+}
+
+func bypassTripChannel(c *gin.Context) {
+	simulationID := c.Param("id")
+	simulation, exists := simCache[simulationID]
+	if !exists {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Simulation not found"})
+		return
+	}
+
+	channel, err := strconv.Atoi(c.Param("channel"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Channel must be a number"})
+		return
+	}
+	if err := simulation.FindReactorProtection().BypassChannel(c.Param("trip"), channel); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, simulation.Status())
+}
+
+func restoreTripChannel(c *gin.Context) {
+	simulationID := c.Param("id")
+	simulation, exists := simCache[simulationID]
+	if !exists {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Simulation not found"})
+		return
+	}
+
+	channel, err := strconv.Atoi(c.Param("channel"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Channel must be a number"})
+		return
+	}
+	if err := simulation.FindReactorProtection().RestoreChannel(c.Param("trip"), channel); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, simulation.Status())
+}
+
+func tripTurbine(c *gin.Context) {
+	simulationID := c.Param("id")
+	simulation, exists := simCache[simulationID]
+	if !exists {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Simulation not found"})
+		return
+	}
+
+	simulation.FindSteamTurbine().Trip()
+	c.JSON(http.StatusOK, simulation.Status())
+}
+
+func resetTurbineTrip(c *gin.Context) {
+	simulationID := c.Param("id")
+	simulation, exists := simCache[simulationID]
+	if !exists {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Simulation not found"})
+		return
+	}
+
+	simulation.FindSteamTurbine().ResetTrip()
+	c.JSON(http.StatusOK, simulation.Status())
+}
+
+func setTurbineLoadSetpoint(c *gin.Context) {
+	simulationID := c.Param("id")
+	simulation, exists := simCache[simulationID]
+	if !exists {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Simulation not found"})
+		return
+	}
+
+	var setpointData struct {
+		Load float64 `json:"load"` // percent of rated steam flow
+	}
+	if err := c.ShouldBindJSON(&setpointData); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if setpointData.Load < 0 || setpointData.Load > 100 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Load setpoint must be between 0 and 100 percent"})
+		return
+	}
+	if simulation.FindSteamTurbine().IsTripped() {
+		c.JSON(http.StatusConflict, gin.H{"error": "Turbine is tripped"})
+		return
+	}
+
+	simulation.FindSteamTurbine().SetLoadSetpoint(setpointData.Load)
+	c.JSON(http.StatusOK, simulation.Status())
+}
+
+func setTurbineLoadRampRate(c *gin.Context) {
+	simulationID := c.Param("id")
+	simulation, exists := simCache[simulationID]
+	if !exists {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Simulation not found"})
+		return
+	}
+
+	var rampData struct {
+		Rate float64 `json:"rate"` // percent per minute
+	}
+	if err := c.ShouldBindJSON(&rampData); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if rampData.Rate <= 0 || rampData.Rate > sim.MAX_LOAD_RAMP_RATE {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Load ramp rate must be above 0 and at most %.0f percent per minute", sim.MAX_LOAD_RAMP_RATE)})
+		return
+	}
+
+	simulation.FindSteamTurbine().SetLoadRampRate(rampData.Rate)
+	c.JSON(http.StatusOK, simulation.Status())
+}
+
+func armSteamDump(c *gin.Context) {
+	simulationID := c.Param("id")
+	simulation, exists := simCache[simulationID]
+	if !exists {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Simulation not found"})
+		return
+	}
+
+	simulation.FindSteamTurbine().ArmSteamDump()
+	c.JSON(http.StatusOK, simulation.Status())
+}
+
+func disarmSteamDump(c *gin.Context) {
+	simulationID := c.Param("id")
+	simulation, exists := simCache[simulationID]
+	if !exists {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Simulation not found"})
+		return
+	}
+
+	simulation.FindSteamTurbine().DisarmSteamDump()
+	c.JSON(http.StatusOK, simulation.Status())
+}
+
+func getTurbineStages(c *gin.Context) {
+	simulationID := c.Param("id")
+	simulation, exists := simCache[simulationID]
+	if !exists {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Simulation not found"})
+		return
+	}
+
+	stages := []map[string]interface{}{}
+	for _, stage := range simulation.FindSteamTurbine().Stages() {
+		stages = append(stages, stage.Status())
+	}
+	c.JSON(http.StatusOK, gin.H{"stages": stages})
+}
+
+func returnReheaterToService(c *gin.Context) {
+	simulationID := c.Param("id")
+	simulation, exists := simCache[simulationID]
+	if !exists {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Simulation not found"})
+		return
+	}
+
+	simulation.FindSteamTurbine().MoistureSeparatorReheater().ReturnReheaterToService()
+	c.JSON(http.StatusOK, simulation.Status())
+}
+
+func takeReheaterOutOfService(c *gin.Context) {
+	simulationID := c.Param("id")
+	simulation, exists := simCache[simulationID]
+	if !exists {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Simulation not found"})
+		return
+	}
+
+	simulation.FindSteamTurbine().MoistureSeparatorReheater().TakeReheaterOutOfService()
+	c.JSON(http.StatusOK, simulation.Status())
+}
+
+func switchOnVacuumPumps(c *gin.Context) {
+	simulationID := c.Param("id")
+	simulation, exists := simCache[simulationID]
+	if !exists {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Simulation not found"})
+		return
+	}
+
+	simulation.FindCondenser().SwitchOnVacuumPumps()
+	c.JSON(http.StatusOK, simulation.Status())
+}
+
+func switchOffVacuumPumps(c *gin.Context) {
+	simulationID := c.Param("id")
+	simulation, exists := simCache[simulationID]
+	if !exists {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Simulation not found"})
+		return
+	}
+
+	simulation.FindCondenser().SwitchOffVacuumPumps()
+	c.JSON(http.StatusOK, simulation.Status())
+}
+
+func openVacuumBreaker(c *gin.Context) {
+	simulationID := c.Param("id")
+	simulation, exists := simCache[simulationID]
+	if !exists {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Simulation not found"})
+		return
+	}
+
+	simulation.FindCondenser().OpenVacuumBreaker()
+	c.JSON(http.StatusOK, simulation.Status())
+}
+
+func closeVacuumBreaker(c *gin.Context) {
+	simulationID := c.Param("id")
+	simulation, exists := simCache[simulationID]
+	if !exists {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Simulation not found"})
+		return
+	}
+
+	simulation.FindCondenser().CloseVacuumBreaker()
+	c.JSON(http.StatusOK, simulation.Status())
+}
+
+// findCirculatingWaterPump reads the pump numbered in the path, answering 404 if there is none
+func findCirculatingWaterPump(c *gin.Context, tertiaryLoop *sim.TertiaryLoop) (int, bool) {
+	number, err := strconv.Atoi(c.Param("pump"))
+	if err != nil || number < 1 || number > tertiaryLoop.PumpCount() {
+		c.JSON(http.StatusNotFound, gin.H{"error": fmt.Sprintf("Pump must be 1 through %d", tertiaryLoop.PumpCount())})
+		return 0, false
+	}
+	return number, true
+}
+
+func startCirculatingWaterPump(c *gin.Context) {
+	simulationID := c.Param("id")
+	simulation, exists := simCache[simulationID]
+	if !exists {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Simulation not found"})
+		return
+	}
+
+	tertiaryLoop := simulation.FindTertiaryLoop()
+	number, ok := findCirculatingWaterPump(c, tertiaryLoop)
+	if !ok {
+		return
+	}
+	tertiaryLoop.StartPump(number)
+	c.JSON(http.StatusOK, simulation.Status())
+}
+
+func stopCirculatingWaterPump(c *gin.Context) {
+	simulationID := c.Param("id")
+	simulation, exists := simCache[simulationID]
+	if !exists {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Simulation not found"})
+		return
+	}
+
+	tertiaryLoop := simulation.FindTertiaryLoop()
+	number, ok := findCirculatingWaterPump(c, tertiaryLoop)
+	if !ok {
+		return
+	}
+	tertiaryLoop.StopPump(number)
+	c.JSON(http.StatusOK, simulation.Status())
+}
+
+func closeGeneratorBreaker(c *gin.Context) {
+	simulationID := c.Param("id")
+	simulation, exists := simCache[simulationID]
+	if !exists {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Simulation not found"})
+		return
+	}
+
+	if err := simulation.FindGenerator().CloseBreaker(simulation); err != nil {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, simulation.Status())
+}
+
+func openGeneratorBreaker(c *gin.Context) {
+	simulationID := c.Param("id")
+	simulation, exists := simCache[simulationID]
+	if !exists {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Simulation not found"})
+		return
+	}
+
+	simulation.FindGenerator().OpenBreaker(simulation)
+	c.JSON(http.StatusOK, simulation.Status())
+}
+
+func switchToAutomaticExcitation(c *gin.Context) {
+	simulationID := c.Param("id")
+	simulation, exists := simCache[simulationID]
+	if !exists {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Simulation not found"})
+		return
+	}
+
+	simulation.FindGenerator().SwitchToAutomaticExcitation()
+	c.JSON(http.StatusOK, simulation.Status())
+}
+
+func switchToManualExcitation(c *gin.Context) {
+	simulationID := c.Param("id")
+	simulation, exists := simCache[simulationID]
+	if !exists {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Simulation not found"})
+		return
+	}
+
+	simulation.FindGenerator().SwitchToManualExcitation()
+	c.JSON(http.StatusOK, simulation.Status())
+}
+
+func setGeneratorExcitation(c *gin.Context) {
+	simulationID := c.Param("id")
+	simulation, exists := simCache[simulationID]
+	if !exists {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Simulation not found"})
+		return
+	}
+
+	var excitationData struct {
+		Excitation float64 `json:"excitation"` // per unit
+	}
+	if err := c.ShouldBindJSON(&excitationData); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if excitationData.Excitation < 0 || excitationData.Excitation > sim.MAX_EXCITATION {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Excitation must be between 0 and %.1f per unit", sim.MAX_EXCITATION)})
+		return
+	}
+
+	generator := simulation.FindGenerator()
+	if generator.ExcitationMode() == sim.EXCITATION_CONTROL_AUTOMATIC {
+		c.JSON(http.StatusConflict, gin.H{"error": "Excitation is in automatic"})
+		return
+	}
+
+	generator.SetExcitation(excitationData.Excitation)
+	c.JSON(http.StatusOK, simulation.Status())
+}
+
+func setGeneratorVoltageSetpoint(c *gin.Context) {
+	simulationID := c.Param("id")
+	simulation, exists := simCache[simulationID]
+	if !exists {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Simulation not found"})
+		return
+	}
+
+	var setpointData struct {
+		Voltage float64 `json:"voltage"` // per unit
+	}
+	if err := c.ShouldBindJSON(&setpointData); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if setpointData.Voltage < sim.MIN_VOLTAGE_SETPOINT || setpointData.Voltage > sim.MAX_VOLTAGE_SETPOINT {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Voltage setpoint must be between %.2f and %.2f per unit", sim.MIN_VOLTAGE_SETPOINT, sim.MAX_VOLTAGE_SETPOINT)})
+		return
+	}
+
+	simulation.FindGenerator().SetVoltageSetpoint(setpointData.Voltage)
+	c.JSON(http.StatusOK, simulation.Status())
+}
+
+func configureGrid(c *gin.Context) {
+	simulationID := c.Param("id")
+	simulation, exists := simCache[simulationID]
+	if !exists {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Simulation not found"})
+		return
+	}
+
+	var gridData struct {
+		Mode              string  `json:"mode"`              // infiniteBus or finite
+		Load              float64 `json:"load"`              // MW, for a finite grid
+		FrequencyResponse float64 `json:"frequencyResponse"` // MW/Hz, for a finite grid
+	}
+	if err := c.ShouldBindJSON(&gridData); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	grid := simulation.FindGenerator().Grid()
+	switch gridData.Mode {
+	case sim.GRID_INFINITE_BUS:
+		grid.UseInfiniteBus()
+	case sim.GRID_FINITE:
+		if gridData.Load < 0 || gridData.FrequencyResponse < sim.MIN_GRID_FREQUENCY_RESPONSE {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("A finite grid needs a load of at least 0 MW and a frequency response of at least %.0f MW/Hz", sim.MIN_GRID_FREQUENCY_RESPONSE)})
+			return
+		}
+		grid.UseFiniteGrid(gridData.Load, gridData.FrequencyResponse)
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Grid mode must be %s or %s", sim.GRID_INFINITE_BUS, sim.GRID_FINITE)})
+		return
+	}
+	c.JSON(http.StatusOK, simulation.Status())
+}
+
+func setWeatherProfile(c *gin.Context) {
+	simulationID := c.Param("id")
+	simulation, exists := simCache[simulationID]
+	if !exists {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Simulation not found"})
+		return
+	}
+
+	var profileData struct {
+		MeanTemperature float64 `json:"meanTemperature"` // °C over the year
+		SeasonalSwing   float64 `json:"seasonalSwing"`   // °C from the mean to midsummer
+		DailySwing      float64 `json:"dailySwing"`      // °C from the daily mean to the afternoon high
+		Humidity        float64 `json:"humidity"`        // percent
+		WindSpeed       float64 `json:"windSpeed"`       // m/s
+	}
+	if err := c.ShouldBindJSON(&profileData); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if profileData.MeanTemperature < sim.MIN_MEAN_TEMPERATURE || profileData.MeanTemperature > sim.MAX_MEAN_TEMPERATURE {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Mean temperature must be between %.0f and %.0f °C", sim.MIN_MEAN_TEMPERATURE, sim.MAX_MEAN_TEMPERATURE)})
+		return
+	}
+	if profileData.SeasonalSwing < 0 || profileData.DailySwing < 0 || profileData.WindSpeed < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Temperature swings and wind speed cannot be negative"})
+		return
+	}
+	if profileData.Humidity < 0 || profileData.Humidity > 100 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Humidity must be between 0 and 100 percent"})
+		return
+	}
+
+	simulation.Climate().SetProfile(profileData.MeanTemperature, profileData.SeasonalSwing, profileData.DailySwing, profileData.Humidity, profileData.WindSpeed)
+	c.JSON(http.StatusOK, simulation.Status())
+}
+
+// loadWeatherFile takes a weather file as CSV in the request body
+func loadWeatherFile(c *gin.Context) {
+	simulationID := c.Param("id")
+	simulation, exists := simCache[simulationID]
+	if !exists {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Simulation not found"})
+		return
+	}
+
+	records, err := sim.ReadWeatherRecords(c.Request.Body)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	simulation.UseWeatherRecords(records)
+	c.JSON(http.StatusOK, simulation.Status())
+}
+
+func getAlarms(c *gin.Context) {
+	simulationID := c.Param("id")
+	simulation, exists := simCache[simulationID]
+	if !exists {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Simulation not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, simulation.Annunciator().Status())
+}
+
+func acknowledgeAllAlarms(c *gin.Context) {
+	simulationID := c.Param("id")
+	simulation, exists := simCache[simulationID]
+	if !exists {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Simulation not found"})
+		return
+	}
+
+	simulation.Annunciator().AcknowledgeAll()
+	c.JSON(http.StatusOK, simulation.Annunciator().Status())
+}
+
+func acknowledgeAlarm(c *gin.Context) {
+	simulationID := c.Param("id")
+	simulation, exists := simCache[simulationID]
+	if !exists {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Simulation not found"})
+		return
+	}
+
+	if err := simulation.Annunciator().Acknowledge(c.Param("alarm")); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, simulation.Annunciator().Status())
+}
+
+func resetAlarms(c *gin.Context) {
+	simulationID := c.Param("id")
+	simulation, exists := simCache[simulationID]
+	if !exists {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Simulation not found"})
+		return
+	}
+
+	simulation.Annunciator().Reset()
+	c.JSON(http.StatusOK, simulation.Annunciator().Status())
+}
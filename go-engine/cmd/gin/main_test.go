@@ -0,0 +1,60 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"won/sim-lab/go-engine/internal/sim"
+)
+
+func TestSpawnedPlantStaysShutDownWithShutdownBanksOut(t *testing.T) {
+	for _, loading := range []string{"beginning", "middle", "end"} {
+		simulation := spawnSimulation("Test Simulation", "Safety First")
+		reactorCore := simulation.FindReactorCore()
+		if err := reactorCore.LoadFuel(loading); err != nil {
+			t.Fatalf("Expected %s fuel to load: %v", loading, err)
+		}
+
+		for _, primaryLoop := range simulation.FindPrimaryLoops() {
+			if primaryLoop.BoronConcentration() != reactorCore.ShutdownBoronConcentration() {
+				t.Errorf("Expected %s loop at %.0f ppm, got %.0f", loading, reactorCore.ShutdownBoronConcentration(), primaryLoop.BoronConcentration())
+			}
+		}
+
+		reactorCore.WithdrawShutdownBanks()
+		for i := 0; i < 60 && !reactorCore.ControlRods().ShutdownBanksFullyWithdrawn(); i++ {
+			simulation.Run(1)
+			if reactorCore.Reactivity() >= 0 {
+				t.Fatalf("Expected %s core to stay subcritical, got %.0f pcm", loading, reactorCore.Reactivity())
+			}
+		}
+		if !reactorCore.ControlRods().ShutdownBanksFullyWithdrawn() {
+			t.Fatalf("Expected shutdown banks out for %s core", loading)
+		}
+		if reactorCore.Reactivity() > -sim.SHUTDOWN_MARGIN/2 {
+			t.Errorf("Expected %s core held well below critical, got %.0f pcm", loading, reactorCore.Reactivity())
+		}
+	}
+}
+
+func TestCreateSimulationRefusesAnUnknownCoreLoading(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	cached := len(simCache)
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodPost, "/api/sims", strings.NewReader(`{"name": "Test Simulation", "motto": "Safety First", "coreLoading": "eoc"}`))
+	c.Request.Header.Set("Content-Type", "application/json")
+	createSimulation(c)
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for an unknown core loading, got %d", w.Code)
+	}
+	if len(simCache) != cached {
+		t.Errorf("Expected no simulation kept for a refused request")
+	}
+}
@@ -0,0 +1,213 @@
+package sim
+
+import (
+	"fmt"
+	"time"
+)
+
+// The annunciator is the wall of alarm windows in the control room. Components
+// register the conditions they want watched, and the annunciator scans them at
+// the end of every tick.
+//
+// A window follows the usual ring-back sequence:
+//
+//	normal --condition in--> alerting (flashing, horn)
+//	alerting --acknowledge--> acknowledged (steady) while the condition is in
+//	acknowledged --condition clears--> cleared (waiting on reset)
+//	cleared --reset--> normal
+//
+// An alarm that comes in and clears before anyone acknowledges it stays
+// alerting, so nothing slips by unseen. The first alarm to come in while the
+// board is quiet is latched as the first-out, which points the operator at
+// what started a cascade; it is released by a reset once that window is normal.
+
+const (
+	ALARM_PRIORITY_HIGH   = 1
+	ALARM_PRIORITY_MEDIUM = 2
+	ALARM_PRIORITY_LOW    = 3
+)
+
+const (
+	ALARM_NORMAL       = "normal"
+	ALARM_ALERTING     = "alerting"
+	ALARM_ACKNOWLEDGED = "acknowledged"
+	ALARM_CLEARED      = "cleared"
+)
+
+type AlarmCondition struct {
+	name     string
+	message  string
+	priority int
+	check    func() bool // true while the alarm condition is in
+}
+
+func NewAlarmCondition(name string, message string, priority int, check func() bool) *AlarmCondition {
+	return &AlarmCondition{
+		name:     name,
+		message:  message,
+		priority: priority,
+		check:    check,
+	}
+}
+
+// components that want windows on the annunciator
+type AlarmSource interface {
+	AlarmConditions() []*AlarmCondition
+}
+
+type Alarm struct {
+	condition   *AlarmCondition
+	state       string
+	active      bool
+	activatedAt time.Time
+}
+
+func (a *Alarm) Name() string {
+	return a.condition.name
+}
+
+func (a *Alarm) State() string {
+	return a.state
+}
+
+func (a *Alarm) IsActive() bool {
+	return a.active
+}
+
+func (a *Alarm) Status() map[string]interface{} {
+	return map[string]interface{}{
+		"name":        a.condition.name,
+		"message":     a.condition.message,
+		"priority":    a.condition.priority,
+		"state":       a.state,
+		"active":      a.active,
+		"activatedAt": a.activatedAt,
+	}
+}
+
+type Annunciator struct {
+	alarms   []*Alarm
+	firstOut string
+}
+
+func NewAnnunciator() *Annunciator {
+	return &Annunciator{
+		alarms: make([]*Alarm, 0),
+	}
+}
+
+func (an *Annunciator) Register(conditions ...*AlarmCondition) {
+	for _, condition := range conditions {
+		an.alarms = append(an.alarms, &Alarm{condition: condition, state: ALARM_NORMAL})
+	}
+}
+
+// Scan checks every condition and moves the windows along their sequence
+func (an *Annunciator) Scan(now time.Time) {
+	for _, alarm := range an.alarms {
+		alarm.active = alarm.condition.check()
+
+		switch alarm.state {
+		case ALARM_NORMAL, ALARM_CLEARED:
+			if alarm.active {
+				alarm.state = ALARM_ALERTING
+				alarm.activatedAt = now
+				if an.firstOut == "" {
+					an.firstOut = alarm.condition.name
+				}
+			}
+		case ALARM_ACKNOWLEDGED:
+			if !alarm.active {
+				alarm.state = ALARM_CLEARED
+			}
+		}
+	}
+}
+
+func (an *Annunciator) findAlarm(name string) *Alarm {
+	for _, alarm := range an.alarms {
+		if alarm.condition.name == name {
+			return alarm
+		}
+	}
+	return nil
+}
+
+func (a *Alarm) acknowledge() {
+	if a.state != ALARM_ALERTING {
+		return
+	}
+	if a.active {
+		a.state = ALARM_ACKNOWLEDGED
+	} else {
+		a.state = ALARM_CLEARED
+	}
+}
+
+// AcknowledgeAll silences the horn and stops every window from flashing
+func (an *Annunciator) AcknowledgeAll() {
+	for _, alarm := range an.alarms {
+		alarm.acknowledge()
+	}
+}
+
+func (an *Annunciator) Acknowledge(name string) error {
+	alarm := an.findAlarm(name)
+	if alarm == nil {
+		return fmt.Errorf("no alarm named %s", name)
+	}
+	alarm.acknowledge()
+	return nil
+}
+
+// Reset returns acknowledged windows whose conditions have cleared to normal,
+// and releases the first-out once its window is normal again.
+func (an *Annunciator) Reset() {
+	for _, alarm := range an.alarms {
+		if alarm.state == ALARM_CLEARED {
+			alarm.state = ALARM_NORMAL
+		}
+	}
+	if first := an.findAlarm(an.firstOut); first == nil || first.state == ALARM_NORMAL {
+		an.firstOut = ""
+	}
+}
+
+func (an *Annunciator) FirstOut() string {
+	return an.firstOut
+}
+
+// alarms that are not normal, most important first
+func (an *Annunciator) ActiveAlarms() []*Alarm {
+	active := make([]*Alarm, 0)
+	for priority := ALARM_PRIORITY_HIGH; priority <= ALARM_PRIORITY_LOW; priority++ {
+		for _, alarm := range an.alarms {
+			if alarm.condition.priority == priority && alarm.state != ALARM_NORMAL {
+				active = append(active, alarm)
+			}
+		}
+	}
+	return active
+}
+
+// true while any window is flashing
+func (an *Annunciator) HornSounding() bool {
+	for _, alarm := range an.alarms {
+		if alarm.state == ALARM_ALERTING {
+			return true
+		}
+	}
+	return false
+}
+
+func (an *Annunciator) Status() map[string]interface{} {
+	alarms := make([]map[string]interface{}, 0)
+	for _, alarm := range an.ActiveAlarms() {
+		alarms = append(alarms, alarm.Status())
+	}
+	return map[string]interface{}{
+		"firstOut": an.firstOut,
+		"horn":     an.HornSounding(),
+		"alarms":   alarms,
+	}
+}
@@ -0,0 +1,116 @@
+package sim
+
+import (
+	"testing"
+	"time"
+)
+
+func TestAlarmSequence(t *testing.T) {
+	an := NewAnnunciator()
+	in := false
+	an.Register(NewAlarmCondition("test", "TEST", ALARM_PRIORITY_MEDIUM, func() bool { return in }))
+	alarm := an.findAlarm("test")
+	now := time.Now()
+
+	an.Scan(now)
+	if alarm.State() != ALARM_NORMAL || an.HornSounding() {
+		t.Errorf("Expected a quiet board, got %s", alarm.State())
+	}
+
+	in = true
+	an.Scan(now)
+	if alarm.State() != ALARM_ALERTING || !an.HornSounding() {
+		t.Errorf("Expected the alarm to come in flashing, got %s", alarm.State())
+	}
+
+	an.AcknowledgeAll()
+	if alarm.State() != ALARM_ACKNOWLEDGED || an.HornSounding() {
+		t.Errorf("Expected acknowledge to hold the window steady, got %s", alarm.State())
+	}
+
+	// reset does nothing while the condition is still in
+	an.Reset()
+	if alarm.State() != ALARM_ACKNOWLEDGED {
+		t.Errorf("Expected reset to leave an active alarm alone, got %s", alarm.State())
+	}
+
+	in = false
+	an.Scan(now)
+	if alarm.State() != ALARM_CLEARED {
+		t.Errorf("Expected the alarm to wait on reset once cleared, got %s", alarm.State())
+	}
+
+	an.Reset()
+	if alarm.State() != ALARM_NORMAL {
+		t.Errorf("Expected reset to return the window to normal, got %s", alarm.State())
+	}
+}
+
+func TestAlarmLocksInUntilAcknowledged(t *testing.T) {
+	an := NewAnnunciator()
+	in := true
+	an.Register(NewAlarmCondition("test", "TEST", ALARM_PRIORITY_MEDIUM, func() bool { return in }))
+	alarm := an.findAlarm("test")
+
+	an.Scan(time.Now())
+	in = false
+	an.Scan(time.Now())
+	if alarm.State() != ALARM_ALERTING {
+		t.Errorf("Expected a fleeting alarm to keep flashing until acknowledged, got %s", alarm.State())
+	}
+
+	if err := an.Acknowledge("test"); err != nil {
+		t.Errorf("Expected to acknowledge the alarm: %v", err)
+	}
+	if alarm.State() != ALARM_CLEARED {
+		t.Errorf("Expected an acknowledged, cleared alarm to wait on reset, got %s", alarm.State())
+	}
+	if err := an.Acknowledge("nope"); err == nil {
+		t.Errorf("Expected an unknown alarm to be refused")
+	}
+}
+
+func TestFirstOutAlarmLatches(t *testing.T) {
+	an := NewAnnunciator()
+	firstIn, secondIn := false, false
+	an.Register(
+		NewAlarmCondition("second", "SECOND", ALARM_PRIORITY_LOW, func() bool { return secondIn }),
+		NewAlarmCondition("first", "FIRST", ALARM_PRIORITY_HIGH, func() bool { return firstIn }),
+	)
+
+	firstIn = true
+	an.Scan(time.Now())
+	secondIn = true
+	an.Scan(time.Now())
+	if an.FirstOut() != "first" {
+		t.Errorf("Expected the first alarm in to be the first-out, got %s", an.FirstOut())
+	}
+	if active := an.ActiveAlarms(); len(active) != 2 || active[0].Name() != "first" {
+		t.Errorf("Expected both alarms, highest priority first")
+	}
+
+	an.AcknowledgeAll()
+	firstIn = false
+	an.Scan(time.Now())
+	an.Reset()
+	if an.FirstOut() != "" {
+		t.Errorf("Expected reset to release the first-out once it is normal, got %s", an.FirstOut())
+	}
+}
+
+func TestSimulationScansComponentAlarms(t *testing.T) {
+	simulation := NewSimulation("Sim for Alarm Testing", "Ring ring.")
+	turbine := NewSteamTurbine("Test Turbine")
+	simulation.AddComponent(turbine)
+
+	turbine.Trip()
+	simulation.annunciator.Scan(simulation.CurrentTime())
+	if simulation.Annunciator().FirstOut() != "turbineTrip" {
+		t.Errorf("Expected a tripped turbine to bring in its alarm, first out: %s", simulation.Annunciator().FirstOut())
+	}
+
+	alarms := simulation.Status()["alarms"].(map[string]interface{})["alarms"].([]map[string]interface{})
+	if len(alarms) != 1 {
+		t.Errorf("Expected the alarm to show in the simulation status, got %v", alarms)
+	}
+}
@@ -6,14 +6,17 @@ import (
 
 type Environment struct {
 	Weather            string
-	AmbientTemperature float64
+	AmbientTemperature float64 // °C
+	RelativeHumidity   float64 // percent
+	WindSpeed          float64 // m/s
 	PowerOn            bool
 }
 
 func NewEnvironment() *Environment {
 	return &Environment{
-		Weather:            "sunny",
+		Weather:            WEATHER_SUNNY,
 		AmbientTemperature: ROOM_TEMPERATURE,
+		RelativeHumidity:   ROOM_HUMIDITY,
 		PowerOn:            true,
 	}
 }
@@ -23,8 +26,12 @@ func (e *Environment) String() string {
 }
 
 const ROOM_TEMPERATURE = 20.0
+const ROOM_HUMIDITY = 50.0 // percent
 const TURBINE_MAX_RPM = 3600
 
+// each iteration of the simulation covers one minute
+const SECONDS_PER_TICK = 60.0
+
 // common run durations, assuming 1-minute iterations
 const HOUR_OF_MINUTES = 60
 const DAY_OF_MINUTES = HOUR_OF_MINUTES * 24
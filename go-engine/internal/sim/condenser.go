@@ -2,51 +2,232 @@ package sim
 
 import (
 	"fmt"
+	"math"
+
+	"won/sim-lab/go-engine/internal/steam"
 )
 
 type Condenser struct {
 	BaseComponent
-	entryTemperature float64 // in Celsius
-	exitTemperature  float64 // in Celsius
-	heatTransferRate float64 // in Watts
+	entryTemperature        float64 // in Celsius; exhaust steam condenses at this temperature
+	exitTemperature         float64 // in Celsius
+	heatTransferRate        float64 // in Watts, into the cooling water
+	steamLoad               float64 // in Watts, given up by the steam condensing
+	pressure                float64 // in MPa; the backpressure on the turbine exhaust
+	airMass                 float64 // kg of air in the shell
+	vacuumPumpsOn           bool
+	vacuumBreakerOpen       bool
+	coolingWaterFlowRate    float64 // kg/s through the tubes
+	coolingWaterInletTemp   float64 // in Celsius
+	coolingWaterOutletTemp  float64 // in Celsius
+	hotwellMass             float64 // kg of condensate
+	makeupFlowRate          float64 // kg/s; negative when rejecting condensate to storage
+	condensateFlowRate      float64 // kg/s of steam condensed
+	feedwaterDemandFlowRate float64 // kg/s drawn off by the feedwater pumps
 }
 
+// The exhaust steam condenses on tubes carrying cooling water from the
+// tertiary loop. The shell sits at the temperature where the heat the steam
+// gives up is carried off by the cooling water; how well the tubes do that is
+// their effectiveness, which falls off with less cooling water flow and with
+// air blanketing the tubes. The pressure in the shell is the saturation
+// pressure at that temperature plus whatever air has leaked in. The vacuum
+// pumps keep drawing the air out; the vacuum breaker lets it in on purpose to
+// slow the turbine down.
+//
+// Condensate collects in the hotwell a little below saturation. The
+// condensate pumps draw it off to the feedwater pumps, and the hotwell level
+// control makes up from or rejects to condensate storage.
+const CONDENSER_UA = 140000.0             // kW/°C, clean tubes
+const CONDENSER_HEAT_CAPACITY = 2000000.0 // kJ/°C of shell, tubes and hotwell
+const CONDENSATE_SUBCOOLING = 2.0         // in Celsius
+const CONDENSER_SHELL_VOLUME = 3000.0     // m³
+const AIR_GAS_CONSTANT = 287.0            // J/kg/K
+const AIR_INLEAKAGE = 0.02                // kg/s through seals and flanges
+const AIR_REMOVAL_TIME = 30.0             // seconds for the vacuum pumps to draw the air down
+const AIR_BLANKETING_MASS = 100.0         // kg of air that halves the effectiveness of the tubes
+const VACUUM_BREAKER_FLOW = 30.0          // kg/s of air through an open vacuum breaker
+const LOW_VACUUM_ALARM_PRESSURE = 0.015   // MPa
+const LOW_VACUUM_TRIP_PRESSURE = 0.025    // MPa
+const HOTWELL_CAPACITY = 300000.0         // kg
+const HOTWELL_NORMAL_LEVEL = 50.0         // percent
+const HOTWELL_LOW_LEVEL = 25.0            // percent
+const HOTWELL_HIGH_LEVEL = 75.0           // percent
+const HOTWELL_CONTROL_TIME = 600.0        // seconds for level control to close the gap
+const HOTWELL_MAKEUP_CAPACITY = 100.0     // kg/s either way
+
 func NewCondenser(name string) *Condenser {
 	return &Condenser{
-		BaseComponent:    BaseComponent{Name: name},
-		entryTemperature: 100.0, // Initial values, can be adjusted as needed
-		exitTemperature:  40.0,
-		heatTransferRate: 1000000.0, // 1 MW, for example
+		BaseComponent:          BaseComponent{Name: name},
+		entryTemperature:       40.0, // Initial values, can be adjusted as needed
+		exitTemperature:        38.0,
+		heatTransferRate:       0.0,
+		pressure:               DESIGN_CONDENSER_PRESSURE,
+		vacuumPumpsOn:          true,
+		coolingWaterInletTemp:  ROOM_TEMPERATURE,
+		coolingWaterOutletTemp: ROOM_TEMPERATURE,
+		hotwellMass:            HOTWELL_CAPACITY * HOTWELL_NORMAL_LEVEL / 100,
 	}
 }
 
 func (c *Condenser) Update(env *Environment, s *Simulation) {
-	// Simplified update logic
-	// In a real scenario, this would involve complex thermodynamics calculations
 	turbine := s.FindSteamTurbine()
 	if turbine == nil {
 		fmt.Println("No turbine found")
 		return
 	}
 
-	// Adjust entry temperature based on turbine exhaust (simplified)
-	c.entryTemperature = 100.0 - (1000.0-float64(turbine.rpm))*0.1
+	// cooling water comes from the tertiary loop; without one, the plant
+	// draws water at ambient temperature with every circulating pump running
+	c.coolingWaterFlowRate = CIRCULATING_WATER_PUMPS * CIRCULATING_WATER_PUMP_FLOW
+	c.coolingWaterInletTemp = env.AmbientTemperature
+	if tertiaryLoop := s.FindTertiaryLoop(); tertiaryLoop != nil {
+		c.coolingWaterFlowRate = tertiaryLoop.FlowRate()
+		c.coolingWaterInletTemp = tertiaryLoop.ColdWaterTemperature()
+	}
+
+	// heat given up condensing the wet exhaust steam, and any steam dumped
+	// straight from the steam line
+	condensate := steam.SaturatedLiquid(c.pressure).Enthalpy
+	c.steamLoad = math.Max(0, turbine.ExhaustFlowRate()*(turbine.ExhaustEnthalpy()-condensate)*1000)
+	c.steamLoad += math.Max(0, turbine.SteamDumpFlowRate()*(turbine.SteamDumpEnthalpy()-condensate)*1000)
+	c.steamLoad += math.Max(0, turbine.HeaterDrainFlowRate()*(turbine.HeaterDrainEnthalpy()-condensate)*1000)
+	c.condensateFlowRate = turbine.ExhaustFlowRate() + turbine.SteamDumpFlowRate() + turbine.HeaterDrainFlowRate()
 
-	// Calculate exit temperature (simplified)
-	c.exitTemperature = c.entryTemperature - (c.heatTransferRate * 0.00001)
+	c.updateAir()
+	c.updateShell()
+	c.updateHotwell(s)
+}
 
-	// Ensure temperatures don't go below ambient
-	if c.exitTemperature < float64(env.AmbientTemperature) {
-		c.exitTemperature = float64(env.AmbientTemperature)
+// updateAir lets air leak in and has the vacuum pumps draw it out
+func (c *Condenser) updateAir() {
+	inleakage := AIR_INLEAKAGE
+	if c.vacuumBreakerOpen {
+		inleakage += VACUUM_BREAKER_FLOW
+	}
+	if c.vacuumPumpsOn {
+		drawnDown := inleakage * AIR_REMOVAL_TIME
+		c.airMass = drawnDown + (c.airMass-drawnDown)*math.Exp(-SECONDS_PER_TICK/AIR_REMOVAL_TIME)
+	} else {
+		c.airMass += inleakage * SECONDS_PER_TICK
 	}
+	// the shell cannot hold more air than it takes to fill it at atmospheric pressure
+	c.airMass = math.Min(c.airMass, c.maxAirMass())
+}
+
+func (c *Condenser) maxAirMass() float64 {
+	return steam.ATMOSPHERIC_PRESSURE * 1e6 * CONDENSER_SHELL_VOLUME / (AIR_GAS_CONSTANT * (c.entryTemperature + 273.15))
+}
+
+// MPa of air in the shell
+func (c *Condenser) airPressure() float64 {
+	return c.airMass * AIR_GAS_CONSTANT * (c.entryTemperature + 273.15) / CONDENSER_SHELL_VOLUME / 1e6
+}
+
+// updateShell settles the shell temperature where the cooling water carries
+// off the heat of the steam; what it cannot carry off heats the shell up
+func (c *Condenser) updateShell() {
+	capacity := c.coolingWaterFlowRate * COOLING_WATER_SPECIFIC_HEAT // kW/°C
+	conductance := 0.0                                               // kW/°C from shell to cooling water
+	if capacity > 0 {
+		ntu := CONDENSER_UA / (1 + c.airMass/AIR_BLANKETING_MASS) / capacity
+		conductance = capacity * (1 - math.Exp(-ntu))
+	}
+
+	heatIn := c.steamLoad / 1000 // kW
+	if conductance > 0 {
+		settled := c.coolingWaterInletTemp + heatIn/conductance
+		c.entryTemperature = settled + (c.entryTemperature-settled)*math.Exp(-conductance*SECONDS_PER_TICK/CONDENSER_HEAT_CAPACITY)
+	} else {
+		c.entryTemperature += heatIn * SECONDS_PER_TICK / CONDENSER_HEAT_CAPACITY
+	}
+	// the atmospheric relief diaphragms keep the shell from going above atmospheric
+	c.entryTemperature = math.Min(c.entryTemperature, steam.SaturationTemperature(steam.ATMOSPHERIC_PRESSURE))
+
+	c.heatTransferRate = math.Max(0, conductance*(c.entryTemperature-c.coolingWaterInletTemp)*1000)
+	c.coolingWaterOutletTemp = c.coolingWaterInletTemp
+	if capacity > 0 {
+		c.coolingWaterOutletTemp += c.heatTransferRate / 1000 / capacity
+	}
+
+	c.pressure = math.Min(steam.ATMOSPHERIC_PRESSURE, steam.SaturationPressure(c.entryTemperature)+c.airPressure())
+
+	// condensate collects in the hotwell a little below saturation, though
+	// never colder than the cooling water
+	c.exitTemperature = math.Max(c.coolingWaterInletTemp, c.entryTemperature-CONDENSATE_SUBCOOLING)
+}
+
+// updateHotwell takes in the condensate, gives up what the feedwater pumps
+// draw, and has level control make up or reject the difference
+func (c *Condenser) updateHotwell(s *Simulation) {
+	c.feedwaterDemandFlowRate = 0
+	for _, steamGenerator := range s.FindSteamGenerators() {
+		c.feedwaterDemandFlowRate += steamGenerator.FeedwaterFlowRate()
+	}
+
+	target := HOTWELL_CAPACITY * HOTWELL_NORMAL_LEVEL / 100
+	c.makeupFlowRate = math.Max(-HOTWELL_MAKEUP_CAPACITY, math.Min(HOTWELL_MAKEUP_CAPACITY, (target-c.hotwellMass)/HOTWELL_CONTROL_TIME))
+	c.hotwellMass += (c.condensateFlowRate - c.feedwaterDemandFlowRate + c.makeupFlowRate) * SECONDS_PER_TICK
+	c.hotwellMass = math.Max(0, math.Min(HOTWELL_CAPACITY, c.hotwellMass))
+}
+
+func (c *Condenser) SwitchOnVacuumPumps() {
+	c.vacuumPumpsOn = true
+}
+
+func (c *Condenser) SwitchOffVacuumPumps() {
+	c.vacuumPumpsOn = false
+}
+
+func (c *Condenser) VacuumPumpsOn() bool {
+	return c.vacuumPumpsOn
+}
+
+func (c *Condenser) OpenVacuumBreaker() {
+	c.vacuumBreakerOpen = true
+}
+
+func (c *Condenser) CloseVacuumBreaker() {
+	c.vacuumBreakerOpen = false
+}
+
+func (c *Condenser) VacuumBreakerOpen() bool {
+	return c.vacuumBreakerOpen
+}
+
+// Available says whether the condenser can take steam: it has vacuum and
+// cooling water going through it
+func (c *Condenser) Available() bool {
+	return c.pressure < LOW_VACUUM_TRIP_PRESSURE && c.coolingWaterFlowRate > 0
+}
+
+// percent
+func (c *Condenser) HotwellLevel() float64 {
+	return c.hotwellMass / HOTWELL_CAPACITY * 100
+}
+
+// in Watts, given up by the steam condensing
+func (c *Condenser) SteamLoad() float64 {
+	return c.steamLoad
 }
 
 func (c *Condenser) Status() map[string]interface{} {
 	return map[string]interface{}{
-		"name":             c.Name,
-		"entryTemperature": c.entryTemperature,
-		"exitTemperature":  c.exitTemperature,
-		"heatTransferRate": c.heatTransferRate,
+		"name":                          c.Name,
+		"entryTemperature":              c.entryTemperature,
+		"exitTemperature":               c.exitTemperature,
+		"heatTransferRate":              c.heatTransferRate,
+		"steamLoad":                     c.steamLoad,
+		"pressure":                      c.pressure,
+		"airMass":                       c.airMass,
+		"vacuumPumpsOn":                 c.vacuumPumpsOn,
+		"vacuumBreakerOpen":             c.vacuumBreakerOpen,
+		"available":                     c.Available(),
+		"coolingWaterFlowRate":          c.coolingWaterFlowRate,
+		"coolingWaterInletTemperature":  c.coolingWaterInletTemp,
+		"coolingWaterOutletTemperature": c.coolingWaterOutletTemp,
+		"hotwellLevel":                  c.HotwellLevel(),
+		"makeupFlowRate":                c.makeupFlowRate,
 	}
 }
 
@@ -55,4 +236,29 @@ func (c *Condenser) PrintStatus() {
 	fmt.Printf("\tEntry Temperature: %.2f °C\n", c.entryTemperature)
 	fmt.Printf("\tExit Temperature: %.2f °C\n", c.exitTemperature)
 	fmt.Printf("\tHeat Transfer Rate: %.2f W\n", c.heatTransferRate)
+	fmt.Printf("\tPressure: %.4f MPa\n", c.pressure)
+	fmt.Printf("\tAir In Shell: %.1f kg\n", c.airMass)
+	fmt.Printf("\tVacuum Pumps: %s\n", boolToString(c.vacuumPumpsOn))
+	fmt.Printf("\tVacuum Breaker Open: %t\n", c.vacuumBreakerOpen)
+	fmt.Printf("\tCooling Water: %.0f kg/s, %.2f °C in, %.2f °C out\n", c.coolingWaterFlowRate, c.coolingWaterInletTemp, c.coolingWaterOutletTemp)
+	fmt.Printf("\tHotwell Level: %.1f%%\n", c.HotwellLevel())
+}
+
+// MPa, the backpressure on the turbine exhaust
+func (c *Condenser) Pressure() float64 {
+	return c.pressure
+}
+
+func (c *Condenser) AlarmConditions() []*AlarmCondition {
+	return []*AlarmCondition{
+		NewAlarmCondition("condenserLowVacuum", "CONDENSER LOW VACUUM", ALARM_PRIORITY_MEDIUM, func() bool {
+			return c.pressure >= LOW_VACUUM_ALARM_PRESSURE
+		}),
+		NewAlarmCondition("hotwellLevelLow", "HOTWELL LEVEL LOW", ALARM_PRIORITY_LOW, func() bool {
+			return c.HotwellLevel() < HOTWELL_LOW_LEVEL
+		}),
+		NewAlarmCondition("hotwellLevelHigh", "HOTWELL LEVEL HIGH", ALARM_PRIORITY_LOW, func() bool {
+			return c.HotwellLevel() > HOTWELL_HIGH_LEVEL
+		}),
+	}
 }
@@ -0,0 +1,86 @@
+package sim
+
+import (
+	"testing"
+)
+
+// the steam cycle at rated power, with a cooling tower carrying off the heat
+func setUpCondensingPlant() (*Simulation, *Environment) {
+	simulation, env := setUpSteamCycle()
+	tertiaryLoop := NewTertiaryLoop("Test Tertiary Loop", HEAT_SINK_COOLING_TOWER)
+	tertiaryLoop.StartAllPumps()
+	simulation.AddComponent(tertiaryLoop)
+	for i := 0; i < 3; i++ {
+		updateCondensingPlant(simulation, env)
+	}
+	return simulation, env
+}
+
+func updateCondensingPlant(simulation *Simulation, env *Environment) {
+	updateSteamCycle(simulation, env)
+	simulation.FindTertiaryLoop().Update(env, simulation)
+}
+
+func TestCondenserHoldsVacuumAtRatedLoad(t *testing.T) {
+	simulation, env := setUpCondensingPlant()
+	condenser := simulation.FindCondenser()
+	for i := 0; i < 5; i++ {
+		updateCondensingPlant(simulation, env)
+	}
+
+	if condenser.Pressure() > 1.5*DESIGN_CONDENSER_PRESSURE || !condenser.Available() {
+		t.Errorf("Expected the condenser near design vacuum, got %f MPa", condenser.Pressure())
+	}
+	// the cooling water carries off the heat of the steam
+	if !almostEqual(condenser.heatTransferRate, condenser.SteamLoad(), 0.02*condenser.SteamLoad()) {
+		t.Errorf("Expected the cooling water to take the steam's heat, %f W of %f", condenser.heatTransferRate, condenser.SteamLoad())
+	}
+	if !almostEqual(condenser.HotwellLevel(), HOTWELL_NORMAL_LEVEL, 5) {
+		t.Errorf("Expected level control to hold the hotwell near %f%%, got %f", HOTWELL_NORMAL_LEVEL, condenser.HotwellLevel())
+	}
+}
+
+func TestLosingCirculatingWaterTripsTheTurbine(t *testing.T) {
+	simulation, env := setUpCondensingPlant()
+	condenser := simulation.FindCondenser()
+	turbine := simulation.FindSteamTurbine()
+
+	simulation.FindTertiaryLoop().StopAllPumps()
+	for i := 0; i < 3; i++ {
+		updateCondensingPlant(simulation, env)
+	}
+
+	if condenser.Pressure() < LOW_VACUUM_TRIP_PRESSURE || condenser.Available() {
+		t.Errorf("Expected the condenser to lose vacuum without cooling water, got %f MPa", condenser.Pressure())
+	}
+	if !turbine.IsTripped() || turbine.TripCause() != TURBINE_TRIP_LOW_VACUUM {
+		t.Errorf("Expected a low vacuum turbine trip, got cause %q", turbine.TripCause())
+	}
+	// with no condenser to take it, the steam dump stays shut
+	if turbine.SteamDumpFlowRate() != 0 {
+		t.Errorf("Expected the steam dump blocked, got %f kg/s", turbine.SteamDumpFlowRate())
+	}
+}
+
+func TestVacuumBreakerLetsAirIn(t *testing.T) {
+	simulation, env := setUpCondensingPlant()
+	condenser := simulation.FindCondenser()
+	turbine := simulation.FindSteamTurbine()
+	turbine.Trip()
+
+	condenser.OpenVacuumBreaker()
+	for i := 0; i < 3; i++ {
+		updateCondensingPlant(simulation, env)
+	}
+	if condenser.Pressure() < LOW_VACUUM_TRIP_PRESSURE {
+		t.Errorf("Expected the vacuum breaker to break vacuum, got %f MPa", condenser.Pressure())
+	}
+
+	condenser.CloseVacuumBreaker()
+	for i := 0; i < 10; i++ {
+		updateCondensingPlant(simulation, env)
+	}
+	if !condenser.Available() {
+		t.Errorf("Expected the vacuum pumps to draw vacuum back down, got %f MPa", condenser.Pressure())
+	}
+}
@@ -1,35 +1,90 @@
 package sim
 
+import (
+	"math"
+)
+
+const MAX_WITHDRAWAL_STEPS = 250
+
+// total worth of one bank, fully inserted to fully withdrawn, in pcm
 const (
-	MAX_WITHDRAWAL_STEPS = 250
-	WITHDRAWAL_RATE      = 50 // steps per minute
+	CONTROL_BANK_WORTH  = 1500.0
+	GRAY_BANK_WORTH     = 250.0 // fewer, weaker absorbers
+	SHUTDOWN_BANK_WORTH = 2000.0
 )
 
 type ControlBank struct {
 	label    string
 	numRods  int
-	position int // in steps, from 0 to MaxWithdrawalSteps
-	target   int // target position
+	worth    float64 // in pcm, fully inserted to fully withdrawn
+	position int     // in steps, from 0 to MaxWithdrawalSteps
+	target   int     // target position
+	stepRate float64 // steps per minute the rod drive mechanisms move the bank
+	travel   float64 // fraction of a step the drive has covered toward the next one
 }
 
-func NewControlBank(label string, numRods int) *ControlBank {
+func NewControlBank(label string, numRods int, worth float64, stepRate float64) *ControlBank {
 	return &ControlBank{
 		label:    label,
 		numRods:  numRods,
+		worth:    worth,
 		position: 0,
 		target:   0,
+		stepRate: stepRate,
 	}
 }
 
 func (cb *ControlBank) Status() map[string]interface{} {
 	return map[string]interface{}{
-		"label":    cb.label,
-		"numRods":  cb.numRods,
-		"position": cb.position,
-		"target":   cb.target,
+		"label":             cb.label,
+		"numRods":           cb.numRods,
+		"position":          cb.position,
+		"target":            cb.target,
+		"stepRate":          cb.stepRate,
+		"rodBottom":         cb.RodBottom(),
+		"atTop":             cb.AtTop(),
+		"worth":             cb.worth,
+		"reactivity":        cb.Reactivity(),
+		"differentialWorth": cb.DifferentialWorth(),
 	}
 }
 
+// Rods are worth little at the ends of their travel, where flux is low, and
+// most in the middle of the core, which gives the S-shaped integral worth curve:
+//
+//	ρ(z) = W * (z/H - sin(2πz/H) / 2π)
+//
+// where z is the withdrawn height, H the full travel and W the bank's total worth.
+func integralWorth(worth float64, position int) float64 {
+	z := float64(position) / MAX_WITHDRAWAL_STEPS
+	return worth * (z - math.Sin(2*math.Pi*z)/(2*math.Pi))
+}
+
+// in pcm; what a bank at the given position holds down, negative until fully withdrawn
+func rodReactivity(worth float64, position int) float64 {
+	return integralWorth(worth, position) - worth
+}
+
+// in pcm; the worth withdrawn so far, from the bottom of the core
+func (cb *ControlBank) IntegralWorth() float64 {
+	return integralWorth(cb.worth, cb.position)
+}
+
+// in pcm per step, at the current position
+func (cb *ControlBank) DifferentialWorth() float64 {
+	z := float64(cb.position) / MAX_WITHDRAWAL_STEPS
+	return cb.worth * (1 - math.Cos(2*math.Pi*z)) / MAX_WITHDRAWAL_STEPS
+}
+
+// in pcm; negative reactivity the bank holds in the core
+func (cb *ControlBank) Reactivity() float64 {
+	return rodReactivity(cb.worth, cb.position)
+}
+
+func (cb *ControlBank) Worth() float64 {
+	return cb.worth
+}
+
 func (cb *ControlBank) Label() string {
 	return cb.label
 }
@@ -57,11 +112,7 @@ func (cb *ControlBank) SetTarget(target int) {
 }
 
 func (cb *ControlBank) Update() {
-	if cb.position < cb.target {
-		cb.position = min(cb.position+WITHDRAWAL_RATE, cb.target)
-	} else if cb.position > cb.target {
-		cb.position = max(cb.position-WITHDRAWAL_RATE, cb.target)
-	}
+	cb.Advance(SECONDS_PER_TICK)
 }
 
 func min(a, b int) int {
@@ -89,19 +140,22 @@ func (cb *ControlBank) IsFullyInserted() bool {
 func (cb *ControlBank) Scram() {
 	cb.position = 0
 	cb.target = 0
+	cb.travel = 0
 }
 
 type ShutdownBank struct {
 	ControlBank
 }
 
-func NewShutdownBank(label string, numRods int) *ShutdownBank {
+func NewShutdownBank(label string, numRods int, worth float64, stepRate float64) *ShutdownBank {
 	return &ShutdownBank{
 		ControlBank: ControlBank{
 			label:    label,
 			numRods:  numRods,
+			worth:    worth,
 			position: 0,
 			target:   0,
+			stepRate: stepRate,
 		},
 	}
 }
@@ -14,32 +14,41 @@ import (
 // critical. At that point, put the control rods back a few positions, and that
 // should do it.
 
-// Not sure what to do with the gray banks yet. They should be useful for modeling
-// "load following," whatever that is.
+// Gray banks hold fewer, weaker absorbers. They are worth a fraction of a
+// control bank, so they can trim reactivity in small steps while following load
+// without distorting the power shape the way a full-strength bank would.
 
 type ControlRods struct {
 	controlBanks          [4]*ControlBank  // full-strength absorbers; for power control during operation
 	grayBanks             [2]*ControlBank  // lower neutron absorption; for load following and fine reactivity control during operation
 	shutdownBanks         [4]*ShutdownBank // full-strength absorbers; for shutting down the core rapidly
 	withdrawShutdownBanks bool             // rods go up when true, down when false
+	bankOverlap           int              // steps consecutive control banks move together
+	bankDemand            int              // position along the control bank sequence, in steps
+	rodControlMode        string           // manual or automatic
+	referenceTemperature  float64          // Tref in °C, from the average temperature program
 }
 
 func NewControlRods() *ControlRods {
-	cr := &ControlRods{}
+	cr := &ControlRods{
+		bankOverlap:          BANK_OVERLAP_STEPS,
+		rodControlMode:       ROD_CONTROL_MANUAL,
+		referenceTemperature: NO_LOAD_AVERAGE_TEMPERATURE,
+	}
 
 	// Initialize Control Banks
-	cr.controlBanks[0] = NewControlBank("MA1", 4)
-	cr.controlBanks[1] = NewControlBank("MA2", 4)
-	cr.controlBanks[2] = NewControlBank("MB1", 4)
-	cr.controlBanks[3] = NewControlBank("MB2", 4)
+	cr.controlBanks[0] = NewControlBank("MA1", 4, CONTROL_BANK_WORTH, CONTROL_BANK_STEP_RATE)
+	cr.controlBanks[1] = NewControlBank("MA2", 4, CONTROL_BANK_WORTH, CONTROL_BANK_STEP_RATE)
+	cr.controlBanks[2] = NewControlBank("MB1", 4, CONTROL_BANK_WORTH, CONTROL_BANK_STEP_RATE)
+	cr.controlBanks[3] = NewControlBank("MB2", 4, CONTROL_BANK_WORTH, CONTROL_BANK_STEP_RATE)
 
-	cr.grayBanks[0] = NewControlBank("GR1", 8)
-	cr.grayBanks[1] = NewControlBank("GR2", 8)
+	cr.grayBanks[0] = NewControlBank("GR1", 8, GRAY_BANK_WORTH, GRAY_BANK_STEP_RATE)
+	cr.grayBanks[1] = NewControlBank("GR2", 8, GRAY_BANK_WORTH, GRAY_BANK_STEP_RATE)
 
-	cr.shutdownBanks[0] = NewShutdownBank("SD1", 8)
-	cr.shutdownBanks[1] = NewShutdownBank("SD2", 8)
-	cr.shutdownBanks[2] = NewShutdownBank("SD3", 8)
-	cr.shutdownBanks[3] = NewShutdownBank("SD4", 8)
+	cr.shutdownBanks[0] = NewShutdownBank("SD1", 8, SHUTDOWN_BANK_WORTH, SHUTDOWN_BANK_STEP_RATE)
+	cr.shutdownBanks[1] = NewShutdownBank("SD2", 8, SHUTDOWN_BANK_WORTH, SHUTDOWN_BANK_STEP_RATE)
+	cr.shutdownBanks[2] = NewShutdownBank("SD3", 8, SHUTDOWN_BANK_WORTH, SHUTDOWN_BANK_STEP_RATE)
+	cr.shutdownBanks[3] = NewShutdownBank("SD4", 8, SHUTDOWN_BANK_WORTH, SHUTDOWN_BANK_STEP_RATE)
 
 	return cr
 }
@@ -104,53 +113,51 @@ func (cr *ControlRods) AverageControlRodExtraction() float64 {
 	return float64(totalSteps) / float64(maxSteps) * 100
 }
 
-func (cr *ControlRods) Scram() {
+// in pcm; total reactivity held down by every bank in the core
+func (cr *ControlRods) Reactivity() float64 {
+	reactivity := 0.0
 	for _, bank := range cr.controlBanks {
-		bank.Scram()
+		reactivity += bank.Reactivity()
 	}
 	for _, bank := range cr.grayBanks {
-		bank.Scram()
+		reactivity += bank.Reactivity()
 	}
 	for _, bank := range cr.shutdownBanks {
-		bank.Scram()
+		reactivity += bank.Reactivity()
 	}
-	cr.withdrawShutdownBanks = false
+	return reactivity
 }
 
-func (cr *ControlRods) Update() {
-	if cr.withdrawShutdownBanks {
-		for _, bank := range cr.shutdownBanks {
-			if !bank.IsFullyWithdrawn() {
-				if bank.Target() == 0 {
-					bank.Withdraw()
-				}
-				break
-			}
-		}
-	} else {
-		for _, bank := range cr.shutdownBanks {
-			if !bank.IsFullyInserted() {
-				if bank.Target() != 0 {
-					bank.Insert()
-				}
-				break
-			}
-		}
-	}
-
+// Scram drops every bank to the bottom and takes rod control out of
+// automatic, so nothing drives the banks back out
+func (cr *ControlRods) Scram() {
 	for _, bank := range cr.controlBanks {
-		bank.Update()
+		bank.Scram()
 	}
 	for _, bank := range cr.grayBanks {
-		bank.Update()
+		bank.Scram()
 	}
 	for _, bank := range cr.shutdownBanks {
-		bank.Update()
+		bank.Scram()
 	}
+	cr.withdrawShutdownBanks = false
+	cr.bankDemand = 0
+	cr.rodControlMode = ROD_CONTROL_MANUAL
+}
+
+func (cr *ControlRods) Update() {
+	cr.Advance(SECONDS_PER_TICK)
 }
 
 func (cr *ControlRods) Status() map[string]interface{} {
 	status := make(map[string]interface{})
+	status["reactivity"] = cr.Reactivity()
+	status["rodControlMode"] = cr.rodControlMode
+	status["bankDemand"] = cr.bankDemand
+	status["bankOverlap"] = cr.bankOverlap
+	status["referenceTemperature"] = cr.referenceTemperature
+	status["shutdownBanksWithdrawing"] = cr.withdrawShutdownBanks
+	status["rodBottom"] = cr.RodBottomBanks()
 
 	for _, bank := range cr.controlBanks {
 		status[bank.Label()] = bank.Status()
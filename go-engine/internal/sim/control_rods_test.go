@@ -160,3 +160,174 @@ func TestAdjustControlBankPosition(t *testing.T) {
 		}
 	}
 }
+
+func TestRodWorthIsSShaped(t *testing.T) {
+	bank := NewControlBank("MA1", 4, CONTROL_BANK_WORTH, CONTROL_BANK_STEP_RATE)
+
+	if !almostEqual(bank.Reactivity(), -CONTROL_BANK_WORTH, 0.001) {
+		t.Errorf("Expected a fully inserted bank to hold its full worth, got %f", bank.Reactivity())
+	}
+
+	bank.position = 10
+	bottom := bank.DifferentialWorth()
+	bank.position = MAX_WITHDRAWAL_STEPS / 2
+	middle := bank.DifferentialWorth()
+	if !almostEqual(bank.IntegralWorth(), CONTROL_BANK_WORTH/2, 0.001) {
+		t.Errorf("Expected half the worth at half withdrawal, got %f", bank.IntegralWorth())
+	}
+	bank.position = MAX_WITHDRAWAL_STEPS - 10
+	top := bank.DifferentialWorth()
+
+	if middle <= bottom || middle <= top {
+		t.Errorf("Expected rods to be worth most mid-core, got %f at bottom, %f mid-core, %f at top", bottom, middle, top)
+	}
+
+	bank.position = MAX_WITHDRAWAL_STEPS
+	if !almostEqual(bank.Reactivity(), 0, 0.001) {
+		t.Errorf("Expected a fully withdrawn bank to hold nothing, got %f", bank.Reactivity())
+	}
+}
+
+func TestMovingBankChangesReactivity(t *testing.T) {
+	cr := NewControlRods()
+	cr.controlBanks[3].position = 120
+	before := cr.Reactivity()
+
+	cr.AdjustControlBankPosition(4, 125)
+	cr.Update()
+	change := cr.Reactivity() - before
+
+	// five steps near mid-core on one bank, worth roughly 5 times the differential worth
+	expected := 5 * cr.controlBanks[3].DifferentialWorth()
+	if !almostEqual(change, expected, 1) {
+		t.Errorf("Expected moving MB2 five steps to add about %f pcm, got %f", expected, change)
+	}
+}
+
+func TestGrayBanksAreWorthLess(t *testing.T) {
+	cr := NewControlRods()
+	if cr.grayBanks[0].Worth() >= cr.controlBanks[0].Worth() {
+		t.Errorf("Expected gray bank worth %f to be less than control bank worth %f", cr.grayBanks[0].Worth(), cr.controlBanks[0].Worth())
+	}
+
+	before := cr.Reactivity()
+	cr.AdjustGrayBankPosition(1, MAX_WITHDRAWAL_STEPS)
+	for i := 0; i < 10; i++ {
+		cr.Update()
+	}
+	if !almostEqual(cr.Reactivity()-before, GRAY_BANK_WORTH, 0.001) {
+		t.Errorf("Expected withdrawing a gray bank to add %f pcm, got %f", GRAY_BANK_WORTH, cr.Reactivity()-before)
+	}
+}
+
+func TestBanksMoveInOverlappedSequence(t *testing.T) {
+	cr := NewControlRods()
+	cr.SetBankOverlap(100)
+
+	// MA1 nearly out, MA2 just past the overlap point, the rest still in
+	cr.MoveControlBanksInSequence(MAX_WITHDRAWAL_STEPS - 100 + 30)
+	expected := []int{MAX_WITHDRAWAL_STEPS - 70, 30, 0, 0}
+	for i, bank := range cr.controlBanks {
+		if bank.Target() != expected[i] {
+			t.Errorf("Expected %s target to be %d, got %d", bank.Label(), expected[i], bank.Target())
+		}
+	}
+
+	cr.MoveControlBanksInSequence(cr.MaxBankDemand())
+	for _, bank := range cr.controlBanks {
+		if bank.Target() != MAX_WITHDRAWAL_STEPS {
+			t.Errorf("Expected %s to be fully withdrawn at maximum demand, got %d", bank.Label(), bank.Target())
+		}
+	}
+}
+
+func TestBankOverlapIsValidated(t *testing.T) {
+	cr := NewControlRods()
+	cr.SetBankOverlap(MAX_WITHDRAWAL_STEPS)
+	if cr.BankOverlap() != BANK_OVERLAP_STEPS {
+		t.Errorf("Expected an overlap of a full bank to be refused, got %d", cr.BankOverlap())
+	}
+	cr.SetBankOverlap(50)
+	if cr.BankOverlap() != 50 {
+		t.Errorf("Expected overlap to be 50, got %d", cr.BankOverlap())
+	}
+}
+
+func TestAutomaticRodControlFollowsTavgProgram(t *testing.T) {
+	cr := NewControlRods()
+	cr.MoveControlBanksInSequence(400)
+	cr.SwitchToAutomaticRodControl()
+	if cr.bankDemand != 400 {
+		t.Errorf("Expected automatic to pick up the banks where they were, got demand %d", cr.bankDemand)
+	}
+
+	tref := referenceAverageTemperature(50)
+	cr.UpdateRodControl(tref+ROD_CONTROL_DEADBAND/2, 50)
+	if cr.bankDemand != 400 {
+		t.Errorf("Expected no rod motion inside the deadband, got demand %d", cr.bankDemand)
+	}
+
+	cr.UpdateRodControl(tref+5, 50)
+	if cr.bankDemand != 400-ROD_CONTROL_MAX_SPEED {
+		t.Errorf("Expected rods to drive in at full speed when hot, got demand %d", cr.bankDemand)
+	}
+
+	cr.UpdateRodControl(tref-1.5, 50)
+	if cr.bankDemand <= 400-ROD_CONTROL_MAX_SPEED {
+		t.Errorf("Expected rods to withdraw when cold, got demand %d", cr.bankDemand)
+	}
+
+	cr.SwitchToManualRodControl()
+	demand := cr.bankDemand
+	cr.UpdateRodControl(tref+5, 50)
+	if cr.bankDemand != demand {
+		t.Errorf("Expected no automatic rod motion in manual, got demand %d", cr.bankDemand)
+	}
+}
+
+func TestRodDrivesStepAtTheirOwnRates(t *testing.T) {
+	cr := NewControlRods()
+	cr.AdjustControlBankPosition(1, MAX_WITHDRAWAL_STEPS)
+	cr.AdjustGrayBankPosition(1, MAX_WITHDRAWAL_STEPS)
+
+	// a minute, a second at a time
+	for i := 0; i < 60; i++ {
+		cr.Advance(1)
+	}
+
+	if cr.controlBanks[0].Position() != int(CONTROL_BANK_STEP_RATE) {
+		t.Errorf("Expected MA1 to move %d steps in a minute, got %d", int(CONTROL_BANK_STEP_RATE), cr.controlBanks[0].Position())
+	}
+	if cr.grayBanks[0].Position() != int(GRAY_BANK_STEP_RATE) {
+		t.Errorf("Expected GR1 to move %d steps in a minute, got %d", int(GRAY_BANK_STEP_RATE), cr.grayBanks[0].Position())
+	}
+}
+
+func TestRodPositionIndication(t *testing.T) {
+	cr := NewControlRods()
+	bank := cr.controlBanks[0]
+	if !bank.RodBottom() || bank.AtTop() {
+		t.Errorf("Expected MA1 to show rod bottom at the start")
+	}
+	if len(cr.RodBottomBanks()) != 10 {
+		t.Errorf("Expected every bank to show rod bottom at the start, got %v", cr.RodBottomBanks())
+	}
+
+	cr.AdjustControlBankPosition(1, MAX_WITHDRAWAL_STEPS)
+	cr.Update()
+	if bank.RodBottom() || bank.AtTop() || !bank.IsMoving() {
+		t.Errorf("Expected MA1 to be on its way out, at %d", bank.Position())
+	}
+
+	for i := 0; i < 10; i++ {
+		cr.Update()
+	}
+	if !bank.AtTop() {
+		t.Errorf("Expected MA1 to show at top, at %d", bank.Position())
+	}
+
+	cr.Scram()
+	if !bank.RodBottom() {
+		t.Errorf("Expected MA1 to show rod bottom after a scram, at %d", bank.Position())
+	}
+}
@@ -0,0 +1,57 @@
+package sim
+
+import (
+	"testing"
+)
+
+func TestDecayHeatFractionAtEquilibrium(t *testing.T) {
+	fraction := decayHeatFraction()
+	if fraction < 0.06 || fraction > 0.075 {
+		t.Errorf("Expected decay heat to carry 6-7.5%% of a long-running core's power, got %f", fraction)
+	}
+}
+
+func TestDecayHeatAfterShutdown(t *testing.T) {
+	dh := NewDecayHeat()
+
+	// a year at rated power
+	for i := 0; i < YEAR_OF_MINUTES/HOUR_OF_MINUTES; i++ {
+		dh.Advance(RATED_THERMAL_POWER, HOUR_OF_MINUTES*SECONDS_PER_TICK)
+	}
+
+	// ten seconds after shutdown
+	dh.Advance(0, 10)
+	early := dh.Power() / RATED_THERMAL_POWER
+	if early < 0.04 || early > 0.065 {
+		t.Errorf("Expected about 5%% decay heat seconds after shutdown, got %f", early)
+	}
+
+	// an hour after shutdown
+	dh.Advance(0, HOUR_OF_MINUTES*SECONDS_PER_TICK-10)
+	hour := dh.Power() / RATED_THERMAL_POWER
+	if hour < 0.008 || hour > 0.02 {
+		t.Errorf("Expected 1-2%% decay heat an hour after shutdown, got %f", hour)
+	}
+
+	// a day after shutdown
+	dh.Advance(0, (DAY_OF_MINUTES-HOUR_OF_MINUTES)*SECONDS_PER_TICK)
+	day := dh.Power() / RATED_THERMAL_POWER
+	if day >= hour || day < 0.002 {
+		t.Errorf("Expected decay heat to keep falling to a few tenths of a percent after a day, got %f", day)
+	}
+}
+
+func TestDecayHeatFollowsOperatingHistory(t *testing.T) {
+	short := NewDecayHeat()
+	long := NewDecayHeat()
+
+	short.Advance(RATED_THERMAL_POWER, HOUR_OF_MINUTES*SECONDS_PER_TICK)
+	long.Advance(RATED_THERMAL_POWER, YEAR_OF_MINUTES*SECONDS_PER_TICK)
+
+	short.Advance(0, HOUR_OF_MINUTES*SECONDS_PER_TICK)
+	long.Advance(0, HOUR_OF_MINUTES*SECONDS_PER_TICK)
+
+	if short.Power() >= long.Power() {
+		t.Errorf("Expected a core run for a year to carry more decay heat than one run for an hour, got %f and %f", long.Power(), short.Power())
+	}
+}
@@ -0,0 +1,129 @@
+package sim
+
+import (
+	"fmt"
+	"math"
+)
+
+// Steam generator level is held by a three-element controller. Steam flow is
+// fed forward, so feed follows load without waiting for the level to move; a
+// PI term on the level error trims it. The third element is feed flow: the
+// regulating valve is driven on the mismatch between the demand and what is
+// measured going in, so a weak pump does not upset the level loop.
+//
+// Because it leans on steam flow rather than level, the controller rides out
+// shrink and swell instead of chasing them. If heatup swells the inventory
+// well above setpoint, blowdown opens to drain it back.
+
+const (
+	FEEDWATER_CONTROL_MANUAL    = "manual"
+	FEEDWATER_CONTROL_AUTOMATIC = "automatic"
+)
+
+const MAX_FEEDWATER_FLOW_RATE = 2200.0          // kg/s, with the regulating valve wide open
+const FEEDWATER_VALVE_STROKE_TIME = 30.0        // seconds, shut to wide open
+const FEEDWATER_LEVEL_GAIN = 5.0                // kg/s of feed per percent of level error
+const FEEDWATER_LEVEL_RESET_TIME = 120.0        // seconds
+const STEAM_GENERATOR_BLOWDOWN_FLOW_RATE = 20.0 // kg/s
+const BLOWDOWN_OPEN_LEVEL_ERROR = 10.0          // percent above setpoint
+const BLOWDOWN_CLOSE_LEVEL_ERROR = 2.0          // percent above setpoint
+
+type FeedwaterControl struct {
+	mode          string
+	levelSetpoint float64 // percent, narrow range
+	levelIntegral float64 // kg/s
+	flowDemand    float64 // kg/s
+	valveDemand   float64 // 0 to 1
+	valvePosition float64 // 0 to 1
+	blowdownOpen  bool
+}
+
+func NewFeedwaterControl() *FeedwaterControl {
+	return &FeedwaterControl{
+		mode:          FEEDWATER_CONTROL_AUTOMATIC,
+		levelSetpoint: NORMAL_STEAM_GENERATOR_LEVEL,
+	}
+}
+
+// update moves the regulating valve, over a step of the given seconds; the
+// valve passes the given kg/s wide open, and the demand is held to what it
+// can pass
+func (fc *FeedwaterControl) update(seconds float64, sg *SteamGenerator, capacity float64) {
+	if fc.mode == FEEDWATER_CONTROL_AUTOMATIC {
+		levelError := fc.levelSetpoint - sg.Level()
+		fc.levelIntegral += FEEDWATER_LEVEL_GAIN * levelError * seconds / FEEDWATER_LEVEL_RESET_TIME
+		fc.levelIntegral = math.Max(-capacity, math.Min(capacity, fc.levelIntegral))
+		fc.flowDemand = math.Max(0, math.Min(capacity, sg.steamFlowRate+FEEDWATER_LEVEL_GAIN*levelError+fc.levelIntegral))
+		fc.valveDemand = fc.valvePosition + (fc.flowDemand-sg.feedwaterFlowRate)/capacity
+		fc.valveDemand = math.Max(0, math.Min(1, fc.valveDemand))
+
+		if -levelError > BLOWDOWN_OPEN_LEVEL_ERROR {
+			fc.blowdownOpen = true
+		} else if -levelError < BLOWDOWN_CLOSE_LEVEL_ERROR {
+			fc.blowdownOpen = false
+		}
+	}
+
+	stroke := seconds / FEEDWATER_VALVE_STROKE_TIME
+	fc.valvePosition += math.Max(-stroke, math.Min(stroke, fc.valveDemand-fc.valvePosition))
+}
+
+func (fc *FeedwaterControl) SwitchToAutomatic() {
+	fc.mode = FEEDWATER_CONTROL_AUTOMATIC
+	// the valve is trimmed from where it stands, so only the level history needs clearing
+	fc.levelIntegral = 0
+}
+
+func (fc *FeedwaterControl) SwitchToManual() {
+	fc.mode = FEEDWATER_CONTROL_MANUAL
+	fc.valveDemand = fc.valvePosition
+}
+
+func (fc *FeedwaterControl) Mode() string {
+	return fc.mode
+}
+
+// SetValvePosition sets the feedwater regulating valve in percent open, for manual control
+func (fc *FeedwaterControl) SetValvePosition(percent float64) {
+	if percent < 0 || percent > 100 {
+		fmt.Printf("Feedwater valve position must be between 0 and 100%%. You requested %f.\n", percent)
+		return
+	}
+	fc.valveDemand = percent / 100
+}
+
+// percent open
+func (fc *FeedwaterControl) ValvePosition() float64 {
+	return fc.valvePosition * 100
+}
+
+// SetLevelSetpoint sets the narrow range level to hold, in percent
+func (fc *FeedwaterControl) SetLevelSetpoint(level float64) {
+	if level < STEAM_GENERATOR_LOW_LEVEL_ALARM || level > STEAM_GENERATOR_HIGH_LEVEL_ALARM {
+		fmt.Printf("Level setpoint must be between %.0f and %.0f%%. You requested %f.\n", STEAM_GENERATOR_LOW_LEVEL_ALARM, STEAM_GENERATOR_HIGH_LEVEL_ALARM, level)
+		return
+	}
+	fc.levelSetpoint = level
+}
+
+func (fc *FeedwaterControl) LevelSetpoint() float64 {
+	return fc.levelSetpoint
+}
+
+func (fc *FeedwaterControl) OpenBlowdown() {
+	fc.blowdownOpen = true
+}
+
+func (fc *FeedwaterControl) CloseBlowdown() {
+	fc.blowdownOpen = false
+}
+
+func (fc *FeedwaterControl) Status() map[string]interface{} {
+	return map[string]interface{}{
+		"mode":          fc.mode,
+		"levelSetpoint": fc.levelSetpoint,
+		"flowDemand":    fc.flowDemand,
+		"valvePosition": fc.valvePosition * 100,
+		"blowdownOpen":  fc.blowdownOpen,
+	}
+}
@@ -0,0 +1,202 @@
+package sim
+
+import (
+	"fmt"
+	"math"
+
+	"won/sim-lab/go-engine/internal/steam"
+)
+
+// The feedwater heaters warm the condensate on its way from the hotwell to
+// the steam generators with steam bled from the turbine, so the steam
+// generators spend less of the reactor's heat bringing the feedwater up to
+// boiling. Steam bled off has already done some work in the turbine, and the
+// heat it still carries goes back into the cycle instead of into the
+// condenser, which is what makes the cycle more efficient.
+//
+// The four low-pressure heaters take steam from the LP turbine and the two
+// high-pressure heaters from the HP turbine, the lower of them from its
+// exhaust at crossover pressure. The train is laid out from the turbine's
+// stages, one heater for each bleed point, and each heater keeps the stage it
+// takes its steam from. Each heater's shell sits at the pressure of its
+// extraction point, which falls off with the steam flow through the turbine,
+// and the feedwater leaves it a terminal difference below saturation at that
+// pressure. The steam condenses in the shell and the drains cascade down to
+// the next heater, cooled to a drain approach above the feedwater coming in;
+// the lowest heater drains to the condenser.
+//
+// A heater out of service is bypassed: the feedwater goes by it unheated and
+// the turbine keeps the steam it would have taken, which makes a little more
+// power at the cost of more heat to the condenser and colder feedwater.
+
+const (
+	FEEDWATER_HEATER_LOW_PRESSURE  = "lowPressure"
+	FEEDWATER_HEATER_HIGH_PRESSURE = "highPressure"
+)
+
+const FEEDWATER_HEATER_TERMINAL_DIFFERENCE = 2.8 // °C, feedwater out below saturation in the shell
+const FEEDWATER_HEATER_DRAIN_APPROACH = 5.6      // °C, drains out above feedwater in
+const FEEDWATER_HEATER_TIME_CONSTANT = 180.0     // seconds for the feedwater to follow the shell
+const DESIGN_HP_BLEED_PRESSURE = 2.8             // MPa, at rated steam flow
+
+type FeedwaterHeater struct {
+	number             int
+	kind               string
+	bleedStage         int  // turbine stage the steam comes from, counted from the HP stage at 0
+	bledPartway        bool // from partway through the stage rather than its outlet
+	inService          bool
+	shellPressure      float64 // MPa, set by the turbine's extraction point
+	extractionEnthalpy float64 // kJ/kg of the steam bled to the shell
+	dutyLimit          float64 // kJ/kg of feedwater the steam and drains coming in can give it
+	inletTemperature   float64 // °C
+	outletTemperature  float64 // °C
+	extractionFlowRate float64 // kg/s
+	drainFlowRate      float64 // kg/s, own condensate and the drains cascading in
+}
+
+func NewFeedwaterHeater(number int, kind string, bleedStage int, bledPartway bool) *FeedwaterHeater {
+	return &FeedwaterHeater{
+		number:            number,
+		kind:              kind,
+		bleedStage:        bleedStage,
+		bledPartway:       bledPartway,
+		dutyLimit:         math.Inf(1),
+		inletTemperature:  BASE_FEEDWATER_TEMPERATURE,
+		outletTemperature: BASE_FEEDWATER_TEMPERATURE,
+	}
+}
+
+// newFeedwaterHeaterTrain lines up the heaters the feedwater goes through,
+// from the hotwell to the steam generators: one for each bleed point on the
+// turbine, from the condenser end up
+func newFeedwaterHeaterTrain() []*FeedwaterHeater {
+	stages := newTurbineStages()
+	heaters := make([]*FeedwaterHeater, 0, len(stages))
+	addHeater := func(stage int, partway bool) {
+		kind := FEEDWATER_HEATER_LOW_PRESSURE
+		if stages[stage].kind == TURBINE_STAGE_HIGH_PRESSURE {
+			kind = FEEDWATER_HEATER_HIGH_PRESSURE
+		}
+		heaters = append(heaters, NewFeedwaterHeater(len(heaters)+1, kind, stage, partway))
+	}
+	// the last stage exhausts to the condenser
+	for i := len(stages) - 2; i >= 0; i-- {
+		addHeater(i, false)
+		if stages[i].designBleedPressure > 0 {
+			addHeater(i, true)
+		}
+	}
+	return heaters
+}
+
+// heat brings the heater's outlet toward what its shell can give the
+// feedwater coming in, over the given seconds; short of steam, the feedwater
+// comes out colder
+func (h *FeedwaterHeater) heat(inletTemperature float64, seconds float64) {
+	h.inletTemperature = inletTemperature
+	target := inletTemperature
+	if h.steaming() {
+		target = math.Max(inletTemperature, steam.SaturationTemperature(h.shellPressure)-FEEDWATER_HEATER_TERMINAL_DIFFERENCE)
+		if limit := waterEnthalpy(inletTemperature) + h.dutyLimit; limit < waterEnthalpy(target) {
+			target = math.Max(inletTemperature, waterTemperature(limit))
+		}
+	}
+	h.outletTemperature = target + (h.outletTemperature-target)*math.Exp(-seconds/FEEDWATER_HEATER_TIME_CONSTANT)
+	// a bypassed heater passes the feedwater straight through
+	if !h.inService {
+		h.outletTemperature = inletTemperature
+	}
+}
+
+// whether extraction steam is coming into the shell
+func (h *FeedwaterHeater) steaming() bool {
+	return h.inService && h.extractionEnthalpy > 0
+}
+
+// kJ/kg the feedwater picks up going through
+func (h *FeedwaterHeater) duty() float64 {
+	return waterEnthalpy(h.outletTemperature) - waterEnthalpy(h.inletTemperature)
+}
+
+// kJ/kg of the drains leaving the shell
+func (h *FeedwaterHeater) drainEnthalpy() float64 {
+	if h.shellPressure <= 0 {
+		return waterEnthalpy(h.inletTemperature)
+	}
+	return math.Min(steam.SaturatedLiquid(h.shellPressure).Enthalpy, waterEnthalpy(h.inletTemperature+FEEDWATER_HEATER_DRAIN_APPROACH))
+}
+
+// waterEnthalpy of feedwater at the given temperature, in kJ/kg; pressure
+// makes little difference to liquid water
+func waterEnthalpy(temperature float64) float64 {
+	return steam.SaturatedLiquid(steam.SaturationPressure(temperature)).Enthalpy
+}
+
+// waterTemperature of feedwater with the given enthalpy, in °C
+func waterTemperature(enthalpy float64) float64 {
+	low, high := 0.0, TARGET_STEAM_TEMPERATURE
+	for i := 0; i < 40; i++ {
+		mid := (low + high) / 2
+		if waterEnthalpy(mid) < enthalpy {
+			low = mid
+		} else {
+			high = mid
+		}
+	}
+	return (low + high) / 2
+}
+
+func (h *FeedwaterHeater) Number() int {
+	return h.number
+}
+
+func (h *FeedwaterHeater) Kind() string {
+	return h.kind
+}
+
+func (h *FeedwaterHeater) InService() bool {
+	return h.inService
+}
+
+func (h *FeedwaterHeater) ReturnToService() {
+	h.inService = true
+}
+
+func (h *FeedwaterHeater) TakeOutOfService() {
+	h.inService = false
+}
+
+// °C
+func (h *FeedwaterHeater) OutletTemperature() float64 {
+	return h.outletTemperature
+}
+
+// kg/s of steam bled from the turbine
+func (h *FeedwaterHeater) ExtractionFlowRate() float64 {
+	return h.extractionFlowRate
+}
+
+func (h *FeedwaterHeater) Status() map[string]interface{} {
+	return map[string]interface{}{
+		"number":             h.number,
+		"kind":               h.kind,
+		"inService":          h.inService,
+		"shellPressure":      h.shellPressure,
+		"inletTemperature":   h.inletTemperature,
+		"outletTemperature":  h.outletTemperature,
+		"extractionFlowRate": h.extractionFlowRate,
+		"drainFlowRate":      h.drainFlowRate,
+	}
+}
+
+func (h *FeedwaterHeater) PrintStatus() {
+	fmt.Printf("\tFeedwater Heater %d (%s): %s, %.1f °C in, %.1f °C out, %.1f kg/s extraction\n",
+		h.number, h.kind, inServiceString(h.inService), h.inletTemperature, h.outletTemperature, h.extractionFlowRate)
+}
+
+func inServiceString(inService bool) string {
+	if inService {
+		return "In Service"
+	}
+	return "Out of Service"
+}
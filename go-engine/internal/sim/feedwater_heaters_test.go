@@ -0,0 +1,90 @@
+package sim
+
+import (
+	"testing"
+
+	"won/sim-lab/go-engine/internal/steam"
+)
+
+// runs the plant set up by setUpSteamCycle until it settles again
+func settleSteamCycle(simulation *Simulation, env *Environment) {
+	for i := 0; i < 30; i++ {
+		updateSteamCycle(simulation, env)
+	}
+}
+
+func TestHeaterTrainBringsFeedwaterToTarget(t *testing.T) {
+	simulation, _ := setUpSteamCycle()
+	secondaryLoop := simulation.FindSecondaryLoop()
+
+	if !almostEqual(secondaryLoop.FeedwaterTemperature(), TARGET_FEEDWATER_TEMPERATURE, 3) {
+		t.Errorf("Expected feedwater at %f °C at rated power, got %f", TARGET_FEEDWATER_TEMPERATURE, secondaryLoop.FeedwaterTemperature())
+	}
+	previous := simulation.FindCondenser().exitTemperature
+	for _, heater := range secondaryLoop.FeedwaterHeaters() {
+		if heater.OutletTemperature() <= previous {
+			t.Errorf("Expected heater %d to warm the feedwater past %f °C, got %f", heater.Number(), previous, heater.OutletTemperature())
+		}
+		if heater.ExtractionFlowRate() <= 0 {
+			t.Errorf("Expected heater %d to bleed steam from the turbine", heater.Number())
+		}
+		previous = heater.OutletTemperature()
+	}
+	heaters := len(secondaryLoop.FeedwaterHeaters())
+	if secondaryLoop.FeedwaterHeater(0) != nil || secondaryLoop.FeedwaterHeater(heaters+1) != nil {
+		t.Errorf("Expected no heaters outside 1 through %d", heaters)
+	}
+}
+
+func TestHeaterOutageCostsEfficiency(t *testing.T) {
+	simulation, env := setUpSteamCycle()
+	settleSteamCycle(simulation, env)
+	feedwaterTemperature := simulation.FindSecondaryLoop().FeedwaterTemperature()
+	power := simulation.FindSteamTurbine().Power()
+
+	// the top heater bypassed: colder feedwater, so less steam for the same heat
+	simulation.FindSecondaryLoop().FeedwaterHeater(len(simulation.FindSecondaryLoop().FeedwaterHeaters())).TakeOutOfService()
+	settleSteamCycle(simulation, env)
+	if simulation.FindSecondaryLoop().FeedwaterTemperature() > feedwaterTemperature-20 {
+		t.Errorf("Expected the feedwater well below %f °C with the top heater out, got %f", feedwaterTemperature, simulation.FindSecondaryLoop().FeedwaterTemperature())
+	}
+	if simulation.FindSteamTurbine().Power() >= power {
+		t.Errorf("Expected less than %f MW from the same reactor power with the top heater out, got %f", power, simulation.FindSteamTurbine().Power())
+	}
+
+	// a low-pressure heater bypassed: the next one up makes up the temperature,
+	// with costlier steam
+	simulation, env = setUpSteamCycle()
+	simulation.FindSecondaryLoop().FeedwaterHeater(2).TakeOutOfService()
+	settleSteamCycle(simulation, env)
+	if !almostEqual(simulation.FindSecondaryLoop().FeedwaterTemperature(), feedwaterTemperature, 1) {
+		t.Errorf("Expected the feedwater still at %f °C, got %f", feedwaterTemperature, simulation.FindSecondaryLoop().FeedwaterTemperature())
+	}
+	if simulation.FindSteamTurbine().Power() >= power {
+		t.Errorf("Expected less than %f MW with heater 2 out, got %f", power, simulation.FindSteamTurbine().Power())
+	}
+	if simulation.FindSecondaryLoop().FeedwaterHeater(2).ExtractionFlowRate() != 0 {
+		t.Errorf("Expected no steam bled to a bypassed heater, got %f kg/s", simulation.FindSecondaryLoop().FeedwaterHeater(2).ExtractionFlowRate())
+	}
+}
+
+func TestReheaterOutageWetsTheExhaust(t *testing.T) {
+	simulation, env := setUpSteamCycle()
+	settleSteamCycle(simulation, env)
+	turbine := simulation.FindSteamTurbine()
+	msr := turbine.MoistureSeparatorReheater()
+	quality, power := turbine.exhaustQuality, turbine.Power()
+
+	if msr.OutletTemperature() <= steam.SaturationTemperature(turbine.CrossoverPressure()) {
+		t.Errorf("Expected the reheater to superheat the crossover steam, got %f °C", msr.OutletTemperature())
+	}
+
+	msr.TakeReheaterOutOfService()
+	settleSteamCycle(simulation, env)
+	if turbine.exhaustQuality >= quality {
+		t.Errorf("Expected wetter exhaust than %f without reheat, got %f", quality, turbine.exhaustQuality)
+	}
+	if turbine.Power() >= power {
+		t.Errorf("Expected less than %f MW without reheat, got %f", power, turbine.Power())
+	}
+}
@@ -0,0 +1,98 @@
+package sim
+
+// Fission poisons are fission products that soak up neutrons. Two of them matter
+// to operators:
+//
+// Xenon-135 comes mostly from the decay of iodine-135 and is burned out by the
+// neutron flux. After a trip the iodine keeps decaying into xenon with nothing
+// to burn it, so xenon peaks some ten hours later (the "xenon pit") and may
+// keep the core from restarting. Power changes set off slow xenon oscillations.
+//
+//   dI/dt  = γI Σf φ - λI I
+//   dXe/dt = γXe Σf φ + λI I - λXe Xe - σXe φ Xe
+//
+// Samarium-149 comes from promethium-149 and is stable, so it builds up after
+// a shutdown and stays until the core is brought back to power.
+//
+//   dPm/dt = γPm Σf φ - λPm Pm
+//   dSm/dt = λPm Pm - σSm φ Sm
+//
+// Concentrations are in atoms per cm³ and φ is in neutrons per cm² per second.
+
+const IODINE_YIELD = 0.0639
+const XENON_YIELD = 0.00237
+const PROMETHIUM_YIELD = 0.0113
+const IODINE_DECAY_CONSTANT = 2.87e-5     // per second; 6.7 hour half-life
+const XENON_DECAY_CONSTANT = 2.09e-5      // per second; 9.2 hour half-life
+const PROMETHIUM_DECAY_CONSTANT = 3.63e-6 // per second; 53 hour half-life
+const XENON_ABSORPTION = 2.65e-18         // cm², microscopic cross section
+const SAMARIUM_ABSORPTION = 4.1e-20       // cm², microscopic cross section
+const FISSION_CROSS_SECTION = 0.1         // per cm, macroscopic
+const NEUTRONS_PER_FISSION = 2.43
+
+type FissionPoisons struct {
+	iodine     float64 // atoms per cm³
+	xenon      float64 // atoms per cm³
+	promethium float64 // atoms per cm³
+	samarium   float64 // atoms per cm³
+}
+
+// fresh fuel carries no fission products
+func NewFissionPoisons() *FissionPoisons {
+	return &FissionPoisons{}
+}
+
+// Advance steps the poison chains forward at the given flux (n/cm²/s)
+func (fp *FissionPoisons) Advance(flux float64, seconds float64) {
+	fissionRate := FISSION_CROSS_SECTION * flux
+
+	iodineDecay := IODINE_DECAY_CONSTANT * fp.iodine
+	promethiumDecay := PROMETHIUM_DECAY_CONSTANT * fp.promethium
+
+	fp.iodine += seconds * (IODINE_YIELD*fissionRate - iodineDecay)
+	fp.xenon += seconds * (XENON_YIELD*fissionRate + iodineDecay - XENON_DECAY_CONSTANT*fp.xenon - XENON_ABSORPTION*flux*fp.xenon)
+	fp.promethium += seconds * (PROMETHIUM_YIELD*fissionRate - promethiumDecay)
+	fp.samarium += seconds * (promethiumDecay - SAMARIUM_ABSORPTION*flux*fp.samarium)
+}
+
+// LoadSamarium puts samarium at the level it settles to after weeks at power.
+// Being stable, it stays with fuel that is shut down for an outage.
+func (fp *FissionPoisons) LoadSamarium() {
+	fp.samarium = PROMETHIUM_YIELD * FISSION_CROSS_SECTION / SAMARIUM_ABSORPTION
+}
+
+// in pcm; a poison's share of absorptions in a core that is otherwise just critical
+func (fp *FissionPoisons) XenonReactivity() float64 {
+	return -XENON_ABSORPTION * fp.xenon / (NEUTRONS_PER_FISSION * FISSION_CROSS_SECTION) * 1e5
+}
+
+// in pcm
+func (fp *FissionPoisons) SamariumReactivity() float64 {
+	return -SAMARIUM_ABSORPTION * fp.samarium / (NEUTRONS_PER_FISSION * FISSION_CROSS_SECTION) * 1e5
+}
+
+// in pcm, once xenon has settled at the given flux
+func equilibriumXenonReactivity(flux float64) float64 {
+	fp := &FissionPoisons{
+		xenon: (IODINE_YIELD + XENON_YIELD) * FISSION_CROSS_SECTION * flux / (XENON_DECAY_CONSTANT + XENON_ABSORPTION*flux),
+	}
+	return fp.XenonReactivity()
+}
+
+// in pcm, once samarium has settled; the same at any power
+func equilibriumSamariumReactivity() float64 {
+	fp := &FissionPoisons{}
+	fp.LoadSamarium()
+	return fp.SamariumReactivity()
+}
+
+func (fp *FissionPoisons) Status() map[string]interface{} {
+	return map[string]interface{}{
+		"iodine":             fp.iodine,
+		"xenon":              fp.xenon,
+		"promethium":         fp.promethium,
+		"samarium":           fp.samarium,
+		"xenonReactivity":    fp.XenonReactivity(),
+		"samariumReactivity": fp.SamariumReactivity(),
+	}
+}
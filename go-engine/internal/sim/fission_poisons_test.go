@@ -0,0 +1,71 @@
+package sim
+
+import (
+	"testing"
+)
+
+func TestXenonReachesEquilibriumAtFullPower(t *testing.T) {
+	fp := NewFissionPoisons()
+
+	// two days at full power is plenty for xenon to settle
+	for i := 0; i < 2*DAY_OF_MINUTES; i++ {
+		fp.Advance(FULL_POWER_NEUTRON_FLUX, SECONDS_PER_TICK)
+	}
+
+	xenon := fp.XenonReactivity()
+	if xenon > -2000 || xenon < -3000 {
+		t.Errorf("Expected equilibrium xenon worth of about -2000 to -3000 pcm, got %f", xenon)
+	}
+
+	fp.Advance(FULL_POWER_NEUTRON_FLUX, SECONDS_PER_TICK)
+	if !almostEqual(fp.XenonReactivity(), xenon, 0.5) {
+		t.Errorf("Expected xenon to hold steady at equilibrium, went from %f to %f", xenon, fp.XenonReactivity())
+	}
+}
+
+func TestXenonPitAfterTrip(t *testing.T) {
+	fp := NewFissionPoisons()
+	for i := 0; i < 2*DAY_OF_MINUTES; i++ {
+		fp.Advance(FULL_POWER_NEUTRON_FLUX, SECONDS_PER_TICK)
+	}
+	equilibrium := fp.XenonReactivity()
+
+	// trip: no more flux
+	deepest := equilibrium
+	deepestAt := 0
+	for i := 0; i < 2*DAY_OF_MINUTES; i++ {
+		fp.Advance(0, SECONDS_PER_TICK)
+		if fp.XenonReactivity() < deepest {
+			deepest = fp.XenonReactivity()
+			deepestAt = i
+		}
+	}
+
+	if deepest > 1.5*equilibrium {
+		t.Errorf("Expected xenon to climb well past equilibrium %f after a trip, deepest was %f", equilibrium, deepest)
+	}
+	if deepestAt < 6*HOUR_OF_MINUTES || deepestAt > 14*HOUR_OF_MINUTES {
+		t.Errorf("Expected xenon to peak about ten hours after the trip, peaked after %d minutes", deepestAt)
+	}
+	if fp.XenonReactivity() <= equilibrium {
+		t.Errorf("Expected xenon to have decayed away two days after the trip, still at %f", fp.XenonReactivity())
+	}
+}
+
+func TestSamariumBuildsUpAfterShutdown(t *testing.T) {
+	fp := NewFissionPoisons()
+	for i := 0; i < 4*WEEK_OF_MINUTES; i++ {
+		fp.Advance(FULL_POWER_NEUTRON_FLUX, SECONDS_PER_TICK)
+	}
+	operating := fp.SamariumReactivity()
+	if operating >= 0 {
+		t.Errorf("Expected samarium to take reactivity away while operating, got %f", operating)
+	}
+
+	for i := 0; i < 2*WEEK_OF_MINUTES; i++ {
+		fp.Advance(0, SECONDS_PER_TICK)
+	}
+	if fp.SamariumReactivity() >= operating {
+		t.Errorf("Expected samarium to build up after shutdown, went from %f to %f", operating, fp.SamariumReactivity())
+	}
+}
@@ -0,0 +1,94 @@
+package sim
+
+import (
+	"math"
+)
+
+// Fuel depletes with the energy it gives up, which is tracked as burnup in
+// megawatt-days per tonne of uranium (MWd/tU). Fresh fuel carries a lot of
+// excess reactivity that boron holds down; as the fuel burns, the operators
+// dilute boron to stay critical, until there is no boron left to take out and
+// it is time to refuel.
+
+const CORE_URANIUM_MASS = 80.0 // tU loaded in the core
+const (
+	MIDDLE_OF_CYCLE = 5000.0  // MWd/tU where the middle of the cycle starts
+	END_OF_CYCLE    = 15000.0 // MWd/tU where the end of the cycle starts
+	CYCLE_BURNUP    = 20000.0 // MWd/tU; the boron is all diluted out, time to refuel
+)
+
+// excess reactivity of a cold, clean core with all rods out, falling roughly
+// linearly as fuel burns
+const BOC_EXCESS_REACTIVITY = 13500.0 // pcm
+const EOC_EXCESS_REACTIVITY = 10900.0 // pcm, at CYCLE_BURNUP
+
+// conditions the critical boron curve is drawn for: hot full power, equilibrium
+// xenon and samarium, control banks nearly all out
+const HOT_FULL_POWER_TEMPERATURE = 307.0    // °C, average coolant temperature
+const CONTROL_BANK_REFERENCE_POSITION = 245 // steps withdrawn
+
+// how far below critical a shut down core is held, in pcm
+const SHUTDOWN_MARGIN = 1000.0
+
+// starting points for a freshly spawned core, in MWd/tU
+var coreLoadings = map[string]float64{
+	"beginning": 0,
+	"middle":    10000,
+	"end":       18000,
+}
+
+func lookupPartOfCycle(burnup float64) string {
+	switch {
+	case burnup < MIDDLE_OF_CYCLE:
+		return "beginning"
+	case burnup < END_OF_CYCLE:
+		return "middle"
+	case burnup < CYCLE_BURNUP:
+		return "end"
+	default:
+		return "refueling"
+	}
+}
+
+// in pcm
+func excessReactivity(burnup float64) float64 {
+	return BOC_EXCESS_REACTIVITY - (BOC_EXCESS_REACTIVITY-EOC_EXCESS_REACTIVITY)*burnup/CYCLE_BURNUP
+}
+
+// criticalBoronConcentration is the boron (ppm) that holds the core critical at
+// hot full power for the given burnup. Reactivity is linear in boron, so the
+// concentration falls out directly from the worth of one more ppm.
+func criticalBoronConcentration(burnup float64) float64 {
+	fuelTemperature := HOT_FULL_POWER_TEMPERATURE + FUEL_TEMPERATURE_RISE
+
+	withoutBoron := excessReactivity(burnup) -
+		float64(len(ControlRods{}.controlBanks))*rodReactivity(CONTROL_BANK_WORTH, CONTROL_BANK_REFERENCE_POSITION) +
+		dopplerReactivity(fuelTemperature) +
+		moderatorReactivity(HOT_FULL_POWER_TEMPERATURE, 0) +
+		equilibriumXenonReactivity(FULL_POWER_NEUTRON_FLUX) +
+		equilibriumSamariumReactivity()
+	perPpm := BORON_WORTH + moderatorReactivity(HOT_FULL_POWER_TEMPERATURE, 1) - moderatorReactivity(HOT_FULL_POWER_TEMPERATURE, 0)
+
+	return math.Max(0, -withoutBoron/perPpm)
+}
+
+// shutdownBoronConcentration is the boron (ppm) that holds a cold core
+// SHUTDOWN_MARGIN below critical with the shutdown banks withdrawn and every
+// other bank on the bottom, the way it sits before a startup. Used fuel comes
+// with its samarium.
+func shutdownBoronConcentration(burnup float64) float64 {
+	rods := ControlRods{}
+	withoutBoron := excessReactivity(burnup) +
+		float64(len(rods.controlBanks))*rodReactivity(CONTROL_BANK_WORTH, 0) +
+		float64(len(rods.grayBanks))*rodReactivity(GRAY_BANK_WORTH, 0)
+	if burnup > 0 {
+		withoutBoron += equilibriumSamariumReactivity()
+	}
+
+	return math.Max(0, (withoutBoron+SHUTDOWN_MARGIN)/-BORON_WORTH)
+}
+
+// MWd/tU gained over the given seconds at the given thermal power (MW)
+func burnupIncrement(thermalPower float64, seconds float64) float64 {
+	return thermalPower * seconds / (DAY_OF_MINUTES * 60) / CORE_URANIUM_MASS
+}
@@ -0,0 +1,80 @@
+package sim
+
+import (
+	"testing"
+)
+
+func TestLookupPartOfCycle(t *testing.T) {
+	cases := map[float64]string{
+		0:                      "beginning",
+		coreLoadings["middle"]: "middle",
+		coreLoadings["end"]:    "end",
+		CYCLE_BURNUP + 1:       "refueling",
+	}
+	for burnup, expected := range cases {
+		if got := lookupPartOfCycle(burnup); got != expected {
+			t.Errorf("Expected burnup %f to be %s of cycle, got %s", burnup, expected, got)
+		}
+	}
+}
+
+func TestCriticalBoronFallsWithBurnup(t *testing.T) {
+	beginning := criticalBoronConcentration(coreLoadings["beginning"])
+	middle := criticalBoronConcentration(coreLoadings["middle"])
+	end := criticalBoronConcentration(CYCLE_BURNUP)
+
+	if !(beginning > middle && middle > end) {
+		t.Errorf("Expected critical boron to fall over the cycle, got %f, %f, %f", beginning, middle, end)
+	}
+	if end > 50 {
+		t.Errorf("Expected almost no boron left at the end of the cycle, got %f", end)
+	}
+	if beginning > MAX_BORON_CONCENTRATION {
+		t.Errorf("Expected critical boron within what the plant can hold, got %f", beginning)
+	}
+}
+
+func TestBurnupFollowsPowerNotTime(t *testing.T) {
+	simulation, env := setupSimulationEnvironment()
+	reactorCore := NewReactorCore("Test Reactor Core")
+	simulation.AddComponent(reactorCore)
+
+	// shut down, the fuel does not burn
+	for i := 0; i < HOUR_OF_MINUTES; i++ {
+		reactorCore.Update(env, simulation)
+	}
+	if reactorCore.Burnup() > 0.001 {
+		t.Errorf("Expected no burnup while shut down, got %f", reactorCore.Burnup())
+	}
+
+	// one day at rated power
+	expected := RATED_THERMAL_POWER / CORE_URANIUM_MASS
+	burnup := 0.0
+	for i := 0; i < DAY_OF_MINUTES; i++ {
+		burnup += burnupIncrement(RATED_THERMAL_POWER, SECONDS_PER_TICK)
+	}
+	if !almostEqual(burnup, expected, 0.001) {
+		t.Errorf("Expected a full power day to add %f MWd/tU, got %f", expected, burnup)
+	}
+}
+
+func TestLoadFuel(t *testing.T) {
+	reactorCore := NewReactorCore("Test Reactor Core")
+
+	if err := reactorCore.LoadFuel("end"); err != nil {
+		t.Fatalf("Expected end of cycle fuel to load: %v", err)
+	}
+	if reactorCore.Burnup() != coreLoadings["end"] {
+		t.Errorf("Expected burnup of %f at end of cycle, got %f", coreLoadings["end"], reactorCore.Burnup())
+	}
+	if reactorCore.poisons.SamariumReactivity() >= 0 {
+		t.Errorf("Expected used fuel to come with samarium")
+	}
+
+	if err := reactorCore.LoadFuel("sideways"); err == nil {
+		t.Errorf("Expected an unknown loading to be refused")
+	}
+	if reactorCore.Burnup() != coreLoadings["end"] {
+		t.Errorf("Expected an unknown loading to leave burnup alone, got %f", reactorCore.Burnup())
+	}
+}
@@ -2,19 +2,83 @@ package sim
 
 import (
 	"fmt"
+	"math"
 )
 
 type Generator struct {
 	BaseComponent
-	rpm             float64
-	electricalPower float64 // in megawatts (MW)
+	rpm              float64
+	electricalPower  float64 // in megawatts (MW)
+	reactivePower    float64 // MVAr; positive is lagging, sent out to the grid
+	frequency        float64 // Hz
+	terminalVoltage  float64 // kV
+	phaseAngle       float64 // degrees the generator leads the grid by, across the open breaker
+	loadAngle        float64 // degrees the rotor leads the grid by, on line
+	excitation       float64 // per unit; 1 gives rated voltage at synchronous speed and no load
+	excitationMode   string
+	voltageSetpoint  float64 // per unit of rated terminal voltage
+	breakerClosed    bool
+	synchronizing    bool // waiting on the phase to come around to close the breaker
+	breakerTripCause string
+	grid             *Grid
 }
 
+// The generator is a two-pole synchronous machine on the turbine shaft, so
+// its frequency is the shaft speed over sixty. Its field sets the voltage
+// it makes, which goes with speed as well.
+//
+// Before the breaker can close, the generator has to match the grid in
+// frequency, voltage and phase. The sync check looks at frequency and
+// voltage when the breaker is asked to close, and then waits for the phase
+// to come around, as the slight difference in frequency carries it.
+//
+// On line, the grid holds the frequency and, through the step-up
+// transformer, the voltage. The real power is what the turbine gives less
+// the generator's own losses. The field decides the reactive power:
+// overexcited, the machine sends MVAr out to the grid; underexcited, it
+// takes them in, and it pulls its rotor further ahead of the grid to make
+// the same real power. Ask for too much real power on too little field and
+// it slips a pole, and the out-of-step relay opens the breaker.
+//
+// In automatic, the voltage regulator trims the field to hold the terminal
+// voltage at its setpoint; in manual, the operator sets the field directly.
+
+const (
+	EXCITATION_CONTROL_MANUAL    = "manual"
+	EXCITATION_CONTROL_AUTOMATIC = "automatic"
+)
+
+const (
+	GENERATOR_TRIP_TURBINE     = "turbineTrip"
+	GENERATOR_TRIP_OUT_OF_STEP = "outOfStep"
+	GENERATOR_TRIP_FREQUENCY   = "frequency"
+)
+
+const GENERATOR_RATED_MVA = 1100.0
+const GENERATOR_RATED_VOLTAGE = 22.0       // kV
+const GENERATOR_EFFICIENCY = 0.985         // electrical out over mechanical in
+const SYNCHRONOUS_REACTANCE = 1.8          // per unit on rated MVA
+const STEP_UP_TRANSFORMER_REACTANCE = 0.15 // per unit on rated MVA
+const MAX_EXCITATION = 3.0                 // per unit
+const MAX_REACTIVE_POWER = 0.6             // per unit, overexcited
+const MIN_REACTIVE_POWER = -0.3            // per unit, underexcited
+const FIELD_FLASHING_SPEED = 0.95          // fraction of synchronous speed the regulator puts the field on at
+const SYNC_CHECK_SLIP = 0.1                // Hz
+const SYNC_CHECK_VOLTAGE = 0.05            // per unit
+const SYNC_CHECK_PHASE = 10.0              // degrees
+const UNDERFREQUENCY_TRIP = 57.5           // Hz
+const OVERFREQUENCY_TRIP = 61.8            // Hz
+const MIN_VOLTAGE_SETPOINT = 0.95          // per unit
+const MAX_VOLTAGE_SETPOINT = 1.05          // per unit
+
 func NewGenerator(name string) *Generator {
 	return &Generator{
 		BaseComponent:   BaseComponent{Name: name},
 		rpm:             0,
 		electricalPower: 0,
+		excitationMode:  EXCITATION_CONTROL_AUTOMATIC,
+		voltageSetpoint: 1.0,
+		grid:            NewGrid(),
 	}
 }
 
@@ -25,26 +89,240 @@ func (g *Generator) Update(env *Environment, s *Simulation) {
 		return
 	}
 
-	// Update RPM based on turbine's RPM
-	g.rpm = float64(turbine.Rpm())
+	g.rpm = turbine.speed
+	g.frequency = g.rpm / TURBINE_SYNCHRONOUS_SPEED * NOMINAL_GRID_FREQUENCY
+
+	// a turbine trip opens the breaker before the generator can motor
+	if g.breakerClosed && !turbine.IsOnline() {
+		g.tripBreaker(s, GENERATOR_TRIP_TURBINE)
+	}
+
+	if g.breakerClosed {
+		g.runOnline(s, turbine.Power())
+	} else {
+		g.runOffline(s)
+	}
+}
+
+// runOnline works out the real and reactive power and the load angle
+// against the grid voltage, and lets the grid settle its frequency
+func (g *Generator) runOnline(s *Simulation, mechanicalPower float64) {
+	g.electricalPower = mechanicalPower * GENERATOR_EFFICIENCY
+	p := g.electricalPower / GENERATOR_RATED_MVA
+	gridVoltage := g.grid.Voltage()
+
+	// the regulator holds the terminal voltage by what it sends through the transformer
+	terminalVoltage := gridVoltage
+	q := 0.0
+	regulating := g.excitationMode == EXCITATION_CONTROL_AUTOMATIC
+	if regulating {
+		q = (g.voltageSetpoint - gridVoltage) * gridVoltage / STEP_UP_TRANSFORMER_REACTANCE
+		q = math.Max(MIN_REACTIVE_POWER, math.Min(MAX_REACTIVE_POWER, q))
+		terminalVoltage = gridVoltage + q*STEP_UP_TRANSFORMER_REACTANCE/gridVoltage
+		g.excitation = math.Hypot(terminalVoltage+q*SYNCHRONOUS_REACTANCE/terminalVoltage, p*SYNCHRONOUS_REACTANCE/terminalVoltage)
+		if g.excitation > MAX_EXCITATION {
+			g.excitation = MAX_EXCITATION
+			regulating = false
+			terminalVoltage = gridVoltage
+		}
+	}
+
+	sinDelta := p * SYNCHRONOUS_REACTANCE / (g.excitation * terminalVoltage)
+	if g.excitation <= 0 || sinDelta > 1 {
+		g.tripBreaker(s, GENERATOR_TRIP_OUT_OF_STEP)
+		g.runOffline(s)
+		return
+	}
+	delta := math.Asin(sinDelta)
+	if !regulating {
+		q = (g.excitation*terminalVoltage*math.Cos(delta) - terminalVoltage*terminalVoltage) / SYNCHRONOUS_REACTANCE
+		terminalVoltage = gridVoltage + q*STEP_UP_TRANSFORMER_REACTANCE/gridVoltage
+	}
+
+	g.loadAngle = delta * 180 / math.Pi
+	g.phaseAngle = 0
+	g.reactivePower = q * GENERATOR_RATED_MVA
+	g.terminalVoltage = terminalVoltage * GENERATOR_RATED_VOLTAGE
+
+	g.grid.Update(g.electricalPower, true)
+	g.frequency = g.grid.Frequency()
+	if g.frequency < UNDERFREQUENCY_TRIP || g.frequency > OVERFREQUENCY_TRIP {
+		g.tripBreaker(s, GENERATOR_TRIP_FREQUENCY)
+	}
+}
+
+// runOffline brings the voltage up with the field and lets the phase slip
+// against the grid, closing the breaker if the synchronizer is waiting on it
+func (g *Generator) runOffline(s *Simulation) {
+	g.electricalPower = 0
+	g.reactivePower = 0
+	g.loadAngle = 0
+	g.grid.Update(0, false)
+
+	speed := g.rpm / TURBINE_SYNCHRONOUS_SPEED
+	if g.excitationMode == EXCITATION_CONTROL_AUTOMATIC {
+		g.excitation = 0
+		if speed >= FIELD_FLASHING_SPEED {
+			g.excitation = math.Min(MAX_EXCITATION, g.voltageSetpoint/speed)
+		}
+	}
+	g.terminalVoltage = g.excitation * speed * GENERATOR_RATED_VOLTAGE
+
+	slip := g.frequency - g.grid.Frequency()
+	for elapsed := 0.0; elapsed < SECONDS_PER_TICK; elapsed++ {
+		g.phaseAngle = math.Remainder(g.phaseAngle+slip*360, 360)
+		if g.synchronizing && math.Abs(g.phaseAngle) <= SYNC_CHECK_PHASE && g.syncCheck(s) == nil {
+			g.closeBreaker(s)
+			return
+		}
+	}
+}
+
+// syncCheck says why the breaker cannot close, if it cannot, going by
+// frequency and voltage; the phase comes around on its own
+func (g *Generator) syncCheck(s *Simulation) error {
+	turbine := s.FindSteamTurbine()
+	if turbine == nil || turbine.IsTripped() {
+		return fmt.Errorf("turbine is tripped")
+	}
+	if slip := g.frequency - g.grid.Frequency(); math.Abs(slip) > SYNC_CHECK_SLIP {
+		return fmt.Errorf("generator is %.2f Hz off the grid", slip)
+	}
+	if difference := g.terminalVoltage/GENERATOR_RATED_VOLTAGE - g.grid.Voltage(); math.Abs(difference) > SYNC_CHECK_VOLTAGE {
+		return fmt.Errorf("generator voltage is %.1f%% off the grid", difference*100)
+	}
+	return nil
+}
+
+// CloseBreaker ties the generator to the grid once it is in sync. If the
+// frequency and voltage match, the synchronizer closes the breaker as the
+// phase comes around; otherwise it says what is off.
+func (g *Generator) CloseBreaker(s *Simulation) error {
+	if g.breakerClosed {
+		return nil
+	}
+	if err := g.syncCheck(s); err != nil {
+		return err
+	}
+	g.synchronizing = true
+	if math.Abs(g.phaseAngle) <= SYNC_CHECK_PHASE {
+		g.closeBreaker(s)
+	}
+	return nil
+}
 
-	// Simple calculation of electrical power based on RPM
-	// This is a simplified model and should be replaced with a more accurate one
-	g.electricalPower = g.rpm * 0.001 // Arbitrary scaling factor
+func (g *Generator) closeBreaker(s *Simulation) {
+	g.breakerClosed = true
+	g.synchronizing = false
+	g.breakerTripCause = ""
+	g.phaseAngle = 0
+	if turbine := s.FindSteamTurbine(); turbine != nil {
+		turbine.synchronize(g.grid.Frequency())
+	}
+}
+
+// OpenBreaker takes the generator off the grid; the turbine governor has to
+// catch the shaft as the load comes off
+func (g *Generator) OpenBreaker(s *Simulation) {
+	g.breakerClosed = false
+	g.synchronizing = false
+	if turbine := s.FindSteamTurbine(); turbine != nil {
+		turbine.takeOffline()
+	}
+}
+
+func (g *Generator) tripBreaker(s *Simulation, cause string) {
+	g.OpenBreaker(s)
+	g.breakerTripCause = cause
+}
+
+func (g *Generator) BreakerClosed() bool {
+	return g.breakerClosed
+}
+
+func (g *Generator) BreakerTripCause() string {
+	return g.breakerTripCause
+}
+
+func (g *Generator) SwitchToAutomaticExcitation() {
+	g.excitationMode = EXCITATION_CONTROL_AUTOMATIC
+}
+
+// SwitchToManualExcitation leaves the field where the regulator had it
+func (g *Generator) SwitchToManualExcitation() {
+	g.excitationMode = EXCITATION_CONTROL_MANUAL
+}
+
+func (g *Generator) ExcitationMode() string {
+	return g.excitationMode
+}
+
+// SetExcitation sets the field, in per unit, while in manual
+func (g *Generator) SetExcitation(excitation float64) {
+	if g.excitationMode != EXCITATION_CONTROL_MANUAL {
+		fmt.Println("Cannot set the field. Excitation is in automatic.")
+		return
+	}
+	if excitation < 0 || excitation > MAX_EXCITATION {
+		fmt.Printf("Excitation must be between 0 and %.1f per unit. You requested %f.\n", MAX_EXCITATION, excitation)
+		return
+	}
+	g.excitation = excitation
+}
+
+func (g *Generator) Excitation() float64 {
+	return g.excitation
+}
+
+// SetVoltageSetpoint gives the terminal voltage, in per unit, the regulator holds
+func (g *Generator) SetVoltageSetpoint(voltage float64) {
+	if voltage < MIN_VOLTAGE_SETPOINT || voltage > MAX_VOLTAGE_SETPOINT {
+		fmt.Printf("Voltage setpoint must be between %.2f and %.2f per unit. You requested %f.\n", MIN_VOLTAGE_SETPOINT, MAX_VOLTAGE_SETPOINT, voltage)
+		return
+	}
+	g.voltageSetpoint = voltage
+}
+
+func (g *Generator) VoltageSetpoint() float64 {
+	return g.voltageSetpoint
+}
+
+func (g *Generator) Grid() *Grid {
+	return g.grid
 }
 
 func (g *Generator) Status() map[string]interface{} {
 	return map[string]interface{}{
-		"name":            g.Name,
-		"rpm":             g.rpm,
-		"electricalPower": g.electricalPower,
+		"name":             g.Name,
+		"rpm":              g.rpm,
+		"frequency":        g.frequency,
+		"electricalPower":  g.electricalPower,
+		"reactivePower":    g.reactivePower,
+		"powerFactor":      g.PowerFactor(),
+		"terminalVoltage":  g.terminalVoltage,
+		"phaseAngle":       g.phaseAngle,
+		"loadAngle":        g.loadAngle,
+		"excitation":       g.excitation,
+		"excitationMode":   g.excitationMode,
+		"voltageSetpoint":  g.voltageSetpoint,
+		"breakerClosed":    g.breakerClosed,
+		"synchronizing":    g.synchronizing,
+		"breakerTripCause": g.breakerTripCause,
+		"grid":             g.grid.Status(),
 	}
 }
 
 func (g *Generator) PrintStatus() {
 	fmt.Printf("Generator: %s\n", g.Name)
 	fmt.Printf("\tRPM: %.2f\n", g.rpm)
+	fmt.Printf("\tFrequency: %.3f Hz\n", g.frequency)
+	fmt.Printf("\tBreaker Closed: %t\n", g.breakerClosed)
 	fmt.Printf("\tElectrical Power: %.2f MW\n", g.electricalPower)
+	fmt.Printf("\tReactive Power: %.2f MVAr\n", g.reactivePower)
+	fmt.Printf("\tTerminal Voltage: %.2f kV\n", g.terminalVoltage)
+	fmt.Printf("\tExcitation: %.3f pu (%s)\n", g.excitation, g.excitationMode)
+	fmt.Printf("\tLoad Angle: %.1f°\n", g.loadAngle)
+	fmt.Printf("\tGrid: %s at %.3f Hz\n", g.grid.Mode(), g.grid.Frequency())
 }
 
 func (g *Generator) GetRPM() float64 {
@@ -54,3 +332,25 @@ func (g *Generator) GetRPM() float64 {
 func (g *Generator) GetElectricalPower() float64 {
 	return g.electricalPower
 }
+
+// MVAr
+func (g *Generator) GetReactivePower() float64 {
+	return g.reactivePower
+}
+
+// real over apparent power; 1 with no output
+func (g *Generator) PowerFactor() float64 {
+	apparent := math.Hypot(g.electricalPower, g.reactivePower)
+	if apparent == 0 {
+		return 1
+	}
+	return g.electricalPower / apparent
+}
+
+func (g *Generator) AlarmConditions() []*AlarmCondition {
+	return []*AlarmCondition{
+		NewAlarmCondition("generatorTrip", "GENERATOR BREAKER TRIP", ALARM_PRIORITY_HIGH, func() bool {
+			return g.breakerTripCause != ""
+		}),
+	}
+}
@@ -0,0 +1,146 @@
+package sim
+
+import (
+	"math"
+	"testing"
+)
+
+// a plant at rated power with the turbine run up to speed, off line
+func setUpGenerator() (*Simulation, *Environment, *Generator) {
+	simulation, env := setUpSteamCycle()
+	turbine := simulation.FindSteamTurbine()
+	turbine.online = false
+	turbine.loadReference = 0
+	turbine.governorValvePosition = 0
+	generator := NewGenerator("Test Generator")
+	simulation.AddComponent(generator)
+	updateSteamCycle(simulation, env)
+	return simulation, env, generator
+}
+
+func TestSyncCheckHoldsTheBreakerOpen(t *testing.T) {
+	simulation, env, generator := setUpGenerator()
+	turbine := simulation.FindSteamTurbine()
+
+	turbine.Trip()
+	if err := generator.CloseBreaker(simulation); err == nil {
+		t.Errorf("Expected the sync check to refuse a tripped turbine")
+	}
+
+	// the shaft coasting well under speed
+	turbine.ResetTrip()
+	turbine.speed = 0.97 * TURBINE_SYNCHRONOUS_SPEED
+	turbine.speedReference = turbine.speed
+	generator.Update(env, simulation)
+	if err := generator.CloseBreaker(simulation); err == nil {
+		t.Errorf("Expected the sync check to refuse a generator at %f Hz", generator.frequency)
+	}
+
+	// field off
+	updateSteamCycle(simulation, env)
+	updateSteamCycle(simulation, env)
+	generator.SwitchToManualExcitation()
+	generator.SetExcitation(0)
+	generator.Update(env, simulation)
+	if err := generator.CloseBreaker(simulation); err == nil {
+		t.Errorf("Expected the sync check to refuse a generator at %f kV", generator.terminalVoltage)
+	}
+	if generator.BreakerClosed() || turbine.IsOnline() {
+		t.Errorf("Expected the breaker to stay open")
+	}
+}
+
+func TestGeneratorSynchronizesAndLoads(t *testing.T) {
+	simulation, env, generator := setUpGenerator()
+	turbine := simulation.FindSteamTurbine()
+
+	if !almostEqual(generator.terminalVoltage, GENERATOR_RATED_VOLTAGE, 0.01*GENERATOR_RATED_VOLTAGE) {
+		t.Errorf("Expected the regulator to bring up rated voltage, got %f kV", generator.terminalVoltage)
+	}
+	if err := generator.CloseBreaker(simulation); err != nil {
+		t.Fatalf("Expected the generator in sync: %v", err)
+	}
+	updateSteamCycle(simulation, env)
+	if !generator.BreakerClosed() || !turbine.IsOnline() {
+		t.Fatalf("Expected the synchronizer to close the breaker as the phase came around, at %f°", generator.phaseAngle)
+	}
+
+	turbine.SetLoadSetpoint(100)
+	turbine.SetLoadRampRate(MAX_LOAD_RAMP_RATE)
+	for i := 0; i < 7; i++ {
+		updateSteamCycle(simulation, env)
+	}
+	if !almostEqual(generator.GetElectricalPower(), turbine.Power()*GENERATOR_EFFICIENCY, 1e-6) || generator.GetElectricalPower() < 900 {
+		t.Errorf("Expected the turbine's power less losses, got %f MW of %f", generator.GetElectricalPower(), turbine.Power())
+	}
+	if math.Abs(generator.GetReactivePower()) > 1 {
+		t.Errorf("Expected no reactive power with the terminal voltage at the grid's, got %f MVAr", generator.GetReactivePower())
+	}
+	if generator.loadAngle < 30 || generator.loadAngle > 80 {
+		t.Errorf("Expected the rotor well ahead of the grid at full load, got %f°", generator.loadAngle)
+	}
+
+	// raising the voltage setpoint pushes MVAr out to the grid
+	excitation := generator.Excitation()
+	generator.SetVoltageSetpoint(1.03)
+	generator.Update(env, simulation)
+	if !almostEqual(generator.GetReactivePower(), 0.2*GENERATOR_RATED_MVA, 1) {
+		t.Errorf("Expected the regulator to send out 220 MVAr, got %f", generator.GetReactivePower())
+	}
+	if generator.Excitation() <= excitation || generator.PowerFactor() >= 1 {
+		t.Errorf("Expected more field and a lagging power factor, got %f pu at %f", generator.Excitation(), generator.PowerFactor())
+	}
+}
+
+func TestUnderexcitedGeneratorSlipsAPole(t *testing.T) {
+	simulation, env, generator := setUpGenerator()
+	turbine := simulation.FindSteamTurbine()
+	generator.CloseBreaker(simulation)
+	updateSteamCycle(simulation, env)
+	runTurbineOnline(turbine, 100)
+	updateSteamCycle(simulation, env)
+
+	generator.SwitchToManualExcitation()
+	generator.SetExcitation(0.8)
+	generator.Update(env, simulation)
+	if generator.BreakerClosed() || generator.BreakerTripCause() != GENERATOR_TRIP_OUT_OF_STEP {
+		t.Errorf("Expected full load on too little field to slip a pole, trip cause %s", generator.BreakerTripCause())
+	}
+	if turbine.IsOnline() {
+		t.Errorf("Expected the turbine off line with the breaker open")
+	}
+}
+
+func TestFiniteGridFrequencyResponse(t *testing.T) {
+	simulation, env, generator := setUpGenerator()
+	turbine := simulation.FindSteamTurbine()
+	generator.Grid().UseFiniteGrid(950, DEFAULT_GRID_FREQUENCY_RESPONSE)
+	generator.CloseBreaker(simulation)
+	updateSteamCycle(simulation, env)
+	runTurbineOnline(turbine, 80)
+	for i := 0; i < 10; i++ {
+		updateSteamCycle(simulation, env)
+	}
+
+	// short of what the grid counts on, the frequency sags and droop opens the valves
+	if generator.Grid().Frequency() >= NOMINAL_GRID_FREQUENCY || generator.Grid().Frequency() < 59.9 {
+		t.Errorf("Expected the frequency a little low, got %f Hz", generator.Grid().Frequency())
+	}
+	if turbine.Load() <= 80.5 {
+		t.Errorf("Expected the governor to pick up load on the low frequency, got %f%%", turbine.Load())
+	}
+	// the turbine goes first, so it runs at the frequency the grid settled on a tick ago
+	if !almostEqual(turbine.speed, TURBINE_SYNCHRONOUS_SPEED*generator.Grid().Frequency()/NOMINAL_GRID_FREQUENCY, 1) {
+		t.Errorf("Expected the grid to hold the shaft at %f Hz, got %f rpm", generator.Grid().Frequency(), turbine.speed)
+	}
+
+	// the turbine trip takes the breaker with it
+	turbine.Trip()
+	updateSteamCycle(simulation, env)
+	if generator.BreakerClosed() || generator.BreakerTripCause() != GENERATOR_TRIP_TURBINE {
+		t.Errorf("Expected the turbine trip to open the breaker, trip cause %s", generator.BreakerTripCause())
+	}
+	if generator.GetElectricalPower() != 0 {
+		t.Errorf("Expected no output off line, got %f MW", generator.GetElectricalPower())
+	}
+}
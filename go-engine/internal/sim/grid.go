@@ -0,0 +1,100 @@
+package sim
+
+import (
+	"fmt"
+)
+
+// The grid the generator ties into. An infinite bus is a grid so big that
+// nothing one unit does moves its frequency or voltage. A finite grid has a
+// frequency response: the other generation on it picks up or sheds load as
+// the frequency moves, so frequency settles where this unit's output, plus
+// that response, meets the load it is expected to carry. While the unit is
+// off the grid, the rest of the grid covers for it at nominal frequency.
+
+const (
+	GRID_INFINITE_BUS = "infiniteBus"
+	GRID_FINITE       = "finite"
+)
+
+const NOMINAL_GRID_FREQUENCY = 60.0            // Hz
+const DEFAULT_GRID_FREQUENCY_RESPONSE = 2000.0 // MW/Hz from the rest of a finite grid
+const MIN_GRID_FREQUENCY_RESPONSE = 500.0      // MW/Hz; anything weaker and the unit would be the grid
+const DEFAULT_GRID_LOAD = 900.0                // MW a finite grid counts on from this unit once it is tied in
+
+type Grid struct {
+	mode              string
+	frequency         float64 // Hz
+	voltage           float64 // per unit of rated generator voltage
+	load              float64 // MW the rest of a finite grid leaves for this unit
+	frequencyResponse float64 // MW/Hz
+}
+
+func NewGrid() *Grid {
+	return &Grid{
+		mode:              GRID_INFINITE_BUS,
+		frequency:         NOMINAL_GRID_FREQUENCY,
+		voltage:           1.0,
+		load:              DEFAULT_GRID_LOAD,
+		frequencyResponse: DEFAULT_GRID_FREQUENCY_RESPONSE,
+	}
+}
+
+// Update settles the frequency for the given MW coming from the generator,
+// if it is tied in
+func (g *Grid) Update(generation float64, connected bool) {
+	if g.mode == GRID_INFINITE_BUS || !connected {
+		g.frequency = NOMINAL_GRID_FREQUENCY
+		return
+	}
+	g.frequency = NOMINAL_GRID_FREQUENCY + (generation-g.load)/g.frequencyResponse
+}
+
+func (g *Grid) UseInfiniteBus() {
+	g.mode = GRID_INFINITE_BUS
+	g.frequency = NOMINAL_GRID_FREQUENCY
+}
+
+// UseFiniteGrid has the unit carry the given MW on a grid whose other
+// generation answers a change in frequency with the given MW/Hz
+func (g *Grid) UseFiniteGrid(load float64, frequencyResponse float64) {
+	if load < 0 {
+		fmt.Printf("Grid load cannot be negative. You requested %f MW.\n", load)
+		return
+	}
+	if frequencyResponse < MIN_GRID_FREQUENCY_RESPONSE {
+		fmt.Printf("Grid frequency response must be at least %.0f MW/Hz. You requested %f.\n", MIN_GRID_FREQUENCY_RESPONSE, frequencyResponse)
+		return
+	}
+	g.mode = GRID_FINITE
+	g.load = load
+	g.frequencyResponse = frequencyResponse
+}
+
+func (g *Grid) Mode() string {
+	return g.mode
+}
+
+// Hz
+func (g *Grid) Frequency() float64 {
+	return g.frequency
+}
+
+// per unit
+func (g *Grid) Voltage() float64 {
+	return g.voltage
+}
+
+// MW
+func (g *Grid) Load() float64 {
+	return g.load
+}
+
+func (g *Grid) Status() map[string]interface{} {
+	return map[string]interface{}{
+		"mode":              g.mode,
+		"frequency":         g.frequency,
+		"voltage":           g.voltage,
+		"load":              g.load,
+		"frequencyResponse": g.frequencyResponse,
+	}
+}
@@ -0,0 +1,100 @@
+package sim
+
+import (
+	"fmt"
+	"math"
+
+	"won/sim-lab/go-engine/internal/steam"
+)
+
+// The moisture separator reheater sits on the crossover between the HP and
+// LP turbines. Saturated steam comes out of the HP turbine wet, and water
+// droplets erode the LP blades and drag on them: every percent of moisture
+// costs about a percent of stage efficiency. The separator wrings the water
+// out, draining it to the high-pressure heater at crossover pressure, and the
+// reheater then superheats the dry steam with steam bled from the HP
+// turbine, so it reaches the end of the LP turbine drier. The reheating
+// steam condenses in the tube bundle and drains to the top high-pressure
+// heater.
+//
+// With the reheater out of service, the LP turbine takes dry saturated steam
+// from the separator, and ends up wetter and less efficient.
+
+const DESIGN_CROSSOVER_PRESSURE = 1.1         // MPa, at rated steam flow
+const REHEATER_TERMINAL_DIFFERENCE = 15.0     // °C, reheated steam out below the heating steam
+const MOISTURE_SEPARATOR_EFFECTIVENESS = 0.98 // fraction of the water wrung out
+
+type MoistureSeparatorReheater struct {
+	reheaterInService  bool
+	inletQuality       float64 // of the HP exhaust
+	outletQuality      float64 // going to the LP turbine
+	outletTemperature  float64 // °C
+	separatorDrainFlow float64 // kg/s of water wrung out
+	reheatingSteamFlow float64 // kg/s bled from the HP turbine
+}
+
+func NewMoistureSeparatorReheater() *MoistureSeparatorReheater {
+	return &MoistureSeparatorReheater{
+		reheaterInService: true,
+		inletQuality:      1,
+		outletQuality:     1,
+	}
+}
+
+// condition takes the HP exhaust and the steam available to reheat it and
+// gives the steam going on to the LP turbine, with the kg of it per kg of
+// HP exhaust and the kg of heating steam that takes
+func (msr *MoistureSeparatorReheater) condition(exhaust steam.State, heating steam.State) (steam.State, float64, float64) {
+	msr.inletQuality = exhaust.Quality
+	moisture := (1 - exhaust.Quality) * MOISTURE_SEPARATOR_EFFECTIVENESS
+	dried := steam.FromEnthalpy(exhaust.Pressure, steam.SaturatedLiquid(exhaust.Pressure).Enthalpy+
+		(exhaust.Enthalpy-steam.SaturatedLiquid(exhaust.Pressure).Enthalpy)/(1-moisture))
+	carriedOn := 1 - moisture
+
+	heatingSteam := 0.0
+	outlet := dried
+	if msr.reheaterInService && heating.Pressure > exhaust.Pressure {
+		reheatTemperature := steam.SaturationTemperature(heating.Pressure) - REHEATER_TERMINAL_DIFFERENCE
+		if reheatTemperature > dried.Temperature {
+			outlet = steam.At(exhaust.Pressure, reheatTemperature)
+			heatingSteam = carriedOn * (outlet.Enthalpy - dried.Enthalpy) /
+				(heating.Enthalpy - steam.SaturatedLiquid(heating.Pressure).Enthalpy)
+		}
+	}
+	msr.outletQuality = math.Min(1, outlet.Quality)
+	msr.outletTemperature = outlet.Temperature
+	return outlet, carriedOn, heatingSteam
+}
+
+func (msr *MoistureSeparatorReheater) ReheaterInService() bool {
+	return msr.reheaterInService
+}
+
+func (msr *MoistureSeparatorReheater) ReturnReheaterToService() {
+	msr.reheaterInService = true
+}
+
+func (msr *MoistureSeparatorReheater) TakeReheaterOutOfService() {
+	msr.reheaterInService = false
+}
+
+// °C of the steam going to the LP turbine
+func (msr *MoistureSeparatorReheater) OutletTemperature() float64 {
+	return msr.outletTemperature
+}
+
+func (msr *MoistureSeparatorReheater) Status() map[string]interface{} {
+	return map[string]interface{}{
+		"reheaterInService":  msr.reheaterInService,
+		"inletQuality":       msr.inletQuality,
+		"outletQuality":      msr.outletQuality,
+		"outletTemperature":  msr.outletTemperature,
+		"separatorDrainFlow": msr.separatorDrainFlow,
+		"reheatingSteamFlow": msr.reheatingSteamFlow,
+	}
+}
+
+func (msr *MoistureSeparatorReheater) PrintStatus() {
+	fmt.Printf("\tMoisture Separator Reheater: reheater %s, quality %.3f in, %.1f °C out\n",
+		inServiceString(msr.reheaterInService), msr.inletQuality, msr.outletTemperature)
+}
@@ -0,0 +1,224 @@
+package sim
+
+import (
+	"fmt"
+	"math"
+	"testing"
+)
+
+// a four-loop plant at rated power, with the secondary side hot and every
+// steam generator level settled
+func setUpFourLoopPlant() (*Simulation, *Environment, []*PrimaryLoop) {
+	simulation, env := setupSimulationEnvironment()
+	reactorCore := NewReactorCore("Test Reactor Core")
+	simulation.AddComponent(reactorCore)
+	primaryLoops := AddCoolantLoops(simulation, 4)
+	for _, primaryLoop := range primaryLoops {
+		primaryLoop.SwitchOnPump()
+		reactorCore.ConnectToPrimaryLoop(primaryLoop)
+	}
+	secondaryLoop := NewSecondaryLoop("Test Secondary Loop")
+	secondaryLoop.SwitchOnFeedwaterPump()
+	secondaryLoop.SwitchOnFeedheaters()
+	simulation.AddComponent(secondaryLoop)
+	turbine := NewSteamTurbine("Test Turbine")
+	runTurbineOnline(turbine, 100)
+	simulation.AddComponent(turbine)
+
+	reactorCore.heatEnergyRate = RATED_THERMAL_POWER
+	// the header needs steam to come up to pressure, and the heaters need
+	// steam from the turbine to bring the feedwater up
+	for i := 0; i < 60; i++ {
+		updatePlantLoops(simulation, env)
+		turbine.Update(env, simulation)
+	}
+	return simulation, env, primaryLoops
+}
+
+// the loops and steam generators, with the loops meeting in the vessel but
+// the core left at the power the test set
+func updatePlantLoops(simulation *Simulation, env *Environment) {
+	for _, primaryLoop := range simulation.FindPrimaryLoops() {
+		primaryLoop.Update(env, simulation)
+	}
+	for _, steamGenerator := range simulation.FindSteamGenerators() {
+		steamGenerator.Update(env, simulation)
+	}
+	simulation.FindSecondaryLoop().Update(env, simulation)
+	simulation.FindReactorCore().mixLoops()
+}
+
+func TestFourLoopsShareTheCore(t *testing.T) {
+	simulation, env, primaryLoops := setUpFourLoopPlant()
+
+	for _, primaryLoop := range primaryLoops {
+		if !almostEqual(primaryLoop.CoreShare(simulation), 0.25, 1e-6) {
+			t.Errorf("Expected each loop to carry a quarter of the core, %s carries %f", primaryLoop.Name, primaryLoop.CoreShare(simulation))
+		}
+		steamGenerator := primaryLoop.SteamGenerator(simulation)
+		if steamGenerator == nil || steamGenerator.Name != fmt.Sprintf("Steam Generator %d", primaryLoop.LoopNumber()) {
+			t.Fatalf("Expected %s to have its own steam generator", primaryLoop.Name)
+		}
+		if !almostEqual(steamGenerator.SteamFlowRate(), RATED_STEAM_FLOW/4, 0.02*RATED_STEAM_FLOW) {
+			t.Errorf("Expected a quarter of rated steam from %s, got %f kg/s", steamGenerator.Name, steamGenerator.SteamFlowRate())
+		}
+	}
+
+	// all four steam into the one header
+	turbine := simulation.FindSteamTurbine()
+	turbine.Update(env, simulation)
+	if !almostEqual(turbine.Load(), 100, 2) {
+		t.Errorf("Expected the turbine to see every steam generator, got %f%% load", turbine.Load())
+	}
+}
+
+func TestIsolatingOneSteamGeneratorTripsOnItsLevel(t *testing.T) {
+	simulation, env, primaryLoops := setUpFourLoopPlant()
+	reactorProtection := NewReactorProtection("Test Reactor Protection")
+	simulation.AddComponent(reactorProtection)
+
+	isolated := primaryLoops[1].SteamGenerator(simulation)
+	isolated.IsolateFeedwater()
+	for i := 0; i < 3; i++ {
+		updatePlantLoops(simulation, env)
+	}
+
+	if !isolated.LowLowLevel() {
+		t.Errorf("Expected %s to boil down without feed, at %f%%", isolated.Name, isolated.Level())
+	}
+	for _, primaryLoop := range primaryLoops {
+		steamGenerator := primaryLoop.SteamGenerator(simulation)
+		if steamGenerator != isolated && !almostEqual(steamGenerator.Level(), NORMAL_STEAM_GENERATOR_LEVEL, 5) {
+			t.Errorf("Expected %s to hold its level, at %f%%", steamGenerator.Name, steamGenerator.Level())
+		}
+	}
+
+	// the loop that lost its heat sink runs hotter back to the core
+	if primaryLoops[1].ColdLegTemperature() <= primaryLoops[0].ColdLegTemperature() {
+		t.Errorf("Expected loop 2 cold leg above loop 1, got %f and %f", primaryLoops[1].ColdLegTemperature(), primaryLoops[0].ColdLegTemperature())
+	}
+
+	reactorProtection.Update(env, simulation)
+	if reactorProtection.FirstOut() != STEAM_GENERATOR_LOW_LEVEL_TRIP {
+		t.Errorf("Expected one steam generator at low-low level to trip the reactor, first out %s", reactorProtection.FirstOut())
+	}
+	if simulation.Annunciator().findAlarm("steamGeneratorLowLowLevel2") == nil {
+		t.Errorf("Expected an alarm window for each steam generator")
+	}
+}
+
+func TestLosingOneReactorCoolantPump(t *testing.T) {
+	simulation, env, primaryLoops := setUpFourLoopPlant()
+	simulation.FindReactorCore().kinetics = NewPointKinetics(1.0)
+	reactorProtection := NewReactorProtection("Test Reactor Protection")
+	simulation.AddComponent(reactorProtection)
+
+	primaryLoops[2].SwitchOffPump()
+	updatePlantLoops(simulation, env)
+
+	// the flywheel keeps it turning, but well under the trip setpoint within the minute
+	reactorProtection.Update(env, simulation)
+	if reactorProtection.FirstOut() != LOW_PRIMARY_FLOW_TRIP {
+		t.Errorf("Expected low flow in one loop to trip the reactor, first out %s", reactorProtection.FirstOut())
+	}
+
+	for i := 0; i < 3; i++ {
+		updatePlantLoops(simulation, env)
+	}
+	if !primaryLoops[2].Pump(1).Stopped() {
+		t.Errorf("Expected the pump to coast to a stop, still at %f rpm", primaryLoops[2].Pump(1).Speed())
+	}
+	if primaryLoops[2].CoreShare(simulation) > 0.05 {
+		t.Errorf("Expected little core heat through a loop with its pump stopped, got %f", primaryLoops[2].CoreShare(simulation))
+	}
+	if primaryLoops[0].CoreShare(simulation) < 0.3 {
+		t.Errorf("Expected the running loops to pick up the core, loop 1 carries %f", primaryLoops[0].CoreShare(simulation))
+	}
+}
+
+func TestLoopsMixInTheVessel(t *testing.T) {
+	simulation, _, primaryLoops := setUpFourLoopPlant()
+	reactorCore := simulation.FindReactorCore()
+
+	primaryLoops[0].averageTemperature += 8
+	primaryLoops[0].boronConcentration = 400
+	total := 0.0
+	for _, primaryLoop := range primaryLoops {
+		total += primaryLoop.AverageTemperature()
+	}
+
+	reactorCore.mixLoops()
+	after := 0.0
+	for _, primaryLoop := range primaryLoops {
+		after += primaryLoop.AverageTemperature()
+	}
+	if !almostEqual(after, total, 1e-9) {
+		t.Errorf("Expected mixing to keep the heat, sum of Tavg went from %f to %f", total, after)
+	}
+	if !almostEqual(primaryLoops[0].AverageTemperature(), primaryLoops[3].AverageTemperature(), 0.2) {
+		t.Errorf("Expected a minute at full flow to mix the loops, got %f and %f", primaryLoops[0].AverageTemperature(), primaryLoops[3].AverageTemperature())
+	}
+	if !almostEqual(reactorCore.boronConcentration(), 100, 0.01) {
+		t.Errorf("Expected boron from one loop to spread through the others, core sees %f ppm", reactorCore.boronConcentration())
+	}
+}
+
+// levelRecoveryTime steps the load from 30 back up to 100 percent and gives
+// the ticks until every steam generator is back within a percent of its
+// level setpoint for good
+func levelRecoveryTime(simulation *Simulation, update func()) int {
+	step := func(load float64, ticks int) int {
+		simulation.FindReactorCore().heatEnergyRate = RATED_THERMAL_POWER * load / 100
+		runTurbineOnline(simulation.FindSteamTurbine(), load)
+		recovered := 0
+		for i := 1; i <= ticks; i++ {
+			update()
+			for _, steamGenerator := range simulation.FindSteamGenerators() {
+				if math.Abs(steamGenerator.Level()-NORMAL_STEAM_GENERATOR_LEVEL) > 1 {
+					recovered = i
+				}
+			}
+		}
+		return recovered
+	}
+	step(30, 40)
+	return step(100, 60)
+}
+
+func TestEachSteamGeneratorRecoversItsLevelLikeASingleLoop(t *testing.T) {
+	single, env := setUpSteamCycle()
+	singleTime := levelRecoveryTime(single, func() { updateSteamCycle(single, env) })
+
+	simulation, env, _ := setUpFourLoopPlant()
+	fourLoopTime := levelRecoveryTime(simulation, func() {
+		updatePlantLoops(simulation, env)
+		simulation.FindSteamTurbine().Update(env, simulation)
+	})
+	if singleTime == 0 || fourLoopTime == 0 {
+		t.Fatalf("Expected the load step to move the levels, recovered in %d and %d ticks", singleTime, fourLoopTime)
+	}
+	if math.Abs(float64(fourLoopTime-singleTime)) > 3 {
+		t.Errorf("Expected each steam generator back on level about as fast as a single loop's %d ticks, took %d", singleTime, fourLoopTime)
+	}
+}
+
+func TestFeedwaterDemandStaysWithinEachValve(t *testing.T) {
+	simulation, env, _ := setUpFourLoopPlant()
+	steamGenerator := simulation.FindSteamGenerators()[0]
+	capacity := steamGenerator.maxFeedwaterFlowRate(simulation)
+
+	// starved of feed, the level falls away and the level term winds up, but
+	// only as far as the one valve could ever pass
+	steamGenerator.IsolateFeedwater()
+	for i := 0; i < 5; i++ {
+		updatePlantLoops(simulation, env)
+		simulation.FindSteamTurbine().Update(env, simulation)
+	}
+	control := steamGenerator.FeedwaterControl()
+	if control.levelIntegral > capacity || control.flowDemand > capacity {
+		t.Errorf("Expected the demand held to the %f kg/s valve, got %f integral and %f demand", capacity, control.levelIntegral, control.flowDemand)
+	}
+	if control.ValvePosition() < 99 {
+		t.Errorf("Expected the valve driven wide open for the lost level, at %f%%", control.ValvePosition())
+	}
+}
@@ -0,0 +1,128 @@
+package sim
+
+import (
+	"math"
+)
+
+// Point kinetics treats the whole core as a single point: the neutron population
+// rises and falls together everywhere. It is the model operators are trained on,
+// and it is good enough to show the prompt jump, the stable period and the
+// startup rate after a reactivity change.
+//
+//   dn/dt  = (ρ - β) / Λ * n + Σ λi * Ci + S
+//   dCi/dt = βi / Λ * n - λi * Ci
+//
+// n is the neutron population relative to rated power (1.0 = 100%), Ci are the
+// six delayed neutron precursor groups, Λ is the prompt neutron generation time
+// and S is the installed neutron source that keeps a shut down core "visible".
+
+const DELAYED_GROUPS = 6
+const PROMPT_NEUTRON_LIFETIME = 2.0e-5 // seconds
+const NEUTRON_SOURCE_STRENGTH = 1.0e-5 // relative power per second
+const KINETICS_TIME_STEP = 0.1         // seconds; largest integration step
+const MAX_RELATIVE_POWER = 50.0        // the model gives up here; the core would be long gone
+
+// U-235 thermal fission delayed neutron data (Keepin)
+var delayedNeutronFractions = [DELAYED_GROUPS]float64{0.000215, 0.001424, 0.001274, 0.002568, 0.000748, 0.000273}
+var precursorDecayConstants = [DELAYED_GROUPS]float64{0.0124, 0.0305, 0.111, 0.301, 1.14, 3.01} // per second
+
+// total delayed neutron fraction; one dollar of reactivity
+func delayedNeutronFraction() float64 {
+	beta := 0.0
+	for _, b := range delayedNeutronFractions {
+		beta += b
+	}
+	return beta
+}
+
+func pcmToDollars(pcm float64) float64 {
+	return pcm / 1e5 / delayedNeutronFraction()
+}
+
+type PointKinetics struct {
+	neutrons    float64                 // relative to rated power
+	precursors  [DELAYED_GROUPS]float64 // delayed neutron precursor concentrations
+	lastChange  float64                 // ratio of neutron population after / before the last advance
+	lastSeconds float64                 // length of the last advance
+}
+
+// NewPointKinetics starts the core at the given relative power with precursors
+// in equilibrium, as if it had been sitting there a long time.
+func NewPointKinetics(relativePower float64) *PointKinetics {
+	pk := &PointKinetics{neutrons: relativePower, lastChange: 1.0}
+	for i := range pk.precursors {
+		pk.precursors[i] = delayedNeutronFractions[i] / (precursorDecayConstants[i] * PROMPT_NEUTRON_LIFETIME) * relativePower
+	}
+	return pk
+}
+
+// Advance integrates the kinetics equations over the given number of seconds
+// with reactivity held constant (in pcm). Implicit Euler keeps the stiff prompt
+// mode stable; the step shrinks when the core is prompt critical.
+func (pk *PointKinetics) Advance(reactivity float64, seconds float64) {
+	rho := reactivity / 1e5
+	beta := delayedNeutronFraction()
+	start := pk.neutrons
+
+	step := KINETICS_TIME_STEP
+	if rho > beta {
+		step = math.Min(step, 0.5*PROMPT_NEUTRON_LIFETIME/(rho-beta))
+	}
+
+	for elapsed := 0.0; elapsed < seconds; elapsed += step {
+		dt := math.Min(step, seconds-elapsed)
+		pk.step(rho, beta, dt)
+		if pk.neutrons >= MAX_RELATIVE_POWER {
+			pk.neutrons = MAX_RELATIVE_POWER
+			break
+		}
+	}
+
+	if start > 0 {
+		pk.lastChange = pk.neutrons / start
+		pk.lastSeconds = seconds
+	}
+}
+
+func (pk *PointKinetics) step(rho, beta, dt float64) {
+	numerator := pk.neutrons + dt*NEUTRON_SOURCE_STRENGTH
+	denominator := 1 - dt*(rho-beta)/PROMPT_NEUTRON_LIFETIME
+	for i := range pk.precursors {
+		decay := 1 + dt*precursorDecayConstants[i]
+		numerator += dt * precursorDecayConstants[i] * pk.precursors[i] / decay
+		denominator -= dt * dt * precursorDecayConstants[i] * delayedNeutronFractions[i] / PROMPT_NEUTRON_LIFETIME / decay
+	}
+	pk.neutrons = math.Max(0, numerator/denominator)
+
+	for i := range pk.precursors {
+		pk.precursors[i] = (pk.precursors[i] + dt*delayedNeutronFractions[i]/PROMPT_NEUTRON_LIFETIME*pk.neutrons) /
+			(1 + dt*precursorDecayConstants[i])
+	}
+}
+
+// relative to rated power
+func (pk *PointKinetics) RelativePower() float64 {
+	return pk.neutrons
+}
+
+// StartupRate is the rate of change of the neutron population over the last
+// advance, in decades per minute (DPM).
+func (pk *PointKinetics) StartupRate() float64 {
+	if pk.lastChange <= 0 || pk.lastSeconds <= 0 {
+		return 0
+	}
+	return math.Log10(pk.lastChange) * 60 / pk.lastSeconds
+}
+
+// Period is the e-folding time of the neutron population in seconds. Negative
+// when power is falling; infinite when it is steady.
+func (pk *PointKinetics) Period() float64 {
+	if pk.lastChange <= 0 || pk.lastSeconds <= 0 {
+		return math.Inf(1)
+	}
+	growth := math.Log(pk.lastChange)
+	if math.Abs(growth) < 1e-9 {
+		return math.Inf(1)
+	}
+	return pk.lastSeconds / growth
+}
@@ -0,0 +1,73 @@
+package sim
+
+import (
+	"testing"
+)
+
+func TestPointKineticsHoldsSteadyWhenCritical(t *testing.T) {
+	pk := NewPointKinetics(1.0)
+
+	for i := 0; i < 60; i++ {
+		pk.Advance(0, SECONDS_PER_TICK)
+	}
+
+	if !almostEqual(pk.RelativePower(), 1.0, 0.001) {
+		t.Errorf("Expected power to hold at 1.0 when critical, got %f", pk.RelativePower())
+	}
+	if !almostEqual(pk.StartupRate(), 0, 0.001) {
+		t.Errorf("Expected startup rate near 0 when critical, got %f", pk.StartupRate())
+	}
+}
+
+func TestPointKineticsPromptJump(t *testing.T) {
+	pk := NewPointKinetics(1.0)
+	reactivity := 100.0 // pcm, well below one dollar
+	beta := delayedNeutronFraction()
+
+	// a tenth of a second is enough for the prompt neutrons to settle
+	pk.Advance(reactivity, 0.1)
+
+	expected := beta / (beta - reactivity/1e5)
+	if !almostEqual(pk.RelativePower(), expected, 0.02) {
+		t.Errorf("Expected prompt jump to about %f, got %f", expected, pk.RelativePower())
+	}
+}
+
+func TestPointKineticsStablePeriod(t *testing.T) {
+	pk := NewPointKinetics(1e-6)
+
+	// let the transient die out, then the period should stay put
+	for i := 0; i < 5; i++ {
+		pk.Advance(100, SECONDS_PER_TICK)
+	}
+	firstPeriod := pk.Period()
+	pk.Advance(100, SECONDS_PER_TICK)
+	secondPeriod := pk.Period()
+
+	if firstPeriod <= 0 {
+		t.Errorf("Expected a positive period with positive reactivity, got %f", firstPeriod)
+	}
+	if !almostEqual(firstPeriod, secondPeriod, 1.0) {
+		t.Errorf("Expected a stable period, got %f then %f", firstPeriod, secondPeriod)
+	}
+	// 100 pcm of U-235 fuel gives roughly a one minute period
+	if firstPeriod < 40 || firstPeriod > 120 {
+		t.Errorf("Expected a period of about a minute for 100 pcm, got %f", firstPeriod)
+	}
+	if pk.StartupRate() <= 0 {
+		t.Errorf("Expected a positive startup rate, got %f", pk.StartupRate())
+	}
+}
+
+func TestPointKineticsNegativeStartupRateAfterScram(t *testing.T) {
+	pk := NewPointKinetics(1.0)
+
+	for i := 0; i < 5; i++ {
+		pk.Advance(-8000, SECONDS_PER_TICK)
+	}
+
+	// power falls no faster than the longest-lived precursors allow: about -1/3 DPM
+	if !almostEqual(pk.StartupRate(), -0.33, 0.05) {
+		t.Errorf("Expected startup rate to settle near -1/3 DPM, got %f", pk.StartupRate())
+	}
+}
@@ -2,40 +2,128 @@ package sim
 
 import (
 	"fmt"
+	"math"
+
+	"won/sim-lab/go-engine/internal/steam"
 )
 
+// The pressurizer is a tall vessel on the hot leg holding water at the bottom
+// and a steam bubble on top, both at saturation, so the pressure of the whole
+// primary side is the saturation pressure of the pressurizer water.
+//
+// It is modeled by the mass and internal energy of what is inside. Each step
+// those are moved by:
+//
+//   - insurge and outsurge, as the loop coolant expands and shrinks with its
+//     average temperature; insurge brings in hot leg water, outsurge takes
+//     water from the bottom of the vessel. Cooler insurge water mostly
+//     settles in the bottom head rather than mixing, so an insurge squeezes
+//     the bubble and raises pressure instead of quenching it.
+//   - heaters in the water, a proportional group that modulates and a backup
+//     group that is on or off
+//   - charging and letdown, which add and take away coolant
+//   - spray of cold leg water into the steam space, pushed by the reactor
+//     coolant pumps, which condenses steam
+//   - the power-operated relief valve and the code safety valves, which let
+//     steam out of the top
+//
+// and the pressure is then the one at which that mass and energy, as
+// saturated water and steam, exactly fill the vessel. If the water fills the
+// vessel (it goes solid) there is no bubble to cushion it, and pressure
+// climbs steeply with any more water.
 type Pressurizer struct {
 	BaseComponent
-	targetPressure    float64
-	pressure          float64
-	temperature       float64
-	heaterOn          bool
-	heaterPower       float64 // in kW
-	heaterTemperature float64
-	sprayNozzleOpen   bool
-	sprayFlowRate     float64 // in kg/s
-	reliefValveOpened bool
+	targetPressure           float64
+	pressure                 float64 // MPa
+	temperature              float64 // °C, saturation temperature at the pressure
+	level                    float64 // percent of the vessel filled with water
+	waterMass                float64 // kg
+	steamMass                float64 // kg
+	internalEnergy           float64 // kJ, of all the water and steam inside
+	solid                    bool    // water fills the vessel; no steam bubble
+	loopCoolantMass          float64 // kg in the primary loop as of the last update
+	surgeFlowRate            float64 // kg/s, into the pressurizer when positive
+	heaterOn                 bool    // heater breakers closed
+	proportionalHeaterDemand float64 // fraction of proportional heater power wanted
+	proportionalHeaterPower  float64 // kW
+	backupHeatersOn          bool
+	heaterPower              float64 // in kW
+	sprayNozzleOpen          bool
+	sprayValvePosition       float64 // fraction open
+	sprayFlowRate            float64 // in kg/s
+	porvDemand               bool    // PORV open by the controller or the operator
+	porvHighPressure         bool    // PORV held open by its own high pressure switch
+	porvOpen                 bool
+	safetyValvesOpen         bool
+	reliefValveOpened        bool    // either lifted at some point during the last update
+	reliefFlowRate           float64 // kg/s, out through the PORV and safety valves
+	pressureControlMode      string
+	masterLastPressure       float64 // MPa, at the last step
+	masterIntegral           float64 // MPa
+	masterOutput             float64 // MPa, compensated pressure error
+	levelControlMode         string
+	levelSetpoint            float64 // percent
+	levelIntegral            float64 // kg/s
+	chargingFlowRate         float64 // kg/s into the cold leg
+	letdownFlowRate          float64 // kg/s out of the cold leg
+	letdownIsolated          bool
 }
 
 const TARGET_PRESSURE = 15.5                 // MPa, typical PWR pressurizer pressure
-const TARGET_TEMPERATURE = 345.0             // °C, typical PWR pressurizer temperature
-const HEATER_HIGH_POWER = 1500.0             // kW, typical pressurizer heater capacity
-const HEATER_LOW_POWER = 50.0                // kW, enough to hold steady
-const SPRAY_FLOW_RATE = 10.0                 // kg/s, typical spray flow rate
-const RELIEF_VALVE_FLOW = 50.0               // kg/s, typical relief valve flow rate
-const RELIEF_VALVE_THRESHOLD_PRESSURE = 17.0 // °C, typical PWR pressurizer temperature
-const RELIEF_VALVE_DROP_DELTA = 2.5
+const TARGET_TEMPERATURE = 344.8             // °C, saturation temperature at TARGET_PRESSURE
+const PRESSURIZER_VOLUME = 51.0              // m³
+const PRESSURIZER_NO_LOAD_LEVEL = 25.0       // percent
+const PRESSURIZER_TIME_STEP = 10.0           // seconds
+const PRESSURIZER_HEAT_LOSS = 0.15           // kW/°C above room temperature, through the insulation
+const PROPORTIONAL_HEATER_POWER = 400.0      // kW
+const PROPORTIONAL_HEATER_BAND = 0.2         // MPa; full on at the bottom of the band, off at the top
+const BACKUP_HEATER_POWER = 1400.0           // kW
+const HEATER_CUTOFF_LEVEL = 17.0             // percent; heaters trip off before they are uncovered
+const INSURGE_MIXING = 0.2                   // fraction of the insurge's subcooling that mixes in; the rest stratifies in the bottom head
+const SPRAY_FLOW_RATE = 25.0                 // kg/s, with the spray valves open and the pumps running
+const PORV_OPEN_PRESSURE = 16.2              // MPa
+const PORV_CLOSE_PRESSURE = 16.0             // MPa
+const PORV_FLOW = 50.0                       // kg/s
+const SAFETY_VALVE_OPEN_PRESSURE = 17.2      // MPa, code safety valve lift
+const SAFETY_VALVE_RESEAT_PRESSURE = 16.7    // MPa, after blowdown
+const SAFETY_VALVE_FLOW = 160.0              // kg/s, three valves together
+const WATER_COMPRESSIBILITY = 0.002          // per MPa, near operating temperature
+const PRESSURIZER_HIGH_PRESSURE_ALARM = 16.2 // MPa
+const PRESSURIZER_LOW_LEVEL_ALARM = 17.0     // percent
+const PRESSURIZER_HIGH_LEVEL_ALARM = 92.0    // percent
 
 func NewPressurizer(name string) *Pressurizer {
-	return &Pressurizer{
-		BaseComponent:     BaseComponent{Name: name},
-		pressure:          0.0, // MPa, typical PWR pressurizer pressure
-		targetPressure:    TARGET_PRESSURE,
-		temperature:       ROOM_TEMPERATURE, // °C, typical PWR pressurizer temperature
-		heaterPower:       0.0,              // kW, typical pressurizer heater capacity
-		heaterTemperature: ROOM_TEMPERATURE, // °C, typical pressurizer temperature
-		sprayFlowRate:     0.0,              // kg/s, typical spray flow rate
+	p := &Pressurizer{
+		BaseComponent:       BaseComponent{Name: name},
+		targetPressure:      TARGET_PRESSURE,
+		pressureControlMode: PRESSURIZER_CONTROL_AUTOMATIC,
+		levelControlMode:    PRESSURIZER_CONTROL_AUTOMATIC,
+		levelSetpoint:       PRESSURIZER_NO_LOAD_LEVEL,
+		chargingFlowRate:    LETDOWN_FLOW_RATE,
+		letdownFlowRate:     LETDOWN_FLOW_RATE,
 	}
+	p.fill(ROOM_TEMPERATURE, PRESSURIZER_NO_LOAD_LEVEL)
+	return p
+}
+
+// fill sets the contents to saturated water and steam at the given
+// temperature, with water up to the given level
+func (p *Pressurizer) fill(temperature, level float64) {
+	pressure := steam.SaturationPressure(temperature)
+	water := steam.SaturatedLiquid(pressure)
+	vapor := steam.SaturatedVapor(pressure)
+	waterVolume := PRESSURIZER_VOLUME * level / 100
+
+	p.waterMass = waterVolume * water.Density
+	p.steamMass = (PRESSURIZER_VOLUME - waterVolume) * vapor.Density
+	p.internalEnergy = p.waterMass*internalEnergy(water) + p.steamMass*internalEnergy(vapor)
+	p.settle()
+	p.masterLastPressure = p.pressure
+}
+
+// kJ/kg
+func internalEnergy(state steam.State) float64 {
+	return state.Enthalpy - state.Pressure*1000/state.Density
 }
 
 func (p *Pressurizer) GetName() string {
@@ -43,84 +131,191 @@ func (p *Pressurizer) GetName() string {
 }
 
 func (p *Pressurizer) Update(env *Environment, s *Simulation) {
-	// dt := 60 // seconds
-	//
-	// Simple formula for pressure changes:
-	//   ΔP = (Q * β) / (V * Cp)
-	//
-	// Where:
-	//   ΔP = Change in pressure
-	//   Q = Heat input from the heater
-	//   β = Coefficient of thermal expansion of water
-	//   V = Volume of the pressurizer
-	//   Cp = Specific heat capacity of water at constant pressure
-	//
-	// Also need the formula for water temperature based on pressure:
-	//   T = T0 * (1 + β * ΔP)
-	//
-	// Where:
-	//   T = Temperature of the water
-	//   T0 = Initial temperature of the water
-	//   β = Coefficient of thermal expansion of water
-	//   ΔP = Change in pressure
-
-	// TODO: this code is even simpler (and only directionally correct)
-	if p.heaterOn {
-		p.heaterTemperature = TARGET_TEMPERATURE
-		if p.pressure < p.targetPressure || p.temperature < TARGET_TEMPERATURE {
-			p.heaterPower = HEATER_HIGH_POWER
-
-			// adjust pressure and temperature independently -- not realistic
-			p.pressure += 1.0 // MPa, raise pressure by 0.5 MPa each update
-			if p.pressure > p.targetPressure {
-				p.pressure = p.targetPressure // cap pressure at TARGET_PRESSURE
-			}
-			p.temperature += 20.0
-			if p.temperature > TARGET_TEMPERATURE {
-				p.temperature = TARGET_TEMPERATURE
-			}
-		} else {
-			p.heaterPower = HEATER_LOW_POWER // maintain pressure
+	thermalSurge := 0.0 // kg over the tick
+	hotLeg := steam.SaturatedLiquid(p.pressure)
+	coldLeg := hotLeg
+	averageTemperature := ROOM_TEMPERATURE
+	sprayFraction := 0.0
+	// the surge line comes off the hot leg and the spray off the cold leg of the
+	// first loop, but every loop's coolant swells into the pressurizer
+	if primaryLoops := s.FindPrimaryLoops(); len(primaryLoops) > 0 {
+		coolant := 0.0
+		averageTemperature = 0
+		for _, primaryLoop := range primaryLoops {
+			coolant += primaryLoop.CoolantMass(p.pressure)
+			averageTemperature += primaryLoop.AverageTemperature() / float64(len(primaryLoops))
 		}
-	} else {
-		p.pressure -= 0.25 // MPa, assumption: pressure drops slowly when heater off
-		if p.pressure < 0.0 {
-			p.pressure = 0.0
+		if p.loopCoolantMass > 0 {
+			thermalSurge = p.loopCoolantMass - coolant
 		}
+		p.loopCoolantMass = coolant
+		hotLeg = steam.Liquid(p.pressure, primaryLoops[0].HotLegTemperature())
+		coldLeg = steam.Liquid(p.pressure, primaryLoops[0].ColdLegTemperature())
+		// spray is driven by the pump head, so natural circulation gives none
+		sprayFraction = math.Sqrt(primaryLoops[0].PumpHead())
 	}
 
-	if p.sprayNozzleOpen {
-		p.sprayFlowRate = SPRAY_FLOW_RATE
-		p.pressure -= 0.5 // MPa; lower pressure
-		p.temperature -= 20.0
-		if p.temperature < ROOM_TEMPERATURE {
-			p.temperature = ROOM_TEMPERATURE
+	surgeMass := 0.0
+	sprayMass := 0.0
+	reliefMass := 0.0
+	p.reliefValveOpened = false
+	for t := 0.0; t < SECONDS_PER_TICK; t += PRESSURIZER_TIME_STEP {
+		p.updatePressureControl(PRESSURIZER_TIME_STEP)
+		p.updateLevelControl(PRESSURIZER_TIME_STEP, averageTemperature)
+		p.updateHeaters()
+		p.updateReliefValves()
+		p.reliefValveOpened = p.reliefValveOpened || p.porvOpen || p.safetyValvesOpen
+		p.sprayFlowRate = SPRAY_FLOW_RATE * p.sprayValvePosition * sprayFraction
+
+		water := steam.SaturatedLiquid(p.pressure)
+		vapor := steam.SaturatedVapor(p.pressure)
+		energy := (p.heaterPower - PRESSURIZER_HEAT_LOSS*(p.temperature-ROOM_TEMPERATURE)) * PRESSURIZER_TIME_STEP
+
+		// coolant swelling in the loop, and charging more or less than letdown,
+		// push water through the surge line; insurge comes in from the hot leg,
+		// outsurge drains from the bottom
+		surge := (thermalSurge/SECONDS_PER_TICK + p.chargingFlowRate - p.letdownFlowRate) * PRESSURIZER_TIME_STEP
+		if surge > 0 {
+			energy += surge * (water.Enthalpy - INSURGE_MIXING*(water.Enthalpy-hotLeg.Enthalpy))
+		} else {
+			surge = math.Max(surge, -p.waterMass)
+			energy += surge * water.Enthalpy
 		}
-	} else {
-		p.sprayFlowRate = 0.0
+		surgeMass += surge
+
+		// spray water mixes in and just as much drains back out the surge line
+		energy += p.sprayFlowRate * PRESSURIZER_TIME_STEP * (coldLeg.Enthalpy - water.Enthalpy)
+		sprayMass += p.sprayFlowRate * PRESSURIZER_TIME_STEP
+
+		// relief valves take steam off the top, or water if there is no bubble
+		relief := math.Min(p.reliefFlowRate*PRESSURIZER_TIME_STEP, p.waterMass+p.steamMass+surge)
+		if p.solid {
+			energy -= relief * water.Enthalpy
+		} else {
+			energy -= relief * vapor.Enthalpy
+		}
+		reliefMass += relief
+
+		p.waterMass += surge - relief
+		p.internalEnergy += energy
+		p.settle()
+	}
+	p.surgeFlowRate = surgeMass / SECONDS_PER_TICK
+	p.sprayFlowRate = sprayMass / SECONDS_PER_TICK
+	p.reliefFlowRate = reliefMass / SECONDS_PER_TICK
+}
+
+// heaters are in the water and trip off if the level gets low enough to
+// uncover them
+func (p *Pressurizer) updateHeaters() {
+	if !p.heaterOn || p.level < HEATER_CUTOFF_LEVEL {
+		p.proportionalHeaterPower = 0
+		p.heaterPower = 0
+		return
+	}
+
+	p.proportionalHeaterPower = PROPORTIONAL_HEATER_POWER * p.proportionalHeaterDemand
+	p.heaterPower = p.proportionalHeaterPower
+	if p.backupHeatersOn {
+		p.heaterPower += BACKUP_HEATER_POWER
+	}
+}
+
+// each valve lifts at its setpoint and stays open until pressure falls back
+// below where it reseats; the PORV also opens on demand
+func (p *Pressurizer) updateReliefValves() {
+	if p.pressure > PORV_OPEN_PRESSURE {
+		p.porvHighPressure = true
+	} else if p.pressure < PORV_CLOSE_PRESSURE {
+		p.porvHighPressure = false
+	}
+	p.porvOpen = p.porvHighPressure || p.porvDemand
+	if p.pressure > SAFETY_VALVE_OPEN_PRESSURE {
+		p.safetyValvesOpen = true
+	} else if p.pressure < SAFETY_VALVE_RESEAT_PRESSURE {
+		p.safetyValvesOpen = false
 	}
 
-	if p.pressure > RELIEF_VALVE_THRESHOLD_PRESSURE {
-		p.reliefValveOpened = true
-		p.pressure -= RELIEF_VALVE_DROP_DELTA
-	} else {
-		p.reliefValveOpened = false
+	p.reliefFlowRate = 0
+	if p.porvOpen {
+		p.reliefFlowRate += PORV_FLOW
 	}
+	if p.safetyValvesOpen {
+		p.reliefFlowRate += SAFETY_VALVE_FLOW
+	}
+}
+
+// settle finds the saturation pressure at which the mass and energy inside
+// fill the vessel, and splits the mass into water and steam
+func (p *Pressurizer) settle() {
+	mass := math.Max(p.waterMass+p.steamMass, 1)
+	volume := PRESSURIZER_VOLUME / mass // m³/kg
+	energy := p.internalEnergy / mass   // kJ/kg
 
+	quality := func(pressure float64) (float64, steam.State, steam.State) {
+		water := steam.SaturatedLiquid(pressure)
+		vapor := steam.SaturatedVapor(pressure)
+		x := (volume - 1/water.Density) / (1/vapor.Density - 1/water.Density)
+		return math.Max(0, math.Min(1, x)), water, vapor
+	}
+
+	// internal energy at a fixed volume rises with pressure, so bisect; on a
+	// log scale, since pressure spans a vacuum to the relief valve setpoints
+	low, high := steam.MIN_SATURATION_PRESSURE, steam.MAX_SATURATION_PRESSURE
+	for i := 0; i < 40; i++ {
+		mid := math.Sqrt(low * high)
+		x, water, vapor := quality(mid)
+		if (1-x)*internalEnergy(water)+x*internalEnergy(vapor) < energy {
+			low = mid
+		} else {
+			high = mid
+		}
+	}
+
+	x, water, _ := quality(low)
+	p.pressure = low
+	p.temperature = steam.SaturationTemperature(low)
+	p.steamMass = x * mass
+	p.waterMass = mass - p.steamMass
+	p.level = math.Min(100, p.waterMass/water.Density/PRESSURIZER_VOLUME*100)
+
+	// with no bubble, more water has to be squeezed in
+	overfill := water.Density*volume - 1
+	p.solid = overfill < 0
+	if p.solid {
+		p.pressure -= overfill / WATER_COMPRESSIBILITY
+	}
 }
 
 func (p *Pressurizer) Status() map[string]interface{} {
 	return map[string]interface{}{
-		"name":              p.Name,
-		"pressure":          p.pressure,
-		"temperature":       p.temperature,
-		"heaterOn":          p.heaterOn,
-		"heaterTemperature": p.heaterTemperature,
-		"targetPressure":    p.targetPressure,
-		"heaterPower":       p.heaterPower,
-		"sprayNozzleOpen":   p.sprayNozzleOpen,
-		"sprayFlowRate":     p.sprayFlowRate,
-		"reliefValveOpened": p.reliefValveOpened,
+		"name":                    p.Name,
+		"pressure":                p.pressure,
+		"temperature":             p.temperature,
+		"level":                   p.level,
+		"waterMass":               p.waterMass,
+		"steamMass":               p.steamMass,
+		"solid":                   p.solid,
+		"surgeFlowRate":           p.surgeFlowRate,
+		"heaterOn":                p.heaterOn,
+		"pressureControlMode":     p.pressureControlMode,
+		"masterControllerOutput":  p.masterOutput,
+		"levelControlMode":        p.levelControlMode,
+		"levelSetpoint":           p.levelSetpoint,
+		"chargingFlowRate":        p.chargingFlowRate,
+		"letdownFlowRate":         p.letdownFlowRate,
+		"letdownIsolated":         p.letdownIsolated,
+		"targetPressure":          p.targetPressure,
+		"proportionalHeaterPower": p.proportionalHeaterPower,
+		"backupHeatersOn":         p.backupHeatersOn,
+		"heaterPower":             p.heaterPower,
+		"sprayNozzleOpen":         p.sprayNozzleOpen,
+		"sprayValvePosition":      p.sprayValvePosition,
+		"sprayFlowRate":           p.sprayFlowRate,
+		"porvOpen":                p.porvOpen,
+		"safetyValvesOpen":        p.safetyValvesOpen,
+		"reliefValveOpened":       p.reliefValveOpened,
+		"reliefFlowRate":          p.reliefFlowRate,
 	}
 }
 
@@ -128,13 +323,28 @@ func (p *Pressurizer) PrintStatus() {
 	fmt.Printf("Pressurizer: %s\n", p.Name)
 	fmt.Printf("\tPressure: %f\n", p.pressure)
 	fmt.Printf("\tTemperature: %f\n", p.temperature)
+	fmt.Printf("\tLevel: %.1f%%\n", p.level)
+	fmt.Printf("\tWater Mass: %.0f kg\n", p.waterMass)
+	fmt.Printf("\tSteam Mass: %.0f kg\n", p.steamMass)
+	fmt.Printf("\tSolid: %t\n", p.solid)
+	fmt.Printf("\tSurge Flow Rate: %.2f kg/s\n", p.surgeFlowRate)
 	fmt.Printf("\tHeater On: %t\n", p.heaterOn)
-	fmt.Printf("\tHeater Temperature: %f\n", p.heaterTemperature)
+	fmt.Printf("\tPressure Control: %s\n", p.pressureControlMode)
+	fmt.Printf("\tMaster Controller Output: %f\n", p.masterOutput)
+	fmt.Printf("\tLevel Control: %s\n", p.levelControlMode)
+	fmt.Printf("\tLevel Setpoint: %.1f%%\n", p.levelSetpoint)
+	fmt.Printf("\tCharging Flow Rate: %.2f kg/s\n", p.chargingFlowRate)
+	fmt.Printf("\tLetdown Flow Rate: %.2f kg/s\n", p.letdownFlowRate)
 	fmt.Printf("\tTarget Pressure: %f\n", p.targetPressure)
+	fmt.Printf("\tProportional Heater Power: %f\n", p.proportionalHeaterPower)
+	fmt.Printf("\tBackup Heaters On: %t\n", p.backupHeatersOn)
 	fmt.Printf("\tHeater Power: %f\n", p.heaterPower)
 	fmt.Printf("\tSpray Nozzle Open: %t\n", p.sprayNozzleOpen)
+	fmt.Printf("\tSpray Valve Position: %.2f\n", p.sprayValvePosition)
 	fmt.Printf("\tSpray Flow Rate: %f\n", p.sprayFlowRate)
-	fmt.Printf("\tRelief Valve Opened: %t\n", p.reliefValveOpened)
+	fmt.Printf("\tPORV Open: %t\n", p.porvOpen)
+	fmt.Printf("\tSafety Valves Open: %t\n", p.safetyValvesOpen)
+	fmt.Printf("\tRelief Flow Rate: %.2f kg/s\n", p.reliefFlowRate)
 }
 
 func (p *Pressurizer) Pressure() float64 {
@@ -149,6 +359,16 @@ func (p *Pressurizer) Temperature() float64 {
 	return p.temperature
 }
 
+// percent of the vessel filled with water
+func (p *Pressurizer) Level() float64 {
+	return p.level
+}
+
+// kg/s, into the pressurizer when positive
+func (p *Pressurizer) SurgeFlowRate() float64 {
+	return p.surgeFlowRate
+}
+
 func (p *Pressurizer) SwitchOnHeater() {
 	p.heaterOn = true
 }
@@ -164,3 +384,20 @@ func (p *Pressurizer) OpenSprayNozzle() {
 func (p *Pressurizer) CloseSprayNozzle() {
 	p.sprayNozzleOpen = false
 }
+
+func (p *Pressurizer) AlarmConditions() []*AlarmCondition {
+	return []*AlarmCondition{
+		NewAlarmCondition("pressurizerReliefValveOpen", "PRZR RELIEF VALVE OPEN", ALARM_PRIORITY_HIGH, func() bool {
+			return p.reliefValveOpened
+		}),
+		NewAlarmCondition("pressurizerHighPressure", "PRZR PRESSURE HIGH", ALARM_PRIORITY_MEDIUM, func() bool {
+			return p.pressure > PRESSURIZER_HIGH_PRESSURE_ALARM
+		}),
+		NewAlarmCondition("pressurizerLowLevel", "PRZR LEVEL LOW HEATERS OFF", ALARM_PRIORITY_MEDIUM, func() bool {
+			return p.level < PRESSURIZER_LOW_LEVEL_ALARM
+		}),
+		NewAlarmCondition("pressurizerHighLevel", "PRZR LEVEL HIGH", ALARM_PRIORITY_MEDIUM, func() bool {
+			return p.level > PRESSURIZER_HIGH_LEVEL_ALARM
+		}),
+	}
+}
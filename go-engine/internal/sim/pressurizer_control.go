@@ -0,0 +1,184 @@
+package sim
+
+import (
+	"fmt"
+	"math"
+)
+
+// Pressure control works from one master controller. It takes the pressure
+// error against the setpoint and turns it, PID fashion, into a compensated
+// error that the final elements act on in sequence as it rises:
+//
+//	backup heaters    on below -0.17 MPa, off again above -0.10 MPa
+//	proportional      full at -0.10 MPa, off at +0.10 MPa
+//	spray valves      start to open at +0.17 MPa, wide open at +0.52 MPa
+//	PORV              opens at +0.69 MPa, shuts again below +0.55 MPa
+//
+// Level control keeps the pressurizer level on its program, which rises with
+// average coolant temperature so the pressurizer takes up the swell of the
+// coolant as load comes on. A PI controller sets charging flow against a
+// steady letdown; letdown is isolated if level falls to the heater cutoff.
+//
+// Each can be switched to manual, where the operator works the heaters, spray,
+// PORV and charging directly.
+
+const (
+	PRESSURIZER_CONTROL_MANUAL    = "manual"
+	PRESSURIZER_CONTROL_AUTOMATIC = "automatic"
+)
+
+// master pressure controller
+const MASTER_PRESSURE_GAIN = 1.0
+const MASTER_PRESSURE_RESET_TIME = 120.0   // seconds
+const MASTER_PRESSURE_RATE_TIME = 10.0     // seconds
+const MASTER_PRESSURE_INTEGRAL_LIMIT = 0.2 // MPa; enough to take up heat losses without winding up through a heatup
+
+// compensated error where each stage acts, in MPa
+const BACKUP_HEATERS_ON_ERROR = -0.17
+const BACKUP_HEATERS_OFF_ERROR = -0.10
+const SPRAY_START_ERROR = 0.17
+const SPRAY_FULL_ERROR = 0.52
+const PORV_OPEN_ERROR = 0.69
+const PORV_CLOSE_ERROR = 0.55
+
+// level program and control
+const PRESSURIZER_FULL_LOAD_LEVEL = 61.5 // percent
+const LEVEL_CONTROL_GAIN = 0.5           // kg/s of charging per percent of level error
+const LEVEL_CONTROL_RESET_TIME = 300.0   // seconds
+const LETDOWN_FLOW_RATE = 5.0            // kg/s
+const MAX_CHARGING_FLOW_RATE = 10.0      // kg/s
+
+// level setpoint in percent for the given average coolant temperature
+func programLevel(averageTemperature float64) float64 {
+	share := (averageTemperature - NO_LOAD_AVERAGE_TEMPERATURE) / (FULL_LOAD_AVERAGE_TEMPERATURE - NO_LOAD_AVERAGE_TEMPERATURE)
+	share = math.Max(0, math.Min(1, share))
+	return PRESSURIZER_NO_LOAD_LEVEL + (PRESSURIZER_FULL_LOAD_LEVEL-PRESSURIZER_NO_LOAD_LEVEL)*share
+}
+
+// updatePressureControl sets the heater demand, spray valve and PORV demand,
+// over a step of the given seconds
+func (p *Pressurizer) updatePressureControl(seconds float64) {
+	if p.pressureControlMode != PRESSURIZER_CONTROL_AUTOMATIC {
+		p.proportionalHeaterDemand = 1
+		if p.sprayNozzleOpen {
+			p.sprayValvePosition = 1
+		} else {
+			p.sprayValvePosition = 0
+		}
+		return
+	}
+
+	pressureError := p.pressure - p.targetPressure
+	p.masterIntegral += pressureError * seconds / MASTER_PRESSURE_RESET_TIME
+	p.masterIntegral = math.Max(-MASTER_PRESSURE_INTEGRAL_LIMIT, math.Min(MASTER_PRESSURE_INTEGRAL_LIMIT, p.masterIntegral))
+	// rate acts on the measurement, so a setpoint change does not kick the output
+	rate := (p.pressure - p.masterLastPressure) / seconds * MASTER_PRESSURE_RATE_TIME
+	p.masterLastPressure = p.pressure
+	p.masterOutput = MASTER_PRESSURE_GAIN * (pressureError + p.masterIntegral + rate)
+
+	u := p.masterOutput
+	p.proportionalHeaterDemand = math.Max(0, math.Min(1, (PROPORTIONAL_HEATER_BAND/2-u)/PROPORTIONAL_HEATER_BAND))
+	if u < BACKUP_HEATERS_ON_ERROR {
+		p.backupHeatersOn = true
+	} else if u > BACKUP_HEATERS_OFF_ERROR {
+		p.backupHeatersOn = false
+	}
+	p.sprayValvePosition = math.Max(0, math.Min(1, (u-SPRAY_START_ERROR)/(SPRAY_FULL_ERROR-SPRAY_START_ERROR)))
+	p.sprayNozzleOpen = p.sprayValvePosition > 0
+	if u > PORV_OPEN_ERROR {
+		p.porvDemand = true
+	} else if u < PORV_CLOSE_ERROR {
+		p.porvDemand = false
+	}
+}
+
+// updateLevelControl sets charging and letdown, over a step of the given seconds
+func (p *Pressurizer) updateLevelControl(seconds, averageTemperature float64) {
+	p.levelSetpoint = programLevel(averageTemperature)
+
+	// letdown would drain the pressurizer dry and uncover the heaters
+	if p.level < HEATER_CUTOFF_LEVEL {
+		p.letdownIsolated = true
+	} else if p.level > HEATER_CUTOFF_LEVEL+1 {
+		p.letdownIsolated = false
+	}
+	p.letdownFlowRate = LETDOWN_FLOW_RATE
+	if p.letdownIsolated {
+		p.letdownFlowRate = 0
+	}
+
+	if p.levelControlMode != PRESSURIZER_CONTROL_AUTOMATIC {
+		return
+	}
+
+	levelError := p.levelSetpoint - p.level
+	p.levelIntegral += LEVEL_CONTROL_GAIN * levelError * seconds / LEVEL_CONTROL_RESET_TIME
+	p.levelIntegral = math.Max(-MAX_CHARGING_FLOW_RATE, math.Min(MAX_CHARGING_FLOW_RATE, p.levelIntegral))
+	charging := LETDOWN_FLOW_RATE + LEVEL_CONTROL_GAIN*levelError + p.levelIntegral
+	p.chargingFlowRate = math.Max(0, math.Min(MAX_CHARGING_FLOW_RATE, charging))
+}
+
+func (p *Pressurizer) SwitchToAutomaticPressureControl() {
+	p.pressureControlMode = PRESSURIZER_CONTROL_AUTOMATIC
+	// bumpless: start from no error history
+	p.masterIntegral = 0
+	p.masterLastPressure = p.pressure
+}
+
+func (p *Pressurizer) SwitchToManualPressureControl() {
+	p.pressureControlMode = PRESSURIZER_CONTROL_MANUAL
+	p.porvDemand = false
+}
+
+func (p *Pressurizer) PressureControlMode() string {
+	return p.pressureControlMode
+}
+
+func (p *Pressurizer) SwitchToAutomaticLevelControl() {
+	p.levelControlMode = PRESSURIZER_CONTROL_AUTOMATIC
+	// pick up from the charging flow the operator left
+	p.levelIntegral = p.chargingFlowRate - LETDOWN_FLOW_RATE
+}
+
+func (p *Pressurizer) SwitchToManualLevelControl() {
+	p.levelControlMode = PRESSURIZER_CONTROL_MANUAL
+}
+
+func (p *Pressurizer) LevelControlMode() string {
+	return p.levelControlMode
+}
+
+func (p *Pressurizer) SwitchOnBackupHeaters() {
+	p.backupHeatersOn = true
+}
+
+func (p *Pressurizer) SwitchOffBackupHeaters() {
+	p.backupHeatersOn = false
+}
+
+func (p *Pressurizer) OpenPORV() {
+	p.porvDemand = true
+}
+
+func (p *Pressurizer) ClosePORV() {
+	p.porvDemand = false
+}
+
+// SetChargingFlowRate sets charging in kg/s, for manual level control
+func (p *Pressurizer) SetChargingFlowRate(rate float64) {
+	if rate < 0 || rate > MAX_CHARGING_FLOW_RATE {
+		fmt.Printf("Charging flow must be between 0 and %.1f kg/s. You requested %f.\n", MAX_CHARGING_FLOW_RATE, rate)
+		return
+	}
+	p.chargingFlowRate = rate
+}
+
+// percent, on the level program
+func (p *Pressurizer) LevelSetpoint() float64 {
+	return p.levelSetpoint
+}
+
+// MPa, the master controller's compensated pressure error
+func (p *Pressurizer) MasterControllerOutput() float64 {
+	return p.masterOutput
+}
@@ -3,6 +3,8 @@ package sim
 import (
 	"math"
 	"testing"
+
+	"won/sim-lab/go-engine/internal/steam"
 )
 
 func TestPressurizer(t *testing.T) {
@@ -21,14 +23,18 @@ func TestPressurizer(t *testing.T) {
 		t.Errorf("Expected pressurizer name to be 'TestPressurizerInitialConditions', got '%s'", pressurizer.GetName())
 	}
 
-	if pressurizer.pressure != 0.0 {
-		t.Errorf("Expected initial pressure to be 0.0, got %f", pressurizer.pressure)
+	if !almostEqual(pressurizer.pressure, steam.SaturationPressure(ROOM_TEMPERATURE), 1e-6) {
+		t.Errorf("Expected initial pressure to be the saturation pressure at room temperature, got %f", pressurizer.pressure)
 	}
 
-	if pressurizer.temperature != ROOM_TEMPERATURE {
+	if !almostEqual(pressurizer.temperature, ROOM_TEMPERATURE, 1e-6) {
 		t.Errorf("Expected initial temperature to be %f, got %f", ROOM_TEMPERATURE, pressurizer.temperature)
 	}
 
+	if !almostEqual(pressurizer.level, PRESSURIZER_NO_LOAD_LEVEL, 1e-6) {
+		t.Errorf("Expected initial level to be %f, got %f", PRESSURIZER_NO_LOAD_LEVEL, pressurizer.level)
+	}
+
 	// Test update function
 	pressurizer.Update(env, sim)
 
@@ -52,8 +58,8 @@ func TestPressurizerReachesTargetPT(t *testing.T) {
 	// Turn on the heater
 	pressurizer.heaterOn = true
 
-	// Update 100 times
-	for i := 0; i < 20; i++ {
+	// heating up from cold takes hours
+	for i := 0; i < 6*HOUR_OF_MINUTES; i++ {
 		pressurizer.Update(env, sim)
 	}
 
@@ -62,10 +68,13 @@ func TestPressurizerReachesTargetPT(t *testing.T) {
 		t.Errorf("Expected pressure to be %f, got %f", pressurizer.targetPressure, pressurizer.pressure)
 	}
 
-	// Check that temperature is at target temperature
-	if !almostEqual(pressurizer.temperature, TARGET_TEMPERATURE, 0.1) {
+	// Check that temperature is at target temperature, and the water is boiling
+	if !almostEqual(pressurizer.temperature, TARGET_TEMPERATURE, 0.5) {
 		t.Errorf("Expected temperature to be %f, got %f", TARGET_TEMPERATURE, pressurizer.temperature)
 	}
+	if !almostEqual(pressurizer.temperature, steam.SaturationTemperature(pressurizer.pressure), 1e-6) {
+		t.Errorf("Expected saturated water at %f MPa, got %f °C", pressurizer.pressure, pressurizer.temperature)
+	}
 }
 
 func TestPressurizerReliefValve(t *testing.T) {
@@ -78,6 +87,7 @@ func TestPressurizerReliefValve(t *testing.T) {
 	sim.AddComponent(pressurizer)
 
 	// Turn on the heater
+	pressurizer.fill(TARGET_TEMPERATURE, PRESSURIZER_NO_LOAD_LEVEL)
 	pressurizer.heaterOn = true
 	pressurizer.SetTargetPressure(20.0)
 
@@ -101,18 +111,23 @@ func TestPressurizerSprayNozzel(t *testing.T) {
 	env := NewEnvironment()
 	pressurizer := NewPressurizer("TestPressurizerReachesTargetPT")
 
+	// spray is pushed by the reactor coolant pumps
+	primaryLoop := setUpHotPrimaryLoop(sim, env)
+
 	// Add the pressurizer to the simulation
 	sim.AddComponent(pressurizer)
 
 	// Turn on the heater
+	pressurizer.fill(TARGET_TEMPERATURE, PRESSURIZER_NO_LOAD_LEVEL)
 	pressurizer.heaterOn = true
 
-	// Run long enough to reach target pressure; heater should go to low power at that point
+	// Run long enough to settle at target pressure; heater should go to low power at that point
 	for i := 0; i < 20; i++ {
 		pressurizer.Update(env, sim)
 	}
 	topPressure := pressurizer.pressure
 
+	pressurizer.SwitchToManualPressureControl()
 	pressurizer.OpenSprayNozzle()
 	pressurizer.Update(env, sim)
 
@@ -124,4 +139,161 @@ func TestPressurizerSprayNozzel(t *testing.T) {
 		pressurizer.PrintStatus()
 		t.Errorf("Expected pressure to dropped with spray nozzel open")
 	}
+
+	// no pumps, no spray, once they have coasted down
+	primaryLoop.SwitchOffPump()
+	for i := 0; i < 3; i++ {
+		primaryLoop.Update(env, sim)
+	}
+	pressurizer.Update(env, sim)
+	if pressurizer.sprayFlowRate != 0.0 {
+		t.Errorf("Expected no spray without the reactor coolant pumps, got %f", pressurizer.sprayFlowRate)
+	}
+}
+
+// a primary loop with its pump running at no-load temperature
+func setUpHotPrimaryLoop(sim *Simulation, env *Environment) *PrimaryLoop {
+	primaryLoop := NewPrimaryLoop("Test Primary Loop")
+	primaryLoop.SwitchOnPump()
+	primaryLoop.averageTemperature = NO_LOAD_AVERAGE_TEMPERATURE
+	sim.AddComponent(primaryLoop)
+	primaryLoop.Update(env, sim)
+	return primaryLoop
+}
+
+func TestInsurgeRaisesLevelAndPressure(t *testing.T) {
+	sim, env := setupSimulationEnvironment()
+	primaryLoop := setUpHotPrimaryLoop(sim, env)
+	pressurizer := NewPressurizer("Test Pressurizer")
+	sim.AddComponent(pressurizer)
+	pressurizer.fill(TARGET_TEMPERATURE, PRESSURIZER_NO_LOAD_LEVEL)
+	pressurizer.Update(env, sim)
+	startLevel, startPressure := pressurizer.level, pressurizer.pressure
+
+	// the coolant warms as load comes on and swells into the pressurizer
+	primaryLoop.averageTemperature += 2
+	pressurizer.Update(env, sim)
+	if pressurizer.SurgeFlowRate() <= 0 {
+		t.Errorf("Expected an insurge as the coolant expands, got %f kg/s", pressurizer.SurgeFlowRate())
+	}
+	if pressurizer.level <= startLevel || pressurizer.pressure <= startPressure {
+		t.Errorf("Expected level and pressure to rise from %f%% and %f MPa, got %f%% and %f MPa", startLevel, startPressure, pressurizer.level, pressurizer.pressure)
+	}
+
+	// and drains back out as it cools
+	primaryLoop.averageTemperature -= 4
+	pressurizer.Update(env, sim)
+	if pressurizer.SurgeFlowRate() >= 0 || pressurizer.level >= startLevel {
+		t.Errorf("Expected an outsurge to drop the level below %f%%, got %f kg/s and %f%%", startLevel, pressurizer.SurgeFlowRate(), pressurizer.level)
+	}
+}
+
+func TestHeatersCutOffAtLowLevel(t *testing.T) {
+	sim, env := setupSimulationEnvironment()
+	pressurizer := NewPressurizer("Test Pressurizer")
+	sim.AddComponent(pressurizer)
+	pressurizer.fill(TARGET_TEMPERATURE, HEATER_CUTOFF_LEVEL-5)
+	pressurizer.SwitchOnHeater()
+
+	pressurizer.Update(env, sim)
+	if pressurizer.heaterPower != 0 {
+		t.Errorf("Expected the heaters to stay off below %f%% level, got %f kW", HEATER_CUTOFF_LEVEL, pressurizer.heaterPower)
+	}
+}
+
+func TestSolidPressurizerLiftsTheReliefValves(t *testing.T) {
+	sim, env := setupSimulationEnvironment()
+	primaryLoop := setUpHotPrimaryLoop(sim, env)
+	pressurizer := NewPressurizer("Test Pressurizer")
+	sim.AddComponent(pressurizer)
+	pressurizer.SwitchToManualPressureControl()
+	pressurizer.SwitchToManualLevelControl()
+	pressurizer.fill(TARGET_TEMPERATURE, 95)
+	pressurizer.Update(env, sim)
+
+	// more swell than there is room for the bubble
+	primaryLoop.averageTemperature += 10
+	pressurizer.Update(env, sim)
+	if !pressurizer.solid || !pressurizer.reliefValveOpened {
+		t.Errorf("Expected the pressurizer to go solid and lift its relief valves, got %f%% level at %f MPa", pressurizer.level, pressurizer.pressure)
+	}
+}
+
+func setUpHotPressurizer() (*Simulation, *Environment, *PrimaryLoop, *Pressurizer) {
+	sim, env := setupSimulationEnvironment()
+	primaryLoop := setUpHotPrimaryLoop(sim, env)
+	pressurizer := NewPressurizer("Test Pressurizer")
+	sim.AddComponent(pressurizer)
+	pressurizer.fill(TARGET_TEMPERATURE, PRESSURIZER_NO_LOAD_LEVEL)
+	pressurizer.SwitchOnHeater()
+	pressurizer.Update(env, sim)
+	return sim, env, primaryLoop, pressurizer
+}
+
+func TestAutomaticPressureControlFollowsSetpoint(t *testing.T) {
+	sim, env, _, pressurizer := setUpHotPressurizer()
+
+	// below setpoint the backup heaters come on first
+	pressurizer.SetTargetPressure(TARGET_PRESSURE + 0.3)
+	pressurizer.Update(env, sim)
+	if !pressurizer.backupHeatersOn || pressurizer.sprayFlowRate != 0 {
+		t.Errorf("Expected backup heaters and no spray below setpoint, got heaters %t, spray %f", pressurizer.backupHeatersOn, pressurizer.sprayFlowRate)
+	}
+
+	// above it, heaters off and spray on, until pressure comes down
+	pressurizer.SetTargetPressure(TARGET_PRESSURE - 0.3)
+	pressurizer.Update(env, sim)
+	if pressurizer.heaterPower != 0 || pressurizer.sprayFlowRate == 0 {
+		t.Errorf("Expected spray and no heaters above setpoint, got %f kW and %f kg/s", pressurizer.heaterPower, pressurizer.sprayFlowRate)
+	}
+	for i := 0; i < 30; i++ {
+		pressurizer.Update(env, sim)
+	}
+	if !almostEqual(pressurizer.pressure, TARGET_PRESSURE-0.3, 0.1) {
+		t.Errorf("Expected pressure to settle at %f MPa, got %f", TARGET_PRESSURE-0.3, pressurizer.pressure)
+	}
+}
+
+func TestMasterControllerOpensThePORV(t *testing.T) {
+	sim, env, _, pressurizer := setUpHotPressurizer()
+
+	// well above setpoint, the PORV opens long before its own pressure switch would
+	pressurizer.SetTargetPressure(TARGET_PRESSURE - 1)
+	pressurizer.Update(env, sim)
+	if !pressurizer.reliefValveOpened || pressurizer.pressure > PORV_OPEN_PRESSURE {
+		t.Errorf("Expected the controller to open the PORV at %f MPa", pressurizer.pressure)
+	}
+
+	pressurizer.SwitchToManualPressureControl()
+	pressurizer.Update(env, sim)
+	if pressurizer.porvOpen {
+		t.Errorf("Expected the PORV to shut once the controller lets go of it")
+	}
+}
+
+func TestLevelControlFollowsProgram(t *testing.T) {
+	sim, env, primaryLoop, pressurizer := setUpHotPressurizer()
+
+	// the coolant heats up as load comes on; level follows its program
+	for i := 0; i < 60; i++ {
+		if primaryLoop.averageTemperature < FULL_LOAD_AVERAGE_TEMPERATURE {
+			primaryLoop.averageTemperature += 0.5
+		}
+		pressurizer.Update(env, sim)
+	}
+	if !almostEqual(pressurizer.LevelSetpoint(), PRESSURIZER_FULL_LOAD_LEVEL, 0.01) {
+		t.Errorf("Expected the full load level setpoint %f%%, got %f", PRESSURIZER_FULL_LOAD_LEVEL, pressurizer.LevelSetpoint())
+	}
+	if !almostEqual(pressurizer.level, PRESSURIZER_FULL_LOAD_LEVEL, 2) {
+		t.Errorf("Expected level near %f%%, got %f", PRESSURIZER_FULL_LOAD_LEVEL, pressurizer.level)
+	}
+
+	// in manual, charging stays where the operator puts it
+	pressurizer.SwitchToManualLevelControl()
+	pressurizer.SetChargingFlowRate(MAX_CHARGING_FLOW_RATE + 1)
+	pressurizer.SetChargingFlowRate(0)
+	pressurizer.Update(env, sim)
+	if pressurizer.chargingFlowRate != 0 || pressurizer.SurgeFlowRate() >= 0 {
+		t.Errorf("Expected no charging and an outsurge from letdown, got %f kg/s and %f kg/s", pressurizer.chargingFlowRate, pressurizer.SurgeFlowRate())
+	}
 }
@@ -3,68 +3,227 @@ package sim
 import (
 	"fmt"
 	"math"
+
+	"won/sim-lab/go-engine/internal/steam"
 )
 
 type PrimaryLoop struct {
 	BaseComponent
-	pumpOn                   bool
+	pumps                    []*ReactorCoolantPump
 	pumpPressure             float64 // in MPa
+	pumpHead                 float64 // fraction of rated head the pumps develop
 	flowRate                 float64 // in m³/s
 	boronConcentration       float64 // in parts per million (ppm)
 	boronConcentrationTarget float64 // in parts per million (ppm)
+	averageTemperature       float64 // in °C
+	hotLegTemperature        float64 // in °C, leaving the core for the steam generator
+	coldLegTemperature       float64 // in °C, back from the steam generator to the core
+	heatInput                float64 // in MW, from the core and the pump this tick
 	pressurizer              *Pressurizer
+	loopNumber               int // 1 and up in a multi-loop plant; 0 for a plant built around a single loop
+	plantLoops               int // loops sharing the core, this one included
 }
 
-// TODO: consider where to track hot / cold leg temperatures;
-// hot leg is influenced by reactor core heat output;
-// cold leg influenced by condenser output
+// The coolant is treated as two halves: the hot side, from the core outlet to
+// the steam generator, and the cold side, from the steam generator back to the
+// core. The core heats the hot side, the steam generator cools the cold side,
+// and the flow carries heat between them:
+//
+//   C/2 dThot/dt  = Qcore - ṁ cp (Thot - Tcold)
+//   C/2 dTcold/dt = ṁ cp (Thot - Tcold) - Qsg
+//
+// Their sum is the energy balance on Tavg. Their difference settles within
+// seconds at full flow, to Qcore / ṁ cp when the heat in matches the heat out.
+//
+// The steam generator takes heat in proportion to how much hotter the coolant
+// is than the water boiling on the secondary side, Qsg = UA (Tavg - Tsat), and
+// the insulation leaks it in proportion to how much hotter the coolant is than
+// the room. With the tubes taking a full core's heat on a few degrees, Tavg
+// settles within seconds, and so does the steam line pressure that sets
+// Tsat. A loop with a steam generator on the steam header is therefore
+// stepped a second at a time along with the header, by the secondary loop;
+// a loop with nowhere to send its heat steps itself over the tick.
+//
+// A plant can have several loops around one core, each with its own pump and
+// steam generator. Each loop holds its share of the coolant and the pump
+// flow, and takes the core heat in proportion to the flow it sends through
+// the vessel. The loops meet in the vessel, where the reactor core mixes
+// them back together. A loop's flow comes from however many pumps it has and
+// the speed each one runs at.
 
 // useful constants
 // TODO: make some of these configurable
-const PUMP_ON_PRESSURE = 1.0   // MPa
-const PUMP_ON_FLOW_RATE = 20.0 // in m³/s
-const PUMP_OFF_PRESSURE = 0
-const PUMP_OFF_FLOW_RATE = 0
+const PRIMARY_RATED_FLOW_RATE = 20.0   // in m³/s, through the core with every pump at rated speed
 const MAX_BORON_RATE_OF_CHANGE = 5.0   // ppm/minute
 const MAX_BORON_CONCENTRATION = 2500.0 // ppm
+const PRIMARY_HEAT_CAPACITY = 1400.0   // MJ/°C; coolant plus the metal it touches
+const PRIMARY_HEAT_LOSS = 0.02         // MW/°C above room temperature, lost through insulation
+const PRIMARY_COOLANT_DENSITY = 740.0  // kg/m³, at operating temperature and pressure
+const PRIMARY_SPECIFIC_HEAT = 0.0055   // MJ/kg/°C, at operating temperature and pressure
+const PRIMARY_LOOP_VOLUME = 300.0      // m³ of coolant outside the pressurizer
 
 func NewPrimaryLoop(name string) *PrimaryLoop {
 	return &PrimaryLoop{
 		BaseComponent:            BaseComponent{Name: name},
 		flowRate:                 0,
-		pumpOn:                   false,
+		pumps:                    []*ReactorCoolantPump{NewReactorCoolantPump(1)},
 		pumpPressure:             0,
 		boronConcentration:       0,
 		boronConcentrationTarget: 0,
+		averageTemperature:       ROOM_TEMPERATURE,
+		hotLegTemperature:        ROOM_TEMPERATURE,
+		coldLegTemperature:       ROOM_TEMPERATURE,
+		plantLoops:               1,
 	}
 }
 
+// AddCoolantLoops builds the given number of coolant loops into the plant,
+// each with its pump and its own steam generator and feedwater train
+func AddCoolantLoops(s *Simulation, count int) []*PrimaryLoop {
+	primaryLoops := make([]*PrimaryLoop, 0, count)
+	for i := 1; i <= count; i++ {
+		primaryLoop := NewPrimaryLoop(fmt.Sprintf("Primary Loop %d", i))
+		primaryLoop.loopNumber = i
+		primaryLoop.plantLoops = count
+		s.AddComponent(primaryLoop)
+
+		steamGenerator := NewSteamGenerator(fmt.Sprintf("Steam Generator %d", i))
+		steamGenerator.ConnectToPrimaryLoop(primaryLoop)
+		s.AddComponent(steamGenerator)
+
+		primaryLoops = append(primaryLoops, primaryLoop)
+	}
+	return primaryLoops
+}
+
 func (pl *PrimaryLoop) Update(env *Environment, s *Simulation) {
+	pl.plantLoops = max(1, len(s.FindPrimaryLoops()))
 
-	if pl.pumpOn {
-		// TODO: does it make sense to allow variable pump speed?
-		// then, perhaps half pressure leads to half the flow volume
-
-		// keep it simple for now. on full or off.
-		pl.pumpPressure = PUMP_ON_PRESSURE
-		pl.flowRate = PUMP_ON_FLOW_RATE
-
-		// adjust boron concentration as needed
-		if pl.boronConcentrationTarget != pl.boronConcentration {
-			pl.boronConcentration = pl.boronConcentration + math.Copysign(
-				math.Min(
-					MAX_BORON_RATE_OF_CHANGE,
-					math.Abs(pl.boronConcentrationTarget-pl.boronConcentration),
-				),
-				pl.boronConcentrationTarget-pl.boronConcentration,
-			)
+	// the pumps run off the station's own power; without it they coast down
+	for _, pump := range pl.pumps {
+		if !env.PowerOn {
+			pump.Stop()
 		}
+		pump.Advance(SECONDS_PER_TICK)
+	}
+	buoyancy := NATURAL_CIRCULATION_HEAD * math.Max(0, pl.TemperatureRise())
+	flow, head := circulate(pl.pumps, buoyancy)
+	pl.flowRate = pl.ratedFlowRate() * flow
+	pl.pumpHead = head
+	pl.pumpPressure = RCP_RATED_HEAD * pl.pumpHead
+
+	// boration and dilution go in through the charging pumps, which need the
+	// coolant pumps running to mix it in
+	if pl.PumpRunning() && pl.boronConcentrationTarget != pl.boronConcentration {
+		pl.boronConcentration = pl.boronConcentration + math.Copysign(
+			math.Min(
+				MAX_BORON_RATE_OF_CHANGE,
+				math.Abs(pl.boronConcentrationTarget-pl.boronConcentration),
+			),
+			pl.boronConcentrationTarget-pl.boronConcentration,
+		)
+	}
+
+	// what the pump puts into the water ends up as heat
+	pl.heatInput = pl.PumpPower()
+	if reactorCore := s.FindReactorCore(); reactorCore != nil {
+		pl.heatInput += reactorCore.HeatEnergyRate() * pl.CoreShare(s)
+	}
+	if !pl.steamsIntoHeader(s) {
+		pl.stepTemperature(0, 0, SECONDS_PER_TICK)
+	}
+}
+
+// true if the loop gives its heat to a steam generator on the steam header
+func (pl *PrimaryLoop) steamsIntoHeader(s *Simulation) bool {
+	return pl.SteamGenerator(s) != nil && s.FindSecondaryLoop() != nil
+}
+
+// stepTemperature moves the coolant on by the given seconds: the core and the
+// pump heat it, the insulation leaks heat to the room, and the steam generator
+// tubes take heat at the given conductance (MW/°C) while the coolant is
+// hotter than the water boiling around them. It gives back the MW the tubes
+// took.
+func (pl *PrimaryLoop) stepTemperature(tubeConductance, boilingTemperature, seconds float64) float64 {
+	share := 1 / float64(pl.plantLoops)
+	heatCapacity := PRIMARY_HEAT_CAPACITY * share
+	lossConductance := PRIMARY_HEAT_LOSS * share // MW/°C
+	if pl.averageTemperature <= boilingTemperature {
+		tubeConductance = 0
+	}
+	conductance := lossConductance + tubeConductance
+
+	// Tavg heads for where the heat out matches the heat in
+	settled := (pl.heatInput + lossConductance*ROOM_TEMPERATURE + tubeConductance*boilingTemperature) / conductance
+	rate := conductance * seconds / heatCapacity
+	mean := settled + (pl.averageTemperature-settled)*(1-math.Exp(-rate))/rate
+	pl.averageTemperature = settled + (pl.averageTemperature-settled)*math.Exp(-rate)
+	pl.averageTemperature = math.Max(ROOM_TEMPERATURE, pl.averageTemperature) // nothing here can chill the coolant
+
+	tubeHeat := math.Max(0, tubeConductance*(mean-boilingTemperature))
+	heatOut := tubeHeat + lossConductance*(mean-ROOM_TEMPERATURE)
+
+	// the hot-to-cold difference relaxes toward where the flow can carry the heat,
+	// or just keeps growing if nothing flows
+	rise := pl.hotLegTemperature - pl.coldLegTemperature
+	transport := pl.flowRate * PRIMARY_COOLANT_DENSITY * PRIMARY_SPECIFIC_HEAT // MW/°C
+	drive := 2 * (pl.heatInput + heatOut) / heatCapacity                       // °C/s
+	if transport > 0 {
+		rate := 4 * transport / heatCapacity
+		settled := drive / rate
+		rise = settled + (rise-settled)*math.Exp(-rate*seconds)
 	} else {
-		// no pressure, no flow, no change to boron concentration
-		pl.pumpPressure = PUMP_OFF_PRESSURE
-		pl.flowRate = PUMP_OFF_FLOW_RATE
+		rise += drive * seconds
 	}
 
+	pl.hotLegTemperature = pl.averageTemperature + rise/2
+	pl.coldLegTemperature = pl.averageTemperature - rise/2
+	if pl.coldLegTemperature < ROOM_TEMPERATURE {
+		pl.coldLegTemperature = ROOM_TEMPERATURE
+		pl.hotLegTemperature = 2*pl.averageTemperature - ROOM_TEMPERATURE
+	}
+	return tubeHeat
+}
+
+// in m³/s, with every pump in the loop at rated speed
+func (pl *PrimaryLoop) ratedFlowRate() float64 {
+	return PRIMARY_RATED_FLOW_RATE / float64(pl.plantLoops)
+}
+
+// CoreShare is the part of the core heat this loop carries away, by the flow
+// it sends through the vessel
+func (pl *PrimaryLoop) CoreShare(s *Simulation) float64 {
+	if reactorCore := s.FindReactorCore(); reactorCore != nil {
+		if share, ok := reactorCore.loopShare(pl); ok {
+			return share
+		}
+	}
+	return 1 / float64(max(1, len(s.FindPrimaryLoops())))
+}
+
+// the steam generator on this loop, if any
+func (pl *PrimaryLoop) SteamGenerator(s *Simulation) *SteamGenerator {
+	for _, steamGenerator := range s.FindSteamGenerators() {
+		if steamGenerator.loop(s) == pl {
+			return steamGenerator
+		}
+	}
+	return nil
+}
+
+// 1 and up in a multi-loop plant, 0 for a single loop
+func (pl *PrimaryLoop) LoopNumber() int {
+	return pl.loopNumber
+}
+
+// head across the pumps, as a fraction of rated
+func (pl *PrimaryLoop) PumpHead() float64 {
+	return pl.pumpHead
+}
+
+// flow as a fraction of what the pumps give at rated speed
+func (pl *PrimaryLoop) RelativeFlow() float64 {
+	return pl.flowRate / pl.ratedFlowRate()
 }
 
 // Returns the current pump pressure in Pa
@@ -80,14 +239,35 @@ func (pl *PrimaryLoop) FlowVolumeUnit() string {
 	return "m³/min"
 }
 
-// Calculates flow volume per minute when pumps are on
+// Calculates flow volume per minute, pumped or by natural circulation
 func (pl *PrimaryLoop) FlowVolume() float64 {
-	if pl.pumpOn {
-		return pl.flowRate * 60.0
-	} else {
-		// assumes water stops after 1 minute of pumps switching off
-		return 0.0
-	}
+	return pl.flowRate * 60.0
+}
+
+// kg of coolant the loop holds at its average temperature; as the coolant
+// warms and expands, the rest surges into the pressurizer
+func (pl *PrimaryLoop) CoolantMass(pressure float64) float64 {
+	return PRIMARY_LOOP_VOLUME / float64(pl.plantLoops) * steam.Liquid(pressure, pl.averageTemperature).Density
+}
+
+// in °C
+func (pl *PrimaryLoop) AverageTemperature() float64 {
+	return pl.averageTemperature
+}
+
+// in °C
+func (pl *PrimaryLoop) HotLegTemperature() float64 {
+	return pl.hotLegTemperature
+}
+
+// in °C
+func (pl *PrimaryLoop) ColdLegTemperature() float64 {
+	return pl.coldLegTemperature
+}
+
+// in °C, across the core
+func (pl *PrimaryLoop) TemperatureRise() float64 {
+	return pl.hotLegTemperature - pl.coldLegTemperature
 }
 
 func (pl *PrimaryLoop) BoronConcentration() float64 {
@@ -103,9 +283,16 @@ func (pl *PrimaryLoop) BoronConcentrationTarget() float64 {
 }
 
 func (pl *PrimaryLoop) Status() map[string]interface{} {
+	pumps := make([]map[string]interface{}, 0, len(pl.pumps))
+	for _, pump := range pl.pumps {
+		pumps = append(pumps, pump.Status())
+	}
 	return map[string]interface{}{
 		"name":                     pl.Name,
-		"pumpOn":                   pl.pumpOn,
+		"loopNumber":               pl.loopNumber,
+		"pumpOn":                   pl.PumpRunning(),
+		"pumps":                    pumps,
+		"pumpPower":                pl.PumpPower(),
 		"pumpPressure":             pl.Pressure(),
 		"pressureUnit":             pl.PressureUnit(),
 		"flowVolume":               pl.FlowVolume(),
@@ -113,28 +300,85 @@ func (pl *PrimaryLoop) Status() map[string]interface{} {
 		"boronConcentration":       pl.BoronConcentration(),
 		"boronConcentrationTarget": pl.BoronConcentrationTarget(),
 		"boronConcentrationUnit":   pl.BoronConcentrationUnit(),
+		"averageTemperature":       pl.AverageTemperature(),
+		"hotLegTemperature":        pl.HotLegTemperature(),
+		"coldLegTemperature":       pl.ColdLegTemperature(),
+		"temperatureRise":          pl.TemperatureRise(),
 	}
 }
 
 func (pl *PrimaryLoop) PrintStatus() {
 	fmt.Printf("Primary Loop: %s\n", pl.Name)
-	pumpStatus := "Off"
-	if pl.pumpOn {
-		pumpStatus = "On"
+	for _, pump := range pl.pumps {
+		fmt.Printf("\tPump %d Status: %s\n", pump.number, boolToString(pump.IsRunning()))
+		fmt.Printf("\tPump %d Speed: %.0f rpm\n", pump.number, pump.Speed())
 	}
-	fmt.Printf("\tPump Status: %s\n", pumpStatus)
+	fmt.Printf("\tPump Power: %.2f MW\n", pl.PumpPower())
 	fmt.Printf("\tPump Pressure: %.2f %s\n", pl.pumpPressure, pl.PressureUnit())
 	fmt.Printf("\tFlow Volume: %.2f %s\n", pl.FlowVolume(), pl.FlowVolumeUnit())
 	fmt.Printf("\tBoron Concentration: %.2f %s\n", pl.BoronConcentration(), pl.BoronConcentrationUnit())
 	fmt.Printf("\tBoron Concentration Target: %.2f %s\n", pl.BoronConcentrationTarget(), pl.BoronConcentrationUnit())
+	fmt.Printf("\tAverage Temperature: %.2f °C\n", pl.AverageTemperature())
+	fmt.Printf("\tHot Leg Temperature: %.2f °C\n", pl.HotLegTemperature())
+	fmt.Printf("\tCold Leg Temperature: %.2f °C\n", pl.ColdLegTemperature())
 }
 
+// SwitchOnPump starts every pump in the loop
 func (pl *PrimaryLoop) SwitchOnPump() {
-	pl.pumpOn = true
+	for _, pump := range pl.pumps {
+		pump.Start()
+	}
 }
 
+// SwitchOffPump trips every pump breaker in the loop; the pumps coast down from there
 func (pl *PrimaryLoop) SwitchOffPump() {
-	pl.pumpOn = false
+	for _, pump := range pl.pumps {
+		pump.Stop()
+	}
+}
+
+// InstallPumps gives the loop the given number of pumps side by side, all
+// stopped, in place of the ones it had; the rated loop flow is the same
+func (pl *PrimaryLoop) InstallPumps(count int) {
+	if count < 1 {
+		fmt.Printf("A loop needs at least one pump. You requested %d.\n", count)
+		return
+	}
+	pl.pumps = make([]*ReactorCoolantPump, 0, count)
+	for i := 1; i <= count; i++ {
+		pl.pumps = append(pl.pumps, NewReactorCoolantPump(i))
+	}
+}
+
+func (pl *PrimaryLoop) Pumps() []*ReactorCoolantPump {
+	return pl.pumps
+}
+
+// the pump with the given number, 1 and up, or nil
+func (pl *PrimaryLoop) Pump(number int) *ReactorCoolantPump {
+	if number < 1 || number > len(pl.pumps) {
+		return nil
+	}
+	return pl.pumps[number-1]
+}
+
+// true while any pump in the loop has power
+func (pl *PrimaryLoop) PumpRunning() bool {
+	for _, pump := range pl.pumps {
+		if pump.IsRunning() {
+			return true
+		}
+	}
+	return false
+}
+
+// MW drawn by the pump motors, all of which ends up in the coolant
+func (pl *PrimaryLoop) PumpPower() float64 {
+	power := 0.0
+	for _, pump := range pl.pumps {
+		power += pump.power
+	}
+	return RCP_RATED_POWER / float64(pl.plantLoops) * power / float64(max(1, len(pl.pumps)))
 }
 
 // set target amount in ppm
@@ -41,14 +41,23 @@ func TestPrimaryLoopPump(t *testing.T) {
 		t.Errorf("Flow volume should be greater than 0 when pump is on, got %f", pl.FlowVolume())
 	}
 
+	// the flywheel carries the pump for a while
+	runningFlow := pl.FlowVolume()
 	pl.SwitchOffPump()
 	pl.Update(testEnv, testy)
+	if pl.FlowVolume() <= 0 || pl.FlowVolume() >= runningFlow/2 {
+		t.Errorf("Flow volume should be coasting down after a minute, got %f of %f", pl.FlowVolume(), runningFlow)
+	}
 
+	// once stopped, a loop with no core heating it barely circulates
+	for i := 0; i < 3; i++ {
+		pl.Update(testEnv, testy)
+	}
 	if pl.Pressure() != 0 {
 		t.Errorf("Pressure should return to 0, got %f", pl.Pressure())
 	}
-	if pl.FlowVolume() != 0 {
-		t.Errorf("Flow volume should return to 0, got %f", pl.FlowVolume())
+	if pl.FlowVolume() > runningFlow/100 {
+		t.Errorf("Flow volume should be all but gone, got %f of %f", pl.FlowVolume(), runningFlow)
 	}
 
 }
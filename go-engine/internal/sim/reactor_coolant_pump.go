@@ -0,0 +1,173 @@
+package sim
+
+import (
+	"fmt"
+	"math"
+)
+
+// A reactor coolant pump is a big motor-driven centrifugal pump with a heavy
+// flywheel on the shaft. When the breaker opens, the flywheel keeps it turning
+// against the water it is pushing, so flow falls off over tens of seconds
+// instead of stopping dead; seal and bearing drag finally bring it to rest.
+//
+// The pump follows the affinity laws: at a given speed its head falls off
+// with flow along its curve, and it settles where that head meets the loop
+// resistance, which goes as flow squared. So flow goes with speed and the
+// power it draws goes with speed cubed, all of which ends up as heat in the
+// coolant.
+//
+// Each pump has a variable-frequency drive, so it can be run below rated
+// speed to trim flow, say for a low-power heatup. A loop can have several
+// pumps side by side. They all push against the same head, and a pump
+// turning too slowly to make that head takes no flow; its discharge check
+// valve keeps the others from driving water back through it.
+//
+// With the pumps stopped, the loop still circulates on its own. Hot water
+// rises out of the core and cooled water sinks out of the steam generator,
+// which sits well above it; the difference in density drives a few percent
+// of rated flow, more the hotter the core runs compared to the steam generator.
+
+const RCP_RATED_SPEED = 1189.0          // rpm
+const RCP_STARTUP_TIME = 20.0           // seconds from standstill to rated speed
+const RCP_COASTDOWN_TIME = 12.0         // seconds; flow halves in about this long on the flywheel
+const RCP_FRICTION = 0.002              // fraction of rated speed lost each second to seals and bearings
+const RCP_SHUTOFF_HEAD = 1.3            // head against a closed loop, as a fraction of rated head
+const RCP_RATED_POWER = 24.0            // MW at rated speed, for every pump in the plant together
+const RCP_RATED_HEAD = 1.0              // MPa the pumps develop at rated speed and flow
+const RCP_MIN_SPEED = 30.0              // percent; the drive cannot hold the pump any slower
+const NATURAL_CIRCULATION_HEAD = 3.3e-4 // fraction of rated head per °C between hot and cold legs
+const RCP_TIME_STEP = 1.0               // seconds
+
+type ReactorCoolantPump struct {
+	number        int     // 1 and up within its loop
+	running       bool    // motor breaker closed
+	speed         float64 // fraction of rated
+	speedSetpoint float64 // fraction of rated, what the drive runs the pump at
+	flow          float64 // fraction of its rated flow
+	head          float64 // fraction of rated head the pump develops
+	power         float64 // fraction of rated power drawn
+}
+
+func NewReactorCoolantPump(number int) *ReactorCoolantPump {
+	return &ReactorCoolantPump{
+		number:        number,
+		speedSetpoint: 1,
+	}
+}
+
+// Advance runs the pump for the given seconds; the drive moves it toward its
+// speed setpoint, or the flywheel carries it down once the breaker is open
+func (rcp *ReactorCoolantPump) Advance(seconds float64) {
+	for t := 0.0; t < seconds; t += RCP_TIME_STEP {
+		if rcp.running {
+			step := RCP_TIME_STEP / RCP_STARTUP_TIME
+			rcp.speed += math.Max(-step, math.Min(step, rcp.speedSetpoint-rcp.speed))
+		} else {
+			// the water takes torque as speed squared; drag takes a little more
+			rcp.speed = rcp.speed/(1+rcp.speed*RCP_TIME_STEP/RCP_COASTDOWN_TIME) - RCP_FRICTION*RCP_TIME_STEP
+			rcp.speed = math.Max(0, rcp.speed)
+		}
+	}
+
+	rcp.power = 0
+	if rcp.running {
+		rcp.power = math.Pow(rcp.speed, 3)
+	}
+}
+
+// circulate finds where the pumps of a loop, working in parallel, settle
+// against the loop resistance with the given buoyancy head helping them, all
+// as fractions of rated. Each pump follows its curve,
+// RCP_SHUTOFF_HEAD s² - (RCP_SHUTOFF_HEAD - 1) q², the loop resistance goes
+// as the loop flow squared, and the head they share is found by bisection.
+// With every pump at rest, the water still has to get through them, and
+// their resistance is the same curve at zero speed. Returns the loop flow and
+// the head across the pumps.
+func circulate(pumps []*ReactorCoolantPump, buoyancy float64) (float64, float64) {
+	if len(pumps) == 0 {
+		return math.Sqrt(buoyancy), 0
+	}
+	loopFlow := func(head float64) float64 {
+		total := 0.0
+		for _, rcp := range pumps {
+			rcp.head = math.Max(0, head)
+			rcp.flow = math.Sqrt(math.Max(0, (RCP_SHUTOFF_HEAD*rcp.speed*rcp.speed-head)/(RCP_SHUTOFF_HEAD-1)))
+			total += rcp.flow
+		}
+		return total / float64(len(pumps))
+	}
+
+	low, high := -buoyancy, 0.0
+	for _, rcp := range pumps {
+		high = math.Max(high, RCP_SHUTOFF_HEAD*rcp.speed*rcp.speed)
+	}
+	for i := 0; i < 50; i++ {
+		head := (low + high) / 2
+		flow := loopFlow(head)
+		if head+buoyancy > flow*flow {
+			high = head
+		} else {
+			low = head
+		}
+	}
+	head := (low + high) / 2
+	return loopFlow(head), math.Max(0, head)
+}
+
+func (rcp *ReactorCoolantPump) Start() {
+	rcp.running = true
+}
+
+// Stop opens the breaker and leaves the pump to coast down
+func (rcp *ReactorCoolantPump) Stop() {
+	rcp.running = false
+}
+
+func (rcp *ReactorCoolantPump) IsRunning() bool {
+	return rcp.running
+}
+
+// SetSpeedSetpoint has the drive run the pump at the given percent of rated speed
+func (rcp *ReactorCoolantPump) SetSpeedSetpoint(percent float64) {
+	if percent < RCP_MIN_SPEED || percent > 100 {
+		fmt.Printf("Pump speed must be between %.0f and 100 percent. You requested %f.\n", RCP_MIN_SPEED, percent)
+		return
+	}
+	rcp.speedSetpoint = percent / 100
+}
+
+// percent of rated speed
+func (rcp *ReactorCoolantPump) SpeedSetpoint() float64 {
+	return rcp.speedSetpoint * 100
+}
+
+// 1 and up within its loop
+func (rcp *ReactorCoolantPump) Number() int {
+	return rcp.number
+}
+
+// rpm
+func (rcp *ReactorCoolantPump) Speed() float64 {
+	return rcp.speed * RCP_RATED_SPEED
+}
+
+// fraction of the pump's rated flow, pumped or natural
+func (rcp *ReactorCoolantPump) Flow() float64 {
+	return rcp.flow
+}
+
+// true once the pump has come to rest and only natural circulation is left
+func (rcp *ReactorCoolantPump) Stopped() bool {
+	return rcp.speed == 0
+}
+
+func (rcp *ReactorCoolantPump) Status() map[string]interface{} {
+	return map[string]interface{}{
+		"number":        rcp.number,
+		"running":       rcp.running,
+		"speed":         rcp.Speed(),
+		"speedSetpoint": rcp.SpeedSetpoint(),
+		"flow":          rcp.flow * 100,
+		"head":          rcp.head * 100,
+	}
+}
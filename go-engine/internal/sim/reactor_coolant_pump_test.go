@@ -0,0 +1,181 @@
+package sim
+
+import (
+	"testing"
+)
+
+func TestReactorCoolantPumpComesUpToSpeed(t *testing.T) {
+	pump := NewReactorCoolantPump(1)
+	pump.Start()
+
+	pump.Advance(RCP_STARTUP_TIME / 2)
+	if !almostEqual(pump.Speed(), RCP_RATED_SPEED/2, 1) {
+		t.Errorf("Expected the pump halfway to speed, got %f rpm", pump.Speed())
+	}
+
+	pump.Advance(SECONDS_PER_TICK)
+	circulate([]*ReactorCoolantPump{pump}, 0)
+	if pump.Speed() != RCP_RATED_SPEED || !almostEqual(pump.Flow(), 1, 1e-9) {
+		t.Errorf("Expected rated speed and flow, got %f rpm and %f", pump.Speed(), pump.Flow())
+	}
+	if !almostEqual(pump.power, 1, 1e-9) || !almostEqual(pump.head, 1, 1e-9) {
+		t.Errorf("Expected rated head and power, got %f and %f", pump.head, pump.power)
+	}
+}
+
+func TestReactorCoolantPumpCoastsDown(t *testing.T) {
+	pump := NewReactorCoolantPump(1)
+	pump.Start()
+	pump.Advance(SECONDS_PER_TICK)
+
+	pump.Stop()
+	pump.Advance(RCP_COASTDOWN_TIME)
+	circulate([]*ReactorCoolantPump{pump}, 0)
+	if !almostEqual(pump.Flow(), 0.5, 0.05) {
+		t.Errorf("Expected flow to halve on the flywheel, got %f", pump.Flow())
+	}
+	if pump.power != 0 {
+		t.Errorf("Expected a coasting pump to draw no power, got %f", pump.power)
+	}
+
+	pump.Advance(2 * SECONDS_PER_TICK)
+	circulate([]*ReactorCoolantPump{pump}, 0)
+	if !pump.Stopped() || pump.Flow() != 0 {
+		t.Errorf("Expected the pump at rest within a couple of minutes, got %f rpm and flow %f", pump.Speed(), pump.Flow())
+	}
+}
+
+func TestNaturalCirculation(t *testing.T) {
+	pump := NewReactorCoolantPump(1)
+
+	circulate([]*ReactorCoolantPump{pump}, NATURAL_CIRCULATION_HEAD*30)
+	if pump.Flow() < 0.03 || pump.Flow() > 0.1 {
+		t.Errorf("Expected a few percent of rated flow on natural circulation, got %f", pump.Flow())
+	}
+	if pump.head != 0 {
+		t.Errorf("Expected no pump head with the pump at rest, got %f", pump.head)
+	}
+
+	natural := pump.Flow()
+	circulate([]*ReactorCoolantPump{pump}, NATURAL_CIRCULATION_HEAD*60)
+	if pump.Flow() <= natural {
+		t.Errorf("Expected more flow from a hotter core, got %f", pump.Flow())
+	}
+}
+
+func TestStationBlackoutTripsTheCoolantPumps(t *testing.T) {
+	simulation, env, _ := setUpFourLoopPlant()
+	simulation.FindReactorCore().kinetics = NewPointKinetics(1.0)
+	reactorProtection := NewReactorProtection("Reactor Protection")
+	simulation.AddComponent(reactorProtection)
+
+	env.PowerOn = false
+	updatePlantLoops(simulation, env)
+	for _, primaryLoop := range simulation.FindPrimaryLoops() {
+		if primaryLoop.Pump(1).IsRunning() {
+			t.Errorf("Expected loop %d's pump to lose power", primaryLoop.LoopNumber())
+		}
+		if primaryLoop.RelativeFlow() <= 0 {
+			t.Errorf("Expected loop %d to still be coasting down", primaryLoop.LoopNumber())
+		}
+	}
+	reactorProtection.Update(env, simulation)
+	if reactorProtection.FirstOut() != LOW_PRIMARY_FLOW_TRIP {
+		t.Errorf("Expected the coastdown to trip the reactor on low flow, first out %s", reactorProtection.FirstOut())
+	}
+
+	// with the core on decay heat, the loops carry it to the steam generators on their own
+	simulation.FindReactorCore().heatEnergyRate = 0.015 * RATED_THERMAL_POWER
+	for i := 0; i < 20; i++ {
+		updatePlantLoops(simulation, env)
+	}
+	for _, primaryLoop := range simulation.FindPrimaryLoops() {
+		if !primaryLoop.Pump(1).Stopped() {
+			t.Errorf("Expected loop %d's pump at rest", primaryLoop.LoopNumber())
+		}
+		if primaryLoop.RelativeFlow() < 0.01 {
+			t.Errorf("Expected loop %d to circulate naturally, got %f", primaryLoop.LoopNumber(), primaryLoop.RelativeFlow())
+		}
+	}
+}
+
+func TestFlowFollowsPumpSpeed(t *testing.T) {
+	simulation, env := setupSimulationEnvironment()
+	primaryLoop := NewPrimaryLoop("Test Primary Loop")
+	simulation.AddComponent(primaryLoop)
+	primaryLoop.SwitchOnPump()
+	primaryLoop.Update(env, simulation)
+	ratedFlow := primaryLoop.FlowVolume()
+	ratedPower := primaryLoop.PumpPower()
+
+	pump := primaryLoop.Pump(1)
+	pump.SetSpeedSetpoint(50)
+	primaryLoop.Update(env, simulation)
+	if !almostEqual(pump.Speed(), RCP_RATED_SPEED/2, 1e-6) {
+		t.Errorf("Expected the drive to bring the pump to half speed, got %f rpm", pump.Speed())
+	}
+	if !almostEqual(primaryLoop.FlowVolume(), ratedFlow/2, ratedFlow/100) {
+		t.Errorf("Expected half the flow at half speed, got %f of %f", primaryLoop.FlowVolume(), ratedFlow)
+	}
+	if !almostEqual(primaryLoop.PumpPower(), ratedPower/8, 1e-6) {
+		t.Errorf("Expected an eighth of the power at half speed, got %f of %f", primaryLoop.PumpPower(), ratedPower)
+	}
+
+	// too slow for the drive; the setpoint stays put
+	pump.SetSpeedSetpoint(RCP_MIN_SPEED - 1)
+	if pump.SpeedSetpoint() != 50 {
+		t.Errorf("Expected the speed setpoint to stay at 50 percent, got %f", pump.SpeedSetpoint())
+	}
+}
+
+func TestPumpsInParallel(t *testing.T) {
+	simulation, env := setupSimulationEnvironment()
+	primaryLoop := NewPrimaryLoop("Test Primary Loop")
+	primaryLoop.InstallPumps(2)
+	simulation.AddComponent(primaryLoop)
+	primaryLoop.SwitchOnPump()
+	primaryLoop.Update(env, simulation)
+
+	if !almostEqual(primaryLoop.RelativeFlow(), 1, 1e-6) {
+		t.Errorf("Expected rated flow with both pumps at rated speed, got %f", primaryLoop.RelativeFlow())
+	}
+
+	primaryLoop.Pump(2).Stop()
+	for i := 0; i < 3; i++ {
+		primaryLoop.Update(env, simulation)
+	}
+	if primaryLoop.Pump(2).Flow() != 0 {
+		t.Errorf("Expected the check valve to keep flow out of the idle pump, got %f", primaryLoop.Pump(2).Flow())
+	}
+	if primaryLoop.RelativeFlow() < 0.6 || primaryLoop.RelativeFlow() > 0.9 {
+		t.Errorf("Expected one pump to carry most of the flow, got %f", primaryLoop.RelativeFlow())
+	}
+	if primaryLoop.Pump(1).Flow() <= 1 {
+		t.Errorf("Expected the running pump to run out along its curve, got %f", primaryLoop.Pump(1).Flow())
+	}
+	if !primaryLoop.PumpRunning() {
+		t.Errorf("Expected the loop to still show a pump running")
+	}
+}
+
+func TestLoopHeadWithOnlyTheSecondPump(t *testing.T) {
+	simulation, env := setupSimulationEnvironment()
+	primaryLoop := NewPrimaryLoop("Test Primary Loop")
+	primaryLoop.InstallPumps(2)
+	simulation.AddComponent(primaryLoop)
+	primaryLoop.Pump(2).Start()
+	for i := 0; i < 3; i++ {
+		primaryLoop.Update(env, simulation)
+	}
+
+	// the head is what the running pump develops along its curve, not what
+	// the idle first pump does
+	pump := primaryLoop.Pump(2)
+	expected := RCP_SHUTOFF_HEAD*pump.speed*pump.speed - (RCP_SHUTOFF_HEAD-1)*pump.Flow()*pump.Flow()
+	if primaryLoop.PumpHead() <= 0 || !almostEqual(primaryLoop.PumpHead(), expected, 1e-6) {
+		t.Errorf("Expected %f of rated head from pump 2, got %f", expected, primaryLoop.PumpHead())
+	}
+	if !almostEqual(primaryLoop.Pressure(), RCP_RATED_HEAD*expected, 1e-6) {
+		t.Errorf("Expected the loop to show the pressure pump 2 develops, got %f", primaryLoop.Pressure())
+	}
+}
@@ -7,150 +7,330 @@ import (
 
 type ReactorCore struct {
 	BaseComponent
-	fuelAge               int     // in minutes
-	reactivity            float64 // negative means subcritical, 0 means critical, positive means supercritical
-	neutronFlux           float64 // in neutrons per second
-	temperature           float64 // in degrees Celsius
-	heatEnergyRate        float64 // in MW
-	controlRods           *ControlRods
-	primaryLoop           *PrimaryLoop
-	withdrawShutdownBanks bool
-	scram                 bool
+	burnup            float64 // in MWd/tU
+	reactivity        float64 // in pcm; negative means subcritical, 0 means critical, positive means supercritical
+	neutronFlux       float64 // in neutrons per cm² per second
+	temperature       float64 // in degrees Celsius; average fuel temperature
+	dopplerFeedback   float64 // in pcm
+	moderatorFeedback float64 // in pcm
+	heatEnergyRate    float64 // in MW; fission plus decay heat
+	kinetics          *PointKinetics
+	decayHeat         *DecayHeat
+	poisons           *FissionPoisons
+	controlRods       *ControlRods
+	primaryLoop       *PrimaryLoop   // the first loop connected
+	primaryLoops      []*PrimaryLoop // every loop through the vessel
+	loopShares        map[*PrimaryLoop]float64
+	scram             bool
 }
 
 func NewReactorCore(name string) *ReactorCore {
+	controlRods := NewControlRods()
 	return &ReactorCore{
 		BaseComponent:  BaseComponent{Name: name},
-		fuelAge:        0, // start w/ brand new fuel; this is something to play with, roll a die to pick a starting age, or let the user specify
-		reactivity:     -1.0,
-		neutronFlux:    0.1,
+		burnup:         0, // start w/ brand new fuel; see LoadFuel to start elsewhere in the cycle
+		reactivity:     controlRods.Reactivity(),
+		neutronFlux:    SOURCE_RANGE_POWER * FULL_POWER_NEUTRON_FLUX,
 		temperature:    20.0, // Start at room temperature (Celsius)
 		heatEnergyRate: 0.0,
-		controlRods:    NewControlRods(),
+		kinetics:       NewPointKinetics(SOURCE_RANGE_POWER),
+		decayHeat:      NewDecayHeat(),
+		poisons:        NewFissionPoisons(),
+		controlRods:    controlRods,
 	}
 }
 
+const RATED_THERMAL_POWER = 3000.0     // MW
+const FULL_POWER_NEUTRON_FLUX = 3.0e13 // neutrons per cm² per second, core average at rated power
+const SOURCE_RANGE_POWER = 1.0e-8      // relative power of a shut down core sitting on its neutron source
+const BORON_WORTH = -7.0               // pcm per ppm
+
+// alarm setpoints
+const HIGH_FUEL_TEMPERATURE_ALARM = 1000.0 // °C, core average fuel temperature
+const HIGH_STARTUP_RATE_ALARM = 1.0        // decades per minute
+
+// temperature feedback; reactivity values are worked out for a core at room temperature,
+// so heating the core up takes reactivity away
+const DOPPLER_COEFFICIENT = -2.5                // pcm per °C of fuel temperature
+const MODERATOR_COEFFICIENT_UNBORATED = -40.0   // pcm per °C of coolant temperature, with no boron, at operating temperature
+const MODERATOR_COEFFICIENT_BORON_SLOPE = 0.018 // pcm per °C per ppm; boron makes the coefficient less negative
+const MODERATOR_COEFFICIENT_TEMPERATURE = 300.0 // °C; the coefficients above hold here and shrink toward zero in cold water
+const FEEDBACK_REFERENCE_TEMPERATURE = ROOM_TEMPERATURE
+const FUEL_TEMPERATURE_RISE = 600.0 // °C above coolant average at rated power
+const FUEL_TIME_CONSTANT = 6.0      // seconds for fuel to settle after a power change
+const FEEDBACK_TIME_STEP = 1.0      // seconds; Doppler acts fast, so re-evaluate it often within a tick
+
+const VESSEL_MIXING_TIME = 15.0 // seconds for the loops to mix in the vessel at full flow
+
+// ConnectToPrimaryLoop adds a coolant loop through the core; a multi-loop
+// plant connects each of them
 func (rc *ReactorCore) ConnectToPrimaryLoop(loop *PrimaryLoop) {
-	rc.primaryLoop = loop
+	if rc.primaryLoop == nil {
+		rc.primaryLoop = loop
+	}
+	rc.primaryLoops = append(rc.primaryLoops, loop)
 }
 
-const (
-	BEGINNING_OF_CYCLE = WEEK_OF_MINUTES * 6
-	MIDDLE_OF_CYCLE    = WEEK_OF_MINUTES * 66
-	END_OF_CYCLE       = WEEK_OF_MINUTES * 6
-)
+// LoadFuel starts the core at the beginning, middle or end of its fuel cycle.
+// Fuel that has already been run comes with its samarium. The loops
+// already through the core are borated to hold the new fuel shut down. An
+// unknown loading leaves the core as it was.
+func (rc *ReactorCore) LoadFuel(partOfCycle string) error {
+	burnup, ok := coreLoadings[partOfCycle]
+	if !ok {
+		return fmt.Errorf("unknown core loading %s; pick beginning, middle or end", partOfCycle)
+	}
+	rc.burnup = burnup
+	rc.poisons = NewFissionPoisons()
+	if burnup > 0 {
+		rc.poisons.LoadSamarium()
+	}
+	rc.BorateToShutdown()
+	return nil
+}
 
-func lookupPartOfCycle(fuelAge int) string {
-	switch {
-	case fuelAge < BEGINNING_OF_CYCLE:
-		return "beginning"
-	case fuelAge < MIDDLE_OF_CYCLE:
-		return "middle"
-	case fuelAge < END_OF_CYCLE:
-		return "end"
-	default:
-		return "refueling"
-	}
-}
-
-// use age of fuel to determine appropriate levels of boron concentration and control rod extraction
-func lookupLevels(fuelAge int) (boronConcentration, controlRodWithdrawal float64) {
-	switch partOfCycle := lookupPartOfCycle(fuelAge); partOfCycle {
-	case "beginning":
-		return 1900, 0.98
-	case "middle":
-		return 900, 0.8
-	case "end":
-		return 30, 0.5
-	case "refueling":
-		return 2200, 50
-	}
-	return 0, 0
-}
-
-/*
-Young fuel (early in the cycle) requires more boron to moderate well.
-
-	  Max: 2500
-	  Beginning: 1200 - 2000 ppm
-		Middle: 800 - 1000 ppm
-		End: 10 - 50 ppm
-		Refueling: 2000 - 2200 ppm
-
-Notes: should be reduced gradually as fuel depletes
-*/
-func (rc *ReactorCore) Update(env *Environment, s *Simulation) {
-	rc.fuelAge++ // keep fuel age in sync with sim time; TODO: improve by basing on operational minutes, not just elapsed time
+// BorateToShutdown fills the coolant loops through the core at the shutdown
+// boron concentration for the fuel loaded, with the target to match
+func (rc *ReactorCore) BorateToShutdown() {
+	boron := rc.ShutdownBoronConcentration()
+	for _, primaryLoop := range rc.primaryLoops {
+		primaryLoop.boronConcentration = boron
+		primaryLoop.boronConcentrationTarget = boron
+	}
+}
 
+func (rc *ReactorCore) Update(env *Environment, s *Simulation) {
 	if rc.scram {
 		rc.controlRods.Scram()
 	}
 
-	// determine reactivity
+	if rc.primaryLoop == nil {
+		for _, primaryLoop := range s.FindPrimaryLoops() {
+			rc.ConnectToPrimaryLoop(primaryLoop)
+		}
+	}
+	rc.mixLoops()
+
+	turbineLoad := 0.0
+	if turbine := s.FindSteamTurbine(); turbine != nil {
+		turbineLoad = turbine.Load()
+	}
+	if !rc.scram {
+		rc.controlRods.UpdateRodControl(rc.moderatorTemperature(), turbineLoad)
+	}
+
+	// let the neutron population respond over the minute; fuel temperature follows
+	// power within seconds, so feedback has to be worked in along the way, and
+	// rods step in and out as it goes
+	for elapsed := 0.0; elapsed < SECONDS_PER_TICK; elapsed += FEEDBACK_TIME_STEP {
+		rc.controlRods.Advance(FEEDBACK_TIME_STEP)
+		rc.reactivity = rc.totalReactivity()
+		rc.kinetics.Advance(rc.reactivity, FEEDBACK_TIME_STEP)
 
-	// factors that affect reactivity
-	// 1) PrimaryLoop.boronConcentration
-	// 2) ControlRods.position: Shutdown rods out, control rods partially out
-	// 3) Place in fuel cycle
+		// part of the heat of fission only comes out later, as fission products decay
+		fissionPower := rc.FissionPower()
+		rc.decayHeat.Advance(fissionPower, FEEDBACK_TIME_STEP)
+		rc.heatEnergyRate = fissionPower*(1-decayHeatFraction()) + rc.decayHeat.Power()
 
-	targetBoronConcentration, targetControlRodExtraction := lookupLevels(rc.fuelAge)
+		rc.updateFuelTemperature(FEEDBACK_TIME_STEP)
+	}
+	rc.neutronFlux = rc.kinetics.RelativePower() * FULL_POWER_NEUTRON_FLUX
 
-	// formula: ρ = (k - 1) / k, where ρ is reactivity and k is the effective multiplication factor
+	// poisons move over hours, so once a tick is plenty
+	rc.poisons.Advance(rc.neutronFlux, SECONDS_PER_TICK)
 
-	// key assumption for the model:
-	//   non-zero boron concentration is at a level where core goes critical with control rods half-way out
-	//   this is a major simplification, but ought to be enough detail to tease out the interaction
+	// fuel only burns while making heat
+	rc.burnup += burnupIncrement(rc.heatEnergyRate, SECONDS_PER_TICK)
+}
 
-	// sum up reactivity from all factors; TODO: find a more predictive formula
-	reactivity := -1.0
+// fuel sits above the coolant by an amount proportional to the heat it makes,
+// and gets there with a lag of a few seconds
+func (rc *ReactorCore) updateFuelTemperature(seconds float64) {
+	equilibrium := rc.moderatorTemperature() + FUEL_TEMPERATURE_RISE*rc.heatEnergyRate/RATED_THERMAL_POWER
+	rc.temperature = equilibrium + (rc.temperature-equilibrium)*math.Exp(-seconds/FUEL_TIME_CONSTANT)
+}
 
-	if rc.primaryLoop == nil {
-		rc.primaryLoop = s.FindPrimaryLoop()
+// mixLoops blends the coolant of the loops that are flowing, as they come
+// together in the lower plenum; what one loop gains the others give up. It
+// also sets how the core heat splits among the loops, by the flow each sends
+// through, so every loop works from the same split over the next tick.
+func (rc *ReactorCore) mixLoops() {
+	totalFlow := 0.0
+	for _, primaryLoop := range rc.primaryLoops {
+		totalFlow += primaryLoop.flowRate
+	}
+	rc.loopShares = make(map[*PrimaryLoop]float64, len(rc.primaryLoops))
+	for _, primaryLoop := range rc.primaryLoops {
+		rc.loopShares[primaryLoop] = 1 / float64(len(rc.primaryLoops))
+		if totalFlow > 0 {
+			rc.loopShares[primaryLoop] = primaryLoop.flowRate / totalFlow
+		}
+	}
+
+	flowing := make([]*PrimaryLoop, 0, len(rc.primaryLoops))
+	slowest := math.Inf(1)
+	temperature, boron := 0.0, 0.0
+	for _, primaryLoop := range rc.primaryLoops {
+		if primaryLoop.flowRate > 0 {
+			flowing = append(flowing, primaryLoop)
+			slowest = math.Min(slowest, primaryLoop.RelativeFlow())
+			temperature += primaryLoop.averageTemperature
+			boron += primaryLoop.boronConcentration
+		}
 	}
-	if rc.primaryLoop.boronConcentration < targetBoronConcentration {
-		reactivity += 0.01
-	} else if rc.primaryLoop.boronConcentration > targetBoronConcentration {
-		reactivity -= 0.01
+	if len(flowing) < 2 {
+		return
+	}
+	temperature /= float64(len(flowing))
+	boron /= float64(len(flowing))
+
+	mixed := 1 - math.Exp(-SECONDS_PER_TICK*slowest/VESSEL_MIXING_TIME)
+	for _, primaryLoop := range flowing {
+		shift := (temperature - primaryLoop.averageTemperature) * mixed
+		primaryLoop.averageTemperature += shift
+		primaryLoop.hotLegTemperature += shift
+		primaryLoop.coldLegTemperature += shift
+		primaryLoop.boronConcentration += (boron - primaryLoop.boronConcentration) * mixed
+	}
+}
+
+// loopShare is the part of the core heat the given loop carries, as of the
+// last mix; until then the loops share evenly
+func (rc *ReactorCore) loopShare(loop *PrimaryLoop) (float64, bool) {
+	if share, ok := rc.loopShares[loop]; ok {
+		return share, true
+	}
+	for _, primaryLoop := range rc.primaryLoops {
+		if primaryLoop == loop {
+			return 1 / float64(len(rc.primaryLoops)), true
+		}
+	}
+	return 0, false
+}
+
+// coolantAverage weighs what each loop brings into the core by its share of the flow
+func (rc *ReactorCore) coolantAverage(value func(*PrimaryLoop) float64) float64 {
+	average := 0.0
+	for _, primaryLoop := range rc.primaryLoops {
+		share, _ := rc.loopShare(primaryLoop)
+		average += share * value(primaryLoop)
 	}
-	if rc.controlRods.AverageControlRodExtraction() < targetControlRodExtraction {
-		reactivity -= 0.01
-	} else if rc.controlRods.AverageControlRodExtraction() > targetControlRodExtraction {
-		reactivity += 0.01
+	return average
+}
+
+func (rc *ReactorCore) moderatorTemperature() float64 {
+	if len(rc.primaryLoops) == 0 {
+		return FEEDBACK_REFERENCE_TEMPERATURE
 	}
-	rc.reactivity = reactivity
+	return rc.coolantAverage(func(pl *PrimaryLoop) float64 { return pl.averageTemperature })
+}
 
-	// use reactivity to determine flux
-	if rc.reactivity > 0 {
-		rc.neutronFlux *= 1.1
-	} else if rc.reactivity < 0 {
-		rc.neutronFlux *= 0.1
+func (rc *ReactorCore) boronConcentration() float64 {
+	if len(rc.primaryLoops) == 0 {
+		return 0
 	}
+	return rc.coolantAverage(func(pl *PrimaryLoop) float64 { return pl.boronConcentration })
+}
+
+// in pcm per °C
+func (rc *ReactorCore) ModeratorTemperatureCoefficient() float64 {
+	return moderatorTemperatureCoefficient(rc.moderatorTemperature(), rc.boronConcentration())
+}
+
+// in pcm
+func dopplerReactivity(fuelTemperature float64) float64 {
+	return DOPPLER_COEFFICIENT * (fuelTemperature - FEEDBACK_REFERENCE_TEMPERATURE)
+}
+
+// in pcm per °C; water barely changes density when cold, so the coefficient grows
+// with temperature. High boron concentrations push it toward zero or even positive,
+// since warmer, less dense water carries less boron through the core.
+func moderatorTemperatureCoefficient(temperature, boronConcentration float64) float64 {
+	warmth := math.Max(0, temperature-FEEDBACK_REFERENCE_TEMPERATURE) / (MODERATOR_COEFFICIENT_TEMPERATURE - FEEDBACK_REFERENCE_TEMPERATURE)
+	return (MODERATOR_COEFFICIENT_UNBORATED + MODERATOR_COEFFICIENT_BORON_SLOPE*boronConcentration) * warmth
+}
+
+// in pcm; the coefficient integrated up from the reference temperature
+func moderatorReactivity(temperature, boronConcentration float64) float64 {
+	rise := math.Max(0, temperature-FEEDBACK_REFERENCE_TEMPERATURE)
+	return moderatorTemperatureCoefficient(temperature, boronConcentration) * rise / 2
+}
 
-	// Update heat energy rate based on reactivity
-	rc.heatEnergyRate = 3000.0 * (rc.reactivity + 1.0) // Assuming max output of 3000 MW
+// factors that affect reactivity
+//  1. PrimaryLoop.boronConcentration
+//  2. ControlRods position: shutdown banks out, control banks partially out
+//  3. Place in fuel cycle
+//  4. Fuel (Doppler) and moderator temperatures
+//  5. Xenon and samarium fission product poisons
+//
+// formula: ρ = (k - 1) / k, where ρ is reactivity and k is the effective multiplication factor
+//
+// key assumption for the model:
+//
+//	the fuel's excess reactivity depends on burnup alone and is given for a cold,
+//	clean core with all rods out; everything else takes away from it
+func (rc *ReactorCore) totalReactivity() float64 {
+	rc.dopplerFeedback = dopplerReactivity(rc.temperature)
+	rc.moderatorFeedback = moderatorReactivity(rc.moderatorTemperature(), rc.boronConcentration())
 
-	// Simple temperature model (this should be more complex in reality)
-	rc.temperature += (rc.heatEnergyRate / 1000.0) * 0.1          // Simplified heating
-	rc.temperature = math.Max(20, math.Min(rc.temperature, 1000)) // Limit temperature range
+	return excessReactivity(rc.burnup) +
+		BORON_WORTH*rc.boronConcentration() +
+		rc.controlRods.Reactivity() +
+		rc.dopplerFeedback +
+		rc.moderatorFeedback +
+		rc.poisons.XenonReactivity() +
+		rc.poisons.SamariumReactivity()
 }
 
 func (rc *ReactorCore) Status() map[string]interface{} {
-	return map[string]interface{}{
-		"name":           rc.Name,
-		"reactivity":     rc.reactivity,
-		"neutronFlux":    rc.neutronFlux,
-		"temperature":    rc.temperature,
-		"heatEnergyRate": rc.heatEnergyRate,
-		"controlRods":    rc.controlRods.Status(),
+	status := map[string]interface{}{
+		"name":                            rc.Name,
+		"burnup":                          rc.burnup,
+		"burnupUnit":                      "MWd/tU",
+		"partOfCycle":                     lookupPartOfCycle(rc.burnup),
+		"excessReactivity":                excessReactivity(rc.burnup),
+		"criticalBoronConcentration":      rc.CriticalBoronConcentration(),
+		"shutdownBoronConcentration":      rc.ShutdownBoronConcentration(),
+		"reactivity":                      rc.reactivity,
+		"reactivityUnit":                  "pcm",
+		"reactivityDollars":               rc.ReactivityDollars(),
+		"neutronFlux":                     rc.neutronFlux,
+		"powerLevel":                      rc.PowerLevel(),
+		"startupRate":                     rc.StartupRate(),
+		"temperature":                     rc.temperature,
+		"heatEnergyRate":                  rc.heatEnergyRate,
+		"decayHeat":                       rc.DecayHeat(),
+		"dopplerFeedback":                 rc.dopplerFeedback,
+		"moderatorFeedback":               rc.moderatorFeedback,
+		"moderatorTemperatureCoefficient": rc.ModeratorTemperatureCoefficient(),
+		"xenonReactivity":                 rc.poisons.XenonReactivity(),
+		"samariumReactivity":              rc.poisons.SamariumReactivity(),
+		"fissionPoisons":                  rc.poisons.Status(),
+		"controlRods":                     rc.controlRods.Status(),
 	}
+	// a steady core has an infinite period, which JSON cannot carry
+	if period := rc.kinetics.Period(); !math.IsInf(period, 0) {
+		status["period"] = period
+	}
+	return status
 }
 
 func (rc *ReactorCore) PrintStatus() {
 	fmt.Printf("Reactor Core: %s\n", rc.Name)
-	fmt.Printf("\tReactivity: %.2f\n", rc.reactivity)
-	fmt.Printf("\tNeutron Flux: %.2f\n", rc.neutronFlux)
+	fmt.Printf("\tBurnup: %.1f MWd/tU (%s of cycle)\n", rc.burnup, lookupPartOfCycle(rc.burnup))
+	fmt.Printf("\tCritical Boron Concentration: %.0f ppm\n", rc.CriticalBoronConcentration())
+	fmt.Printf("\tShutdown Boron Concentration: %.0f ppm\n", rc.ShutdownBoronConcentration())
+	fmt.Printf("\tReactivity: %.2f pcm ($%.3f)\n", rc.reactivity, rc.ReactivityDollars())
+	fmt.Printf("\tNeutron Flux: %.3e n/cm²/s\n", rc.neutronFlux)
+	fmt.Printf("\tPower Level: %.4f%%\n", rc.PowerLevel())
+	fmt.Printf("\tStartup Rate: %.3f DPM\n", rc.StartupRate())
 	fmt.Printf("\tTemperature: %.2f°C\n", rc.temperature)
+	fmt.Printf("\tDoppler Feedback: %.2f pcm\n", rc.dopplerFeedback)
+	fmt.Printf("\tModerator Feedback: %.2f pcm (%.2f pcm/°C)\n", rc.moderatorFeedback, rc.ModeratorTemperatureCoefficient())
+	fmt.Printf("\tXenon: %.2f pcm\n", rc.poisons.XenonReactivity())
+	fmt.Printf("\tSamarium: %.2f pcm\n", rc.poisons.SamariumReactivity())
 	fmt.Printf("\tHeat Energy Rate: %.2f MW\n", rc.heatEnergyRate)
+	fmt.Printf("\tDecay Heat: %.2f MW\n", rc.DecayHeat())
 	fmt.Printf("\tControl Rods: %v\n", rc.controlRods.Status())
 }
 
@@ -158,12 +338,60 @@ func (rc *ReactorCore) HeatEnergyRate() float64 {
 	return rc.heatEnergyRate
 }
 
+// in MW; heat straight from the chain reaction, going by the neutron population
+func (rc *ReactorCore) FissionPower() float64 {
+	return rc.kinetics.RelativePower() * RATED_THERMAL_POWER
+}
+
+// in MW; heat from fission products, which keeps coming after a scram
+func (rc *ReactorCore) DecayHeat() float64 {
+	return rc.decayHeat.Power()
+}
+
+// in MWd/tU
+func (rc *ReactorCore) Burnup() float64 {
+	return rc.burnup
+}
+
+// in ppm; where to hold boron for hot full power at today's burnup
+func (rc *ReactorCore) CriticalBoronConcentration() float64 {
+	return criticalBoronConcentration(rc.burnup)
+}
+
+// in ppm; where to hold boron to keep the cold core shut down at today's burnup
+func (rc *ReactorCore) ShutdownBoronConcentration() float64 {
+	return shutdownBoronConcentration(rc.burnup)
+}
+
+// in pcm
+func (rc *ReactorCore) Reactivity() float64 {
+	return rc.reactivity
+}
+
+func (rc *ReactorCore) ReactivityDollars() float64 {
+	return pcmToDollars(rc.reactivity)
+}
+
+// percent of rated thermal power, from neutron population
+func (rc *ReactorCore) PowerLevel() float64 {
+	return rc.kinetics.RelativePower() * 100
+}
+
+// in decades per minute
+func (rc *ReactorCore) StartupRate() float64 {
+	return rc.kinetics.StartupRate()
+}
+
+func (rc *ReactorCore) ControlRods() *ControlRods {
+	return rc.controlRods
+}
+
 func (rc *ReactorCore) WithdrawShutdownBanks() {
-	rc.withdrawShutdownBanks = true
+	rc.controlRods.InitiateShutdownBankWithdrawal()
 }
 
 func (rc *ReactorCore) InsertShutdownBanks() {
-	rc.withdrawShutdownBanks = false
+	rc.controlRods.InitiateShutdownBankInsertion()
 }
 
 func (rc *ReactorCore) ScramReactor() {
@@ -173,3 +401,18 @@ func (rc *ReactorCore) ScramReactor() {
 func (rc *ReactorCore) CancelScram() {
 	rc.scram = false
 }
+
+func (rc *ReactorCore) AlarmConditions() []*AlarmCondition {
+	return []*AlarmCondition{
+		NewAlarmCondition("highFuelTemperature", "CORE FUEL TEMPERATURE HIGH", ALARM_PRIORITY_MEDIUM, func() bool {
+			return rc.temperature > HIGH_FUEL_TEMPERATURE_ALARM
+		}),
+		NewAlarmCondition("highStartupRate", "STARTUP RATE HIGH", ALARM_PRIORITY_MEDIUM, func() bool {
+			return rc.StartupRate() > HIGH_STARTUP_RATE_ALARM
+		}),
+		NewAlarmCondition("controlRodBottom", "CONTROL ROD BOTTOM", ALARM_PRIORITY_LOW, func() bool {
+			// only worth telling when the core should be running
+			return rc.controlRods.ShutdownBanksFullyWithdrawn() && len(rc.controlRods.RodBottomBanks()) > 0
+		}),
+	}
+}
@@ -2,6 +2,7 @@ package sim
 
 import (
 	"fmt"
+	"math"
 	"testing"
 )
 
@@ -53,3 +54,215 @@ func TestInitialReactivityIsNegative(t *testing.T) {
 		t.Errorf("Initial reactivity should be negative, got: %f", reactorCore.reactivity)
 	}
 }
+
+func TestTemperatureFeedbackIsNegative(t *testing.T) {
+	primaryLoop := NewPrimaryLoop("Test Primary Loop")
+	reactorCore := NewReactorCore("Test Reactor Core")
+	reactorCore.ConnectToPrimaryLoop(primaryLoop)
+
+	coldReactivity := reactorCore.totalReactivity()
+
+	reactorCore.temperature += 100
+	hotFuelReactivity := reactorCore.totalReactivity()
+	if !almostEqual(hotFuelReactivity-coldReactivity, 100*DOPPLER_COEFFICIENT, 0.001) {
+		t.Errorf("Expected hotter fuel to cost %f pcm, got %f", 100*DOPPLER_COEFFICIENT, hotFuelReactivity-coldReactivity)
+	}
+
+	primaryLoop.averageTemperature += 100
+	hotCoolantReactivity := reactorCore.totalReactivity()
+	if hotCoolantReactivity >= hotFuelReactivity {
+		t.Errorf("Expected hotter coolant to take reactivity away, got %f then %f", hotFuelReactivity, hotCoolantReactivity)
+	}
+}
+
+func TestTemperatureFeedbackLimitsPowerExcursion(t *testing.T) {
+	simulation := NewSimulation("Sim for Reactor Core Testing", "Customers do not replace QA.")
+	env := NewEnvironment()
+	primaryLoop := NewPrimaryLoop("Test Primary Loop")
+	simulation.AddComponent(primaryLoop)
+	reactorCore := NewReactorCore("Test Reactor Core")
+	reactorCore.ConnectToPrimaryLoop(primaryLoop)
+	simulation.AddComponent(reactorCore)
+
+	// all rods out, with just enough boron missing to leave the cold core 200 pcm supercritical
+	for _, bank := range reactorCore.controlRods.controlBanks {
+		bank.position = MAX_WITHDRAWAL_STEPS
+		bank.target = MAX_WITHDRAWAL_STEPS
+	}
+	for _, bank := range reactorCore.controlRods.shutdownBanks {
+		bank.position = MAX_WITHDRAWAL_STEPS
+		bank.target = MAX_WITHDRAWAL_STEPS
+	}
+	reactorCore.WithdrawShutdownBanks()
+	primaryLoop.boronConcentration = (reactorCore.totalReactivity() - 200) / -BORON_WORTH
+
+	peakPower := 0.0
+	for i := 0; i < 120; i++ {
+		primaryLoop.Update(env, simulation)
+		reactorCore.Update(env, simulation)
+		peakPower = math.Max(peakPower, reactorCore.PowerLevel())
+	}
+
+	if peakPower >= MAX_RELATIVE_POWER*100 {
+		t.Errorf("Expected temperature feedback to turn power around, but it ran away to %f%%", peakPower)
+	}
+	if peakPower < 1 {
+		t.Errorf("Expected the core to reach the power range, peaked at %f%%", peakPower)
+	}
+	// with nowhere for the heat to go, the warm core ends up just subcritical
+	if reactorCore.reactivity > 0 || reactorCore.reactivity < -200 {
+		t.Errorf("Expected feedback to take back the 200 pcm, got %f pcm", reactorCore.reactivity)
+	}
+}
+
+func TestScramTakesRodsOutOfAutomatic(t *testing.T) {
+	simulation, env := setupSimulationEnvironment()
+	primaryLoop := NewPrimaryLoop("Test Primary Loop")
+	simulation.AddComponent(primaryLoop)
+	reactorCore := NewReactorCore("Test Reactor Core")
+	reactorCore.ConnectToPrimaryLoop(primaryLoop)
+	simulation.AddComponent(reactorCore)
+
+	// a cold core in automatic wants the rods out
+	controlRods := reactorCore.ControlRods()
+	controlRods.MoveControlBanksInSequence(400)
+	controlRods.SwitchToAutomaticRodControl()
+
+	reactorCore.ScramReactor()
+	for i := 0; i < 3; i++ {
+		primaryLoop.Update(env, simulation)
+		reactorCore.Update(env, simulation)
+		for _, bank := range controlRods.controlBanks {
+			if !bank.RodBottom() {
+				t.Errorf("Expected %s on the bottom after scram, at %d", bank.label, bank.Position())
+			}
+		}
+	}
+	if controlRods.bankDemand != 0 || controlRods.RodControlMode() != ROD_CONTROL_MANUAL {
+		t.Errorf("Expected scram to leave rod control in manual with no demand, got %s at %d", controlRods.RodControlMode(), controlRods.bankDemand)
+	}
+
+	reactorCore.CancelScram()
+	for i := 0; i < 3; i++ {
+		primaryLoop.Update(env, simulation)
+		reactorCore.Update(env, simulation)
+	}
+	for _, bank := range controlRods.controlBanks {
+		if !bank.RodBottom() || bank.Target() != 0 {
+			t.Errorf("Expected %s to stay on the bottom once the scram is reset, at %d heading for %d", bank.label, bank.Position(), bank.Target())
+		}
+	}
+}
+
+func TestDecayHeatKeepsComingAfterScram(t *testing.T) {
+	simulation, env := setupSimulationEnvironment()
+	primaryLoop := NewPrimaryLoop("Test Primary Loop")
+	simulation.AddComponent(primaryLoop)
+	reactorCore := NewReactorCore("Test Reactor Core")
+	reactorCore.ConnectToPrimaryLoop(primaryLoop)
+	simulation.AddComponent(reactorCore)
+
+	// pretend the core has been at full power for weeks
+	reactorCore.kinetics = NewPointKinetics(1.0)
+	reactorCore.decayHeat.Advance(RATED_THERMAL_POWER, 4*WEEK_OF_MINUTES*SECONDS_PER_TICK)
+	primaryLoop.averageTemperature = HOT_FULL_POWER_TEMPERATURE
+
+	reactorCore.ScramReactor()
+	primaryLoop.Update(env, simulation)
+	reactorCore.Update(env, simulation)
+
+	if reactorCore.FissionPower() > 0.01*RATED_THERMAL_POWER {
+		t.Errorf("Expected fission power to collapse after scram, got %f MW", reactorCore.FissionPower())
+	}
+	if reactorCore.HeatEnergyRate() < 0.02*RATED_THERMAL_POWER {
+		t.Errorf("Expected decay heat to keep the core making several percent of rated power, got %f MW", reactorCore.HeatEnergyRate())
+	}
+
+	// with no steam generator to take it, decay heat warms the coolant
+	startTemperature := primaryLoop.AverageTemperature()
+	for i := 0; i < 10; i++ {
+		primaryLoop.Update(env, simulation)
+		reactorCore.Update(env, simulation)
+	}
+	if primaryLoop.AverageTemperature() <= startTemperature {
+		t.Errorf("Expected decay heat to heat up the primary coolant, went from %f to %f", startTemperature, primaryLoop.AverageTemperature())
+	}
+}
+
+func TestShutdownBanksMoveWithTheSimulation(t *testing.T) {
+	simulation, env := setupSimulationEnvironment()
+	primaryLoop := NewPrimaryLoop("Test Primary Loop")
+	simulation.AddComponent(primaryLoop)
+	reactorCore := NewReactorCore("Test Reactor Core")
+	reactorCore.ConnectToPrimaryLoop(primaryLoop)
+	simulation.AddComponent(reactorCore)
+
+	reactorCore.WithdrawShutdownBanks()
+	reactorCore.Update(env, simulation)
+
+	sd1 := reactorCore.ControlRods().shutdownBanks[0]
+	if sd1.Position() != int(SHUTDOWN_BANK_STEP_RATE) {
+		t.Errorf("Expected SD1 to move %d steps in a minute, got %d", int(SHUTDOWN_BANK_STEP_RATE), sd1.Position())
+	}
+	if reactorCore.Reactivity() <= NewControlRods().Reactivity() {
+		t.Errorf("Expected withdrawing SD1 to add reactivity, got %f pcm", reactorCore.Reactivity())
+	}
+
+	for i := 0; i < 30; i++ {
+		reactorCore.Update(env, simulation)
+	}
+	if !reactorCore.ControlRods().ShutdownBanksFullyWithdrawn() {
+		t.Errorf("Expected the shutdown banks to be out after half an hour, status: %v", reactorCore.ControlRods().Status())
+	}
+
+	reactorCore.InsertShutdownBanks()
+	reactorCore.Update(env, simulation)
+	if reactorCore.ControlRods().shutdownBanks[3].Position() == MAX_WITHDRAWAL_STEPS {
+		t.Errorf("Expected SD4 to start back in first")
+	}
+}
+
+func TestCoreHeatSplitsHotAndColdLegs(t *testing.T) {
+	simulation, env := setupSimulationEnvironment()
+	primaryLoop := NewPrimaryLoop("Test Primary Loop")
+	primaryLoop.SwitchOnPump()
+	simulation.AddComponent(primaryLoop)
+	reactorCore := NewReactorCore("Test Reactor Core")
+	reactorCore.ConnectToPrimaryLoop(primaryLoop)
+	simulation.AddComponent(reactorCore)
+	secondaryLoop := NewSecondaryLoop("Test Secondary Loop")
+	secondaryLoop.steamPressure = RATED_STEAM_PRESSURE
+	simulation.AddComponent(secondaryLoop)
+	steamGenerator := NewSteamGenerator("Test Steam Generator")
+	simulation.AddComponent(steamGenerator)
+
+	reactorCore.kinetics = NewPointKinetics(1.0)
+	reactorCore.heatEnergyRate = RATED_THERMAL_POWER
+	steamGenerator.Update(env, simulation)
+	primaryLoop.averageTemperature = HOT_FULL_POWER_TEMPERATURE
+	primaryLoop.Update(env, simulation)
+	secondaryLoop.Update(env, simulation)
+
+	// with heat in and out nearly matched, the rise across the core is what the flow can carry
+	expected := RATED_THERMAL_POWER / (PRIMARY_RATED_FLOW_RATE * PRIMARY_COOLANT_DENSITY * PRIMARY_SPECIFIC_HEAT)
+	if !almostEqual(primaryLoop.TemperatureRise(), expected, 0.05*expected) {
+		t.Errorf("Expected about %f °C across the core, got %f", expected, primaryLoop.TemperatureRise())
+	}
+	if primaryLoop.HotLegTemperature() <= primaryLoop.AverageTemperature() || primaryLoop.ColdLegTemperature() >= primaryLoop.AverageTemperature() {
+		t.Errorf("Expected Thot %f > Tavg %f > Tcold %f", primaryLoop.HotLegTemperature(), primaryLoop.AverageTemperature(), primaryLoop.ColdLegTemperature())
+	}
+
+	steamGenerator.Update(env, simulation)
+	if steamGenerator.primaryInletTemp != primaryLoop.HotLegTemperature() {
+		t.Errorf("Expected the steam generator to see the hot leg, got %f", steamGenerator.primaryInletTemp)
+	}
+
+	// losing flow leaves the core heat with nowhere to go
+	rise := primaryLoop.TemperatureRise()
+	primaryLoop.SwitchOffPump()
+	primaryLoop.Update(env, simulation)
+	secondaryLoop.Update(env, simulation)
+	if primaryLoop.TemperatureRise() <= rise {
+		t.Errorf("Expected the rise across the core to grow without flow, went from %f to %f", rise, primaryLoop.TemperatureRise())
+	}
+}
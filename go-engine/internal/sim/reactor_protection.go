@@ -0,0 +1,380 @@
+package sim
+
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
+// The reactor protection system watches the plant and scrams the core when
+// something goes beyond what it was designed for. Each trip function watches
+// one parameter through four independent instrument channels; a single channel
+// can fail or drift, so the function only trips when two of the four agree.
+// A bypassed channel is left out of the vote.
+//
+// Some trips only make sense once the plant is making power. Low pressure and
+// low flow are blocked below the P-7 permissive, and a turbine trip only trips
+// the reactor above P-9, where the steam dumps can no longer take the load.
+//
+// Once tripped, the scram holds until an operator resets it, which is only
+// allowed once every trip condition has cleared. The first trip function to
+// fire is kept as the first-out, to tell what started it.
+
+const TRIP_CHANNELS = 4
+const TRIP_COINCIDENCE = 2 // channels that must agree
+
+// permissives, in percent power
+const P7_POWER = 10.0
+const P9_POWER = 50.0
+
+// default setpoints
+const (
+	HIGH_FLUX_TRIP_SETPOINT            = 109.0 // percent power
+	HIGH_STARTUP_RATE_TRIP_SETPOINT    = 2.0   // decades per minute
+	LOW_PRESSURIZER_PRESSURE_SETPOINT  = 13.1  // MPa
+	HIGH_PRESSURIZER_PRESSURE_SETPOINT = 16.5  // MPa
+	LOW_PRIMARY_FLOW_TRIP_SETPOINT     = 90.0  // percent of rated flow
+	STEAM_GENERATOR_LOW_LEVEL_SETPOINT = STEAM_GENERATOR_LOW_LOW_LEVEL
+)
+
+// trip function names
+const (
+	HIGH_NEUTRON_FLUX_TRIP         = "highNeutronFlux"
+	HIGH_STARTUP_RATE_TRIP         = "highStartupRate"
+	LOW_PRESSURIZER_PRESSURE_TRIP  = "lowPressurizerPressure"
+	HIGH_PRESSURIZER_PRESSURE_TRIP = "highPressurizerPressure"
+	LOW_PRIMARY_FLOW_TRIP          = "lowPrimaryFlow"
+	STEAM_GENERATOR_LOW_LEVEL_TRIP = "steamGeneratorLowLevel"
+	TURBINE_TRIP                   = "turbineTrip"
+	MANUAL_TRIP                    = "manual"
+)
+
+type TripChannel struct {
+	offset   float64 // instrument error, added to what the channel reads
+	bypassed bool
+	tripped  bool
+}
+
+type TripFunction struct {
+	name       string
+	unit       string
+	setpoint   float64
+	high       bool    // trips above the setpoint when true, below it when false
+	permissive float64 // percent power the function is armed above; 0 for always
+	measure    func(s *Simulation) (float64, bool)
+	value      float64
+	armed      bool
+	tripped    bool
+	channels   [TRIP_CHANNELS]*TripChannel
+}
+
+func NewTripFunction(name string, unit string, setpoint float64, high bool, permissive float64, measure func(s *Simulation) (float64, bool)) *TripFunction {
+	tf := &TripFunction{
+		name:       name,
+		unit:       unit,
+		setpoint:   setpoint,
+		high:       high,
+		permissive: permissive,
+		measure:    measure,
+	}
+	for i := range tf.channels {
+		tf.channels[i] = &TripChannel{}
+	}
+	return tf
+}
+
+// evaluate reads the parameter through every channel and votes
+func (tf *TripFunction) evaluate(s *Simulation, powerLevel float64) {
+	value, found := tf.measure(s)
+	tf.value = value
+	tf.armed = found && powerLevel >= tf.permissive
+
+	votes := 0
+	for _, channel := range tf.channels {
+		reading := value + channel.offset
+		channel.tripped = tf.armed && !channel.bypassed &&
+			((tf.high && reading > tf.setpoint) || (!tf.high && reading < tf.setpoint))
+		if channel.tripped {
+			votes++
+		}
+	}
+	tf.tripped = votes >= TRIP_COINCIDENCE
+}
+
+func (tf *TripFunction) Name() string {
+	return tf.name
+}
+
+func (tf *TripFunction) Setpoint() float64 {
+	return tf.setpoint
+}
+
+func (tf *TripFunction) IsTripped() bool {
+	return tf.tripped
+}
+
+func (tf *TripFunction) Status() map[string]interface{} {
+	channels := make([]map[string]interface{}, 0, TRIP_CHANNELS)
+	for _, channel := range tf.channels {
+		channels = append(channels, map[string]interface{}{
+			"offset":   channel.offset,
+			"bypassed": channel.bypassed,
+			"tripped":  channel.tripped,
+		})
+	}
+	return map[string]interface{}{
+		"setpoint": tf.setpoint,
+		"unit":     tf.unit,
+		"value":    tf.value,
+		"armed":    tf.armed,
+		"tripped":  tf.tripped,
+		"channels": channels,
+	}
+}
+
+type ReactorProtection struct {
+	BaseComponent
+	tripFunctions []*TripFunction
+	tripped       bool
+	firstOut      string    // trip function that fired first
+	trippedAt     time.Time // sim time of the first-out
+	manualTrip    bool
+}
+
+func NewReactorProtection(name string) *ReactorProtection {
+	return &ReactorProtection{
+		BaseComponent: BaseComponent{Name: name},
+		tripFunctions: []*TripFunction{
+			NewTripFunction(HIGH_NEUTRON_FLUX_TRIP, "%", HIGH_FLUX_TRIP_SETPOINT, true, 0, measurePowerLevel),
+			NewTripFunction(HIGH_STARTUP_RATE_TRIP, "DPM", HIGH_STARTUP_RATE_TRIP_SETPOINT, true, 0, measureStartupRate),
+			NewTripFunction(LOW_PRESSURIZER_PRESSURE_TRIP, "MPa", LOW_PRESSURIZER_PRESSURE_SETPOINT, false, P7_POWER, measurePressurizerPressure),
+			NewTripFunction(HIGH_PRESSURIZER_PRESSURE_TRIP, "MPa", HIGH_PRESSURIZER_PRESSURE_SETPOINT, true, 0, measurePressurizerPressure),
+			NewTripFunction(LOW_PRIMARY_FLOW_TRIP, "%", LOW_PRIMARY_FLOW_TRIP_SETPOINT, false, P7_POWER, measurePrimaryFlow),
+			NewTripFunction(STEAM_GENERATOR_LOW_LEVEL_TRIP, "%", STEAM_GENERATOR_LOW_LEVEL_SETPOINT, false, 0, measureSteamGeneratorLevel),
+			NewTripFunction(TURBINE_TRIP, "", 0.5, true, P9_POWER, measureTurbineTripped),
+		},
+	}
+}
+
+func measurePowerLevel(s *Simulation) (float64, bool) {
+	reactorCore := s.FindReactorCore()
+	if reactorCore == nil {
+		return 0, false
+	}
+	return reactorCore.PowerLevel(), true
+}
+
+func measureStartupRate(s *Simulation) (float64, bool) {
+	reactorCore := s.FindReactorCore()
+	if reactorCore == nil {
+		return 0, false
+	}
+	return reactorCore.StartupRate(), true
+}
+
+func measurePressurizerPressure(s *Simulation) (float64, bool) {
+	pressurizer := s.FindPressurizer()
+	if pressurizer == nil {
+		return 0, false
+	}
+	return pressurizer.Pressure(), true
+}
+
+// in percent of the flow with the pump running, in the loop with the least
+func measurePrimaryFlow(s *Simulation) (float64, bool) {
+	primaryLoops := s.FindPrimaryLoops()
+	if len(primaryLoops) == 0 {
+		return 0, false
+	}
+	lowest := math.Inf(1)
+	for _, primaryLoop := range primaryLoops {
+		lowest = math.Min(lowest, primaryLoop.FlowVolume()/(primaryLoop.ratedFlowRate()*60)*100)
+	}
+	return lowest, true
+}
+
+// in the steam generator with the lowest level
+func measureSteamGeneratorLevel(s *Simulation) (float64, bool) {
+	steamGenerators := s.FindSteamGenerators()
+	if len(steamGenerators) == 0 {
+		return 0, false
+	}
+	lowest := math.Inf(1)
+	for _, steamGenerator := range steamGenerators {
+		lowest = math.Min(lowest, steamGenerator.Level())
+	}
+	return lowest, true
+}
+
+// 1 once the turbine has tripped
+func measureTurbineTripped(s *Simulation) (float64, bool) {
+	turbine := s.FindSteamTurbine()
+	if turbine == nil {
+		return 0, false
+	}
+	if turbine.IsTripped() {
+		return 1, true
+	}
+	return 0, true
+}
+
+func (rp *ReactorProtection) Update(env *Environment, s *Simulation) {
+	powerLevel, _ := measurePowerLevel(s)
+
+	for _, tf := range rp.tripFunctions {
+		tf.evaluate(s, powerLevel)
+		if tf.tripped && !rp.tripped {
+			rp.trip(tf.name, s.clock.SimTime())
+		}
+	}
+	if rp.manualTrip && !rp.tripped {
+		rp.trip(MANUAL_TRIP, s.clock.SimTime())
+	}
+
+	// the scram holds until reset
+	if rp.tripped {
+		if reactorCore := s.FindReactorCore(); reactorCore != nil {
+			reactorCore.ScramReactor()
+		}
+	}
+}
+
+func (rp *ReactorProtection) trip(firstOut string, at time.Time) {
+	rp.tripped = true
+	rp.firstOut = firstOut
+	rp.trippedAt = at
+}
+
+// ManualTrip scrams the reactor from the control room, on the next tick
+func (rp *ReactorProtection) ManualTrip() {
+	rp.manualTrip = true
+}
+
+// Reset clears the trip and lets the rods be withdrawn again, as long as no
+// trip function is still tripped.
+func (rp *ReactorProtection) Reset(s *Simulation) error {
+	for _, tf := range rp.tripFunctions {
+		if tf.tripped {
+			return fmt.Errorf("cannot reset reactor trip while %s is tripped", tf.name)
+		}
+	}
+
+	rp.tripped = false
+	rp.manualTrip = false
+	rp.firstOut = ""
+	rp.trippedAt = time.Time{}
+	if reactorCore := s.FindReactorCore(); reactorCore != nil {
+		reactorCore.CancelScram()
+	}
+	return nil
+}
+
+func (rp *ReactorProtection) findTripFunction(name string) *TripFunction {
+	for _, tf := range rp.tripFunctions {
+		if tf.name == name {
+			return tf
+		}
+	}
+	return nil
+}
+
+func (rp *ReactorProtection) SetSetpoint(name string, setpoint float64) error {
+	tf := rp.findTripFunction(name)
+	if tf == nil {
+		return fmt.Errorf("no trip function named %s", name)
+	}
+	if setpoint < 0 {
+		return fmt.Errorf("setpoint for %s cannot be negative", name)
+	}
+	tf.setpoint = setpoint
+	return nil
+}
+
+func (rp *ReactorProtection) checkChannel(name string, channel int) (*TripFunction, error) {
+	tf := rp.findTripFunction(name)
+	if tf == nil {
+		return nil, fmt.Errorf("no trip function named %s", name)
+	}
+	if channel < 1 || channel > TRIP_CHANNELS {
+		return nil, fmt.Errorf("channel must be 1 through %d", TRIP_CHANNELS)
+	}
+	return tf, nil
+}
+
+// channel 1 thru 4
+func (rp *ReactorProtection) BypassChannel(name string, channel int) error {
+	tf, err := rp.checkChannel(name, channel)
+	if err != nil {
+		return err
+	}
+	tf.channels[channel-1].bypassed = true
+	return nil
+}
+
+func (rp *ReactorProtection) RestoreChannel(name string, channel int) error {
+	tf, err := rp.checkChannel(name, channel)
+	if err != nil {
+		return err
+	}
+	tf.channels[channel-1].bypassed = false
+	return nil
+}
+
+// SetChannelOffset miscalibrates a channel by the given amount, in the trip
+// function's unit
+func (rp *ReactorProtection) SetChannelOffset(name string, channel int, offset float64) error {
+	tf, err := rp.checkChannel(name, channel)
+	if err != nil {
+		return err
+	}
+	tf.channels[channel-1].offset = offset
+	return nil
+}
+
+func (rp *ReactorProtection) IsTripped() bool {
+	return rp.tripped
+}
+
+func (rp *ReactorProtection) FirstOut() string {
+	return rp.firstOut
+}
+
+func (rp *ReactorProtection) TripFunctions() []*TripFunction {
+	return rp.tripFunctions
+}
+
+func (rp *ReactorProtection) Status() map[string]interface{} {
+	tripFunctions := make(map[string]interface{})
+	for _, tf := range rp.tripFunctions {
+		tripFunctions[tf.name] = tf.Status()
+	}
+	status := map[string]interface{}{
+		"name":          rp.Name,
+		"tripped":       rp.tripped,
+		"firstOut":      rp.firstOut,
+		"tripFunctions": tripFunctions,
+	}
+	if rp.tripped {
+		status["trippedAt"] = rp.trippedAt
+	}
+	return status
+}
+
+func (rp *ReactorProtection) PrintStatus() {
+	fmt.Printf("Reactor Protection: %s\n", rp.Name)
+	fmt.Printf("\tTripped: %t\n", rp.tripped)
+	if rp.tripped {
+		fmt.Printf("\tFirst Out: %s at %s\n", rp.firstOut, rp.trippedAt)
+	}
+	for _, tf := range rp.tripFunctions {
+		fmt.Printf("\t%s: %.2f %s (setpoint %.2f), tripped: %t\n", tf.name, tf.value, tf.unit, tf.setpoint, tf.tripped)
+	}
+}
+
+func (rp *ReactorProtection) AlarmConditions() []*AlarmCondition {
+	return []*AlarmCondition{
+		NewAlarmCondition("reactorTrip", "REACTOR TRIP", ALARM_PRIORITY_HIGH, func() bool {
+			return rp.tripped
+		}),
+	}
+}
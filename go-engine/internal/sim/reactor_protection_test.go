@@ -0,0 +1,135 @@
+package sim
+
+import (
+	"testing"
+)
+
+func setUpProtectedPlant() (*Simulation, *Environment, *ReactorCore, *ReactorProtection) {
+	simulation := NewSimulation("Sim for Reactor Protection Testing", "Two out of four ain't bad.")
+	env := NewEnvironment()
+	primaryLoop := NewPrimaryLoop("Test Primary Loop")
+	primaryLoop.SwitchOnPump()
+	simulation.AddComponent(primaryLoop)
+	reactorCore := NewReactorCore("Test Reactor Core")
+	reactorCore.ConnectToPrimaryLoop(primaryLoop)
+	simulation.AddComponent(reactorCore)
+	reactorProtection := NewReactorProtection("Test Reactor Protection")
+	simulation.AddComponent(reactorProtection)
+	primaryLoop.Update(env, simulation)
+	return simulation, env, reactorCore, reactorProtection
+}
+
+func TestHighFluxTripScramsTheReactor(t *testing.T) {
+	simulation, env, reactorCore, reactorProtection := setUpProtectedPlant()
+
+	reactorProtection.Update(env, simulation)
+	if reactorProtection.IsTripped() {
+		t.Errorf("Expected no trip for a shut down core, first out: %s", reactorProtection.FirstOut())
+	}
+
+	reactorCore.kinetics = NewPointKinetics(1.2)
+	reactorProtection.Update(env, simulation)
+	if !reactorProtection.IsTripped() || reactorProtection.FirstOut() != HIGH_NEUTRON_FLUX_TRIP {
+		t.Errorf("Expected a high flux trip at 120%% power, got tripped %t, first out %s", reactorProtection.IsTripped(), reactorProtection.FirstOut())
+	}
+
+	// delayed neutrons hold some power up for a while
+	reactorCore.Update(env, simulation)
+	if !reactorCore.ControlRods().ShutdownBanksFullyInserted() || reactorCore.PowerLevel() > 10 {
+		t.Errorf("Expected the trip to scram the core, power at %f%%", reactorCore.PowerLevel())
+	}
+}
+
+func TestTripNeedsTwoOutOfFourChannels(t *testing.T) {
+	simulation, env, reactorCore, reactorProtection := setUpProtectedPlant()
+	reactorCore.kinetics = NewPointKinetics(1.0)
+
+	// one channel reading high is not enough
+	reactorProtection.SetChannelOffset(HIGH_NEUTRON_FLUX_TRIP, 1, 15)
+	reactorProtection.Update(env, simulation)
+	if reactorProtection.IsTripped() {
+		t.Errorf("Expected one channel in trip to be outvoted")
+	}
+
+	// a second bypassed channel does not count either
+	reactorProtection.SetChannelOffset(HIGH_NEUTRON_FLUX_TRIP, 2, 15)
+	reactorProtection.BypassChannel(HIGH_NEUTRON_FLUX_TRIP, 2)
+	reactorProtection.Update(env, simulation)
+	if reactorProtection.IsTripped() {
+		t.Errorf("Expected a bypassed channel to be left out of the vote")
+	}
+
+	reactorProtection.RestoreChannel(HIGH_NEUTRON_FLUX_TRIP, 2)
+	reactorProtection.Update(env, simulation)
+	if !reactorProtection.IsTripped() {
+		t.Errorf("Expected two channels in trip to trip the reactor")
+	}
+}
+
+func TestFirstOutAndReset(t *testing.T) {
+	simulation, env, reactorCore, reactorProtection := setUpProtectedPlant()
+	pressurizer := NewPressurizer("Test Pressurizer")
+	simulation.AddComponent(pressurizer)
+
+	pressurizer.pressure = 17.0
+	reactorProtection.Update(env, simulation)
+	if reactorProtection.FirstOut() != HIGH_PRESSURIZER_PRESSURE_TRIP {
+		t.Errorf("Expected high pressurizer pressure as the first out, got %s", reactorProtection.FirstOut())
+	}
+
+	// a later trip does not take over the first out
+	reactorCore.kinetics = NewPointKinetics(1.2)
+	reactorProtection.Update(env, simulation)
+	if reactorProtection.FirstOut() != HIGH_PRESSURIZER_PRESSURE_TRIP {
+		t.Errorf("Expected the first out to stay high pressurizer pressure, got %s", reactorProtection.FirstOut())
+	}
+
+	if err := reactorProtection.Reset(simulation); err == nil {
+		t.Errorf("Expected reset to be refused while trips are still in")
+	}
+
+	pressurizer.pressure = TARGET_PRESSURE
+	reactorCore.kinetics = NewPointKinetics(SOURCE_RANGE_POWER)
+	reactorProtection.Update(env, simulation)
+	if err := reactorProtection.Reset(simulation); err != nil {
+		t.Errorf("Expected reset to clear once conditions are back to normal: %v", err)
+	}
+	if reactorProtection.IsTripped() || reactorCore.scram {
+		t.Errorf("Expected reset to clear the trip and the scram")
+	}
+}
+
+func TestLowFlowTripIsBlockedBelowP7(t *testing.T) {
+	simulation, env, reactorCore, reactorProtection := setUpProtectedPlant()
+	primaryLoop := simulation.FindPrimaryLoop()
+	primaryLoop.SwitchOffPump()
+	primaryLoop.Update(env, simulation)
+
+	// pumps off in a cold, shut down plant is normal
+	reactorProtection.Update(env, simulation)
+	if reactorProtection.IsTripped() {
+		t.Errorf("Expected low flow to be blocked below P-7, first out: %s", reactorProtection.FirstOut())
+	}
+
+	reactorCore.kinetics = NewPointKinetics(0.3)
+	reactorProtection.Update(env, simulation)
+	if reactorProtection.FirstOut() != LOW_PRIMARY_FLOW_TRIP {
+		t.Errorf("Expected a low flow trip at 30%% power with no pumps, got %s", reactorProtection.FirstOut())
+	}
+}
+
+func TestSetpointsAreConfigurable(t *testing.T) {
+	simulation, env, reactorCore, reactorProtection := setUpProtectedPlant()
+	reactorCore.kinetics = NewPointKinetics(1.0)
+
+	if err := reactorProtection.SetSetpoint("noSuchTrip", 1); err == nil {
+		t.Errorf("Expected an unknown trip function to be refused")
+	}
+	if err := reactorProtection.SetSetpoint(HIGH_NEUTRON_FLUX_TRIP, 90); err != nil {
+		t.Errorf("Expected setpoint change to be accepted: %v", err)
+	}
+	reactorProtection.Update(env, simulation)
+	if reactorProtection.FirstOut() != HIGH_NEUTRON_FLUX_TRIP {
+		t.Errorf("Expected a lowered flux setpoint to trip at full power, got %s", reactorProtection.FirstOut())
+	}
+}
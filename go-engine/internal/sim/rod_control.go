@@ -0,0 +1,121 @@
+package sim
+
+import (
+	"fmt"
+	"math"
+)
+
+// Control banks are moved as one sequence rather than one at a time. On the way
+// out, MA1 leads; once it is within the overlap of the top, MA2 starts to move,
+// and so on through MB2. On the way in, the order reverses. Overlap keeps the
+// total differential worth smooth, since one bank is leaving the core's
+// high-worth middle as the next enters it.
+//
+// In automatic, the rod control system moves the sequence to keep the average
+// coolant temperature on its program: Tref rises linearly with turbine load from
+// the no-load temperature to the full-load temperature. Hot coolant means too
+// much power for the load, so rods go in; cold coolant, rods come out.
+
+const (
+	ROD_CONTROL_MANUAL    = "manual"
+	ROD_CONTROL_AUTOMATIC = "automatic"
+)
+
+const BANK_OVERLAP_STEPS = 100
+
+// average temperature program, in °C
+const NO_LOAD_AVERAGE_TEMPERATURE = 291.7
+const FULL_LOAD_AVERAGE_TEMPERATURE = HOT_FULL_POWER_TEMPERATURE
+
+const ROD_CONTROL_DEADBAND = 0.8         // °C; no rod motion inside this band
+const ROD_CONTROL_MIN_SPEED = 8          // steps per minute, just outside the deadband
+const ROD_CONTROL_MAX_SPEED = 72         // steps per minute
+const ROD_CONTROL_FULL_SPEED_ERROR = 3.0 // °C off program for full speed
+
+// Tref in °C for the given turbine load in percent
+func referenceAverageTemperature(turbineLoad float64) float64 {
+	load := math.Max(0, math.Min(100, turbineLoad))
+	return NO_LOAD_AVERAGE_TEMPERATURE + (FULL_LOAD_AVERAGE_TEMPERATURE-NO_LOAD_AVERAGE_TEMPERATURE)*load/100
+}
+
+// steps between one bank starting to move and the next
+func (cr *ControlRods) bankSpacing() int {
+	return MAX_WITHDRAWAL_STEPS - cr.bankOverlap
+}
+
+// highest demand: every control bank fully withdrawn
+func (cr *ControlRods) MaxBankDemand() int {
+	return (len(cr.controlBanks)-1)*cr.bankSpacing() + MAX_WITHDRAWAL_STEPS
+}
+
+// MoveControlBanksInSequence sets every control bank's target from a single
+// demand, in steps along the overlapped sequence.
+func (cr *ControlRods) MoveControlBanksInSequence(demand int) {
+	cr.bankDemand = max(0, min(demand, cr.MaxBankDemand()))
+	for i, bank := range cr.controlBanks {
+		bank.SetTarget(cr.bankDemand - i*cr.bankSpacing())
+	}
+}
+
+// SetBankOverlap changes how many steps consecutive banks move together
+func (cr *ControlRods) SetBankOverlap(steps int) {
+	if steps < 0 || steps >= MAX_WITHDRAWAL_STEPS {
+		fmt.Printf("Bank overlap must be between 0 and %d steps. You requested %d.\n", MAX_WITHDRAWAL_STEPS-1, steps)
+		return
+	}
+	cr.bankOverlap = steps
+}
+
+func (cr *ControlRods) BankOverlap() int {
+	return cr.bankOverlap
+}
+
+// where the sequence stands, going by the banks that are out the furthest
+func (cr *ControlRods) currentBankDemand() int {
+	demand := 0
+	for i, bank := range cr.controlBanks {
+		if bank.Target() > 0 {
+			demand = max(demand, bank.Target()+i*cr.bankSpacing())
+		}
+	}
+	return demand
+}
+
+func (cr *ControlRods) SwitchToAutomaticRodControl() {
+	cr.rodControlMode = ROD_CONTROL_AUTOMATIC
+	// pick up the sequence from wherever the operator left the banks
+	cr.MoveControlBanksInSequence(cr.currentBankDemand())
+}
+
+func (cr *ControlRods) SwitchToManualRodControl() {
+	cr.rodControlMode = ROD_CONTROL_MANUAL
+}
+
+func (cr *ControlRods) RodControlMode() string {
+	return cr.rodControlMode
+}
+
+// UpdateRodControl moves the bank sequence to bring Tavg back onto its program.
+// Speed is proportional to the error, outside a deadband.
+func (cr *ControlRods) UpdateRodControl(averageTemperature, turbineLoad float64) {
+	cr.referenceTemperature = referenceAverageTemperature(turbineLoad)
+	if cr.rodControlMode != ROD_CONTROL_AUTOMATIC {
+		return
+	}
+
+	temperatureError := averageTemperature - cr.referenceTemperature
+	if math.Abs(temperatureError) <= ROD_CONTROL_DEADBAND {
+		return
+	}
+
+	share := math.Min(1, (math.Abs(temperatureError)-ROD_CONTROL_DEADBAND)/(ROD_CONTROL_FULL_SPEED_ERROR-ROD_CONTROL_DEADBAND))
+	speed := ROD_CONTROL_MIN_SPEED + int(share*(ROD_CONTROL_MAX_SPEED-ROD_CONTROL_MIN_SPEED))
+	if temperatureError > 0 {
+		speed = -speed // too hot, rods in
+	}
+	cr.MoveControlBanksInSequence(cr.bankDemand + speed)
+}
+
+func (cr *ControlRods) ReferenceTemperature() float64 {
+	return cr.referenceTemperature
+}
@@ -0,0 +1,102 @@
+package sim
+
+// The rod drive mechanisms step each bank toward its target at a fixed rate,
+// one step at a time. Control banks are the quickest, since rod control leans on
+// them to follow load; shutdown banks go out one after the other, each only once
+// the bank before it is at the top, and come back in the same way in reverse
+// order. A scram skips all of that: the drives let go and the rods fall.
+
+const (
+	CONTROL_BANK_STEP_RATE  = 72.0 // steps per minute
+	GRAY_BANK_STEP_RATE     = 48.0 // steps per minute
+	SHUTDOWN_BANK_STEP_RATE = 64.0 // steps per minute
+)
+
+// Advance moves the bank toward its target by however many whole steps the
+// drive has made in the given seconds.
+func (cb *ControlBank) Advance(seconds float64) {
+	if cb.position == cb.target {
+		cb.travel = 0
+		return
+	}
+
+	cb.travel += cb.stepRate * seconds / 60
+	steps := int(cb.travel + 1e-9) // don't lose a step to rounding
+	cb.travel -= float64(steps)
+
+	if cb.position < cb.target {
+		cb.position = min(cb.position+steps, cb.target)
+	} else {
+		cb.position = max(cb.position-steps, cb.target)
+	}
+}
+
+func (cb *ControlBank) IsMoving() bool {
+	return cb.position != cb.target
+}
+
+// rod bottom light: the bank is all the way in
+func (cb *ControlBank) RodBottom() bool {
+	return cb.IsFullyInserted()
+}
+
+// at-top light: the bank is all the way out
+func (cb *ControlBank) AtTop() bool {
+	return cb.IsFullyWithdrawn()
+}
+
+// Advance runs the rod drives for the given seconds, sequencing the shutdown
+// banks and stepping every bank toward its target.
+func (cr *ControlRods) Advance(seconds float64) {
+	if cr.withdrawShutdownBanks {
+		for _, bank := range cr.shutdownBanks {
+			if !bank.IsFullyWithdrawn() {
+				if bank.Target() == 0 {
+					bank.Withdraw()
+				}
+				break
+			}
+		}
+	} else {
+		for i := len(cr.shutdownBanks) - 1; i >= 0; i-- {
+			bank := cr.shutdownBanks[i]
+			if !bank.IsFullyInserted() {
+				if bank.Target() != 0 {
+					bank.Insert()
+				}
+				break
+			}
+		}
+	}
+
+	for _, bank := range cr.controlBanks {
+		bank.Advance(seconds)
+	}
+	for _, bank := range cr.grayBanks {
+		bank.Advance(seconds)
+	}
+	for _, bank := range cr.shutdownBanks {
+		bank.Advance(seconds)
+	}
+}
+
+// labels of the banks showing rod bottom
+func (cr *ControlRods) RodBottomBanks() []string {
+	labels := []string{}
+	for _, bank := range cr.controlBanks {
+		if bank.RodBottom() {
+			labels = append(labels, bank.Label())
+		}
+	}
+	for _, bank := range cr.grayBanks {
+		if bank.RodBottom() {
+			labels = append(labels, bank.Label())
+		}
+	}
+	for _, bank := range cr.shutdownBanks {
+		if bank.RodBottom() {
+			labels = append(labels, bank.Label())
+		}
+	}
+	return labels
+}
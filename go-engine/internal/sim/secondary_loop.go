@@ -3,35 +3,56 @@ package sim
 import (
 	"fmt"
 	"math"
+
+	"won/sim-lab/go-engine/internal/steam"
 )
 
-const MSSV_PRESSURE_THRESHOLD = 8.0          // in MPa; main steam safety value
-const TARGET_STEAM_TEMPERATURE = 285.0       // in Celsius
-const TARGET_FEEDWATER_TEMPERATURE = 80.0    // in Celsius
-const FEEDWATER_TEMPERATURE_INCREMENT = 10.0 // in Celsius
-const BASE_FEEDWATER_TEMPERATURE = 40.0      // in Celsius
+const TARGET_STEAM_TEMPERATURE = 285.0     // in Celsius; saturated steam at about 6.9 MPa
+const TARGET_FEEDWATER_TEMPERATURE = 227.0 // in Celsius; out of the top heater at rated steam flow
+const BASE_FEEDWATER_TEMPERATURE = 40.0    // in Celsius
+
+const STEAM_HEADER_VOLUME = 600.0                                // m³ of steam in the steam generator domes and the steam lines
+const STEAM_HEADER_TIME_STEP = 1.0                               // seconds
+const MAIN_STEAM_SAFETY_VALVE_CAPACITY = 0.22 * RATED_STEAM_FLOW // kg/s through each valve at its setpoint
+const MAIN_STEAM_SAFETY_VALVE_BLOWDOWN = 0.05                    // fraction below its setpoint a valve reseats at
+const STEAM_LINE_PORV_SETPOINT = 7.6                             // MPa
+const STEAM_LINE_PORV_RESEAT_PRESSURE = 7.4                      // MPa
+const STEAM_LINE_PORV_CAPACITY = 0.1 * RATED_STEAM_FLOW          // kg/s at its setpoint
+
+// lift setpoints of the main steam safety valves, in MPa; staggered, so only
+// as many lift as it takes to hold the pressure down
+var MAIN_STEAM_SAFETY_VALVE_SETPOINTS = []float64{8.0, 8.1, 8.2, 8.3, 8.4}
 
 type SecondaryLoop struct {
 	BaseComponent
 	steamTemperature               float64 // in Celsius
 	steamPressure                  float64 // in MPa
-	mainSteamSafetyValveOpened     bool
-	openPowerOperatedReliefValve   bool
-	powerOperatedReliefValveOpened bool
-	targetSteamPressure            float64 // in MPa
+	mainSteamSafetyValveOpened     bool    // any safety valve lifted during the last tick
+	mainSteamSafetyValvesOpen      []bool  // each safety valve, as it stands
+	mainSteamSafetyValveFlowRate   float64 // kg/s to atmosphere
+	openPowerOperatedReliefValve   bool    // the operator has the relief valve open to bring the pressure down
+	powerOperatedReliefValveOpened bool    // the relief valve as it stands
+	powerOperatedReliefValveFlow   float64 // kg/s to atmosphere
+	targetSteamPressure            float64 // in MPa, the operator is bringing the pressure down to
+	steamInflowRate                float64 // kg/s from the steam generators
+	steamDemandRate                float64 // kg/s taken by the turbine and the steam dump
+	steamSupplyFraction            float64 // share of what the turbine and steam dump asked for that the header gave them last tick
 	feedwaterPumpOn                bool
 	feedwaterFlowRate              float64 // in m³/s
-	feedheatersOn                  bool
+	feedwaterHeaters               []*FeedwaterHeater
 	feedwaterTemperature           float64 // in Celsius; temperature of the feedwater as it enters the steam generator; related to efficiency of the steam generator
 }
 
 func NewSecondaryLoop(name string) *SecondaryLoop {
 	return &SecondaryLoop{
-		BaseComponent:        BaseComponent{Name: name},
-		steamTemperature:     ROOM_TEMPERATURE,
-		steamPressure:        0.0,
-		feedwaterFlowRate:    0.0, // 2 m³/s, 120 per minute
-		feedwaterTemperature: BASE_FEEDWATER_TEMPERATURE,
+		BaseComponent:             BaseComponent{Name: name},
+		steamTemperature:          ROOM_TEMPERATURE,
+		steamPressure:             0.0,
+		feedwaterFlowRate:         0.0, // set by the steam generators' feed valves
+		mainSteamSafetyValvesOpen: make([]bool, len(MAIN_STEAM_SAFETY_VALVE_SETPOINTS)),
+		feedwaterHeaters:          newFeedwaterHeaterTrain(),
+		feedwaterTemperature:      BASE_FEEDWATER_TEMPERATURE,
+		steamSupplyFraction:       1,
 	}
 }
 
@@ -44,46 +65,198 @@ func NewSecondaryLoop(name string) *SecondaryLoop {
 // to make up for evaporation and blowdown.
 // safety valve is needed to prevent explosions due to excessive pressure.
 // Most of the circulation is driven by natural convection.
+//
+// The steam generators all steam into the one header, and its pressure
+// follows what they make against what the turbine, the steam dump and the
+// relief valves take. Steam the header cannot get rid of piles up in the
+// steam space, and the water in the steam generators, saturated at header
+// pressure, soaks up heat as the pressure climbs instead of boiling; when
+// more is taken than made, the water flashes and the pressure falls. The
+// steam leaves saturated, so its temperature goes with the pressure. Nothing
+// can take more steam than the header holds above atmospheric pressure and
+// the steam generators make on top of it; at atmospheric pressure nothing
+// drives steam out at all, and when the header runs short, everything taking
+// from it gets its share of what there is.
+//
+// The power-operated relief valve lifts at its setpoint and reseats a little
+// below it, and the operator can open it to bring the pressure down to a
+// target. It needs station power. Should the pressure keep climbing, the
+// spring-loaded main steam safety valves lift one after another at their
+// staggered setpoints, and each reseats once the pressure has come down by
+// its blowdown.
 
 func (sl *SecondaryLoop) Update(env *Environment, s *Simulation) {
-	// TODO: react to Steam Generator; determine steam temperature and pressure
-	// steam moves at 60 mph during operation
-	if sl.steamTemperature < TARGET_STEAM_TEMPERATURE {
-		sl.steamTemperature += 10.0 // temperature increases some amount TODO: base this on Steam Generator
-		sl.steamPressure += 1.0     // pressure increases accordingly TODO: base this on steam temperature
-	}
-
-	// vent steam when pressure is too high
-	if sl.steamPressure > MSSV_PRESSURE_THRESHOLD {
-		sl.mainSteamSafetyValveOpened = true
-		sl.steamPressure = MSSV_PRESSURE_THRESHOLD - 1.5 // TODO: research how much pressure would drop
-		sl.steamTemperature -= 30.0                      // temperature drops some amount TODO: research how much per event
-	} else {
-		sl.mainSteamSafetyValveOpened = false
-	}
-
-	if env.PowerOn {
-		// TODO: figure out less awkward way to adjust sub-components
-		if sl.openPowerOperatedReliefValve {
-			sl.steamPressure = sl.targetSteamPressure
-			sl.powerOperatedReliefValveOpened = true
-			sl.openPowerOperatedReliefValve = false
-		} else {
-			sl.powerOperatedReliefValveOpened = false
+	if !env.PowerOn {
+		sl.SwitchOffFeedwaterPump()
+		sl.SwitchOffFeedheaters()
+	}
+	sl.updateSteamPressure(env, s)
+
+	// condensate leaves the hotwell and goes up through the heater train
+	feedwaterTemperature := BASE_FEEDWATER_TEMPERATURE
+	if condenser := s.FindCondenser(); condenser != nil {
+		feedwaterTemperature = condenser.exitTemperature
+	}
+	for _, heater := range sl.feedwaterHeaters {
+		heater.heat(feedwaterTemperature, SECONDS_PER_TICK)
+		feedwaterTemperature = heater.outletTemperature
+	}
+	sl.feedwaterTemperature = feedwaterTemperature
+}
+
+// updateSteamPressure balances the steam into the header against the steam
+// out of it over the tick; the steam generators boil at the header pressure
+// of each step, so the primary, the boiling and the pressure move together
+func (sl *SecondaryLoop) updateSteamPressure(env *Environment, s *Simulation) {
+	steamGenerators := s.FindSteamGenerators()
+	waterMass := 0.0
+	for _, steamGenerator := range steamGenerators {
+		waterMass += steamGenerator.waterMass
+	}
+	turbine := s.FindSteamTurbine()
+	condenserAvailable := true
+	if condenser := s.FindCondenser(); condenser != nil {
+		condenserAvailable = condenser.Available()
+	}
+
+	pressure := math.Max(sl.steamPressure, steam.ATMOSPHERIC_PRESSURE)
+	inflow, supply, demand, porv, mssv := 0.0, 0.0, 0.0, 0.0, 0.0
+	steps := int(SECONDS_PER_TICK / STEAM_HEADER_TIME_STEP)
+	for i := 0; i < steps; i++ {
+		made := 0.0
+		for _, steamGenerator := range steamGenerators {
+			made += steamGenerator.boil(s, pressure, STEAM_HEADER_TIME_STEP)
+		}
+		wanted := 0.0
+		if turbine != nil {
+			wanted = turbine.steamDemand(pressure) + turbine.steamDumpDemand(pressure, condenserAvailable)
 		}
+		relieved := sl.reliefValveFlow(pressure, env.PowerOn)
+		safety := sl.safetyValveFlow(pressure)
 
-		// adjust feedwater temperature as needed
-		if sl.feedheatersOn && sl.feedwaterTemperature < TARGET_FEEDWATER_TEMPERATURE {
-			// increase temperature by 10 degree per minute until target is reached
-			sl.feedwaterTemperature += math.Min(TARGET_FEEDWATER_TEMPERATURE-sl.feedwaterTemperature, FEEDWATER_TEMPERATURE_INCREMENT)
-		} else if !sl.feedheatersOn && sl.feedwaterTemperature > BASE_FEEDWATER_TEMPERATURE {
-			// decrease temperature by 10 degree per minute until base is reached
-			sl.feedwaterTemperature -= math.Min(sl.feedwaterTemperature-BASE_FEEDWATER_TEMPERATURE, FEEDWATER_TEMPERATURE_INCREMENT)
+		// what the header holds above atmospheric, plus what comes in, is all
+		// there is to take
+		capacitance := steamHeaderCapacitance(pressure, waterMass)
+		available := 0.0
+		if pressure > steam.ATMOSPHERIC_PRESSURE {
+			available = (pressure-steam.ATMOSPHERIC_PRESSURE)*capacitance/STEAM_HEADER_TIME_STEP + made
 		}
-	} else {
-		sl.SwitchOffFeedwaterPump()
-		sl.SwitchOffFeedheaters()
+		supplied := 0.0
+		if available > 0 {
+			supplied = 1
+			if total := wanted + relieved + safety; total > available {
+				supplied = available / total
+			}
+		}
+		taken := wanted * supplied
+		relieved *= supplied
+		safety *= supplied
+
+		pressure += (made - taken - relieved - safety) / capacitance * STEAM_HEADER_TIME_STEP
+		pressure = math.Max(pressure, steam.ATMOSPHERIC_PRESSURE)
+
+		inflow += made
+		supply += supplied
+		demand += taken
+		porv += relieved
+		mssv += safety
+	}
+	sl.steamPressure = pressure
+	sl.steamTemperature = sl.saturationTemperature()
+	for _, steamGenerator := range steamGenerators {
+		steamGenerator.finishBoiling()
+	}
+	sl.steamInflowRate = inflow / float64(steps)
+	sl.steamDemandRate = demand / float64(steps)
+	sl.steamSupplyFraction = supply / float64(steps)
+	sl.powerOperatedReliefValveFlow = porv / float64(steps)
+	sl.mainSteamSafetyValveFlowRate = mssv / float64(steps)
+	sl.mainSteamSafetyValveOpened = sl.mainSteamSafetyValveFlowRate > 0
+
+	// report what the pumps are delivering to the feed header
+	sl.feedwaterFlowRate = 0
+	if sl.feedwaterPumpOn {
+		header := 0.0
+		for _, steamGenerator := range steamGenerators {
+			header += steamGenerator.feedwaterFlowRate
+		}
+		sl.feedwaterFlowRate = header / sl.FeedwaterDensity() // Convert kg/s to m³/s
+	}
+}
+
+// reliefValveFlow lifts or reseats the power-operated relief valve for the
+// given pressure and gives the steam going through it, in kg/s
+func (sl *SecondaryLoop) reliefValveFlow(pressure float64, powerOn bool) float64 {
+	if sl.openPowerOperatedReliefValve && pressure <= sl.targetSteamPressure {
+		sl.openPowerOperatedReliefValve = false
 	}
+	switch {
+	case !powerOn:
+		sl.powerOperatedReliefValveOpened = false
+	case sl.openPowerOperatedReliefValve || pressure >= STEAM_LINE_PORV_SETPOINT:
+		sl.powerOperatedReliefValveOpened = true
+	case pressure <= STEAM_LINE_PORV_RESEAT_PRESSURE:
+		sl.powerOperatedReliefValveOpened = false
+	}
+	if !sl.powerOperatedReliefValveOpened {
+		return 0
+	}
+	// choked, so the flow goes with the pressure
+	return STEAM_LINE_PORV_CAPACITY * pressure / STEAM_LINE_PORV_SETPOINT
+}
+
+// safetyValveFlow lifts or reseats each main steam safety valve for the given
+// pressure and gives the steam going through them, in kg/s
+func (sl *SecondaryLoop) safetyValveFlow(pressure float64) float64 {
+	flow := 0.0
+	for i, setpoint := range MAIN_STEAM_SAFETY_VALVE_SETPOINTS {
+		if pressure >= setpoint {
+			sl.mainSteamSafetyValvesOpen[i] = true
+		} else if pressure <= setpoint*(1-MAIN_STEAM_SAFETY_VALVE_BLOWDOWN) {
+			sl.mainSteamSafetyValvesOpen[i] = false
+		}
+		if sl.mainSteamSafetyValvesOpen[i] {
+			flow += MAIN_STEAM_SAFETY_VALVE_CAPACITY * pressure / setpoint
+		}
+	}
+	return flow
+}
+
+// steamHeaderCapacitance is the kg of steam it takes to raise the header
+// pressure by a MPa: the steam space holds more steam, and the water in the
+// steam generators takes up heat that would otherwise have boiled it
+func steamHeaderCapacitance(pressure float64, waterMass float64) float64 {
+	const dp = 0.01
+	low := math.Max(steam.ATMOSPHERIC_PRESSURE, pressure-dp/2)
+	high := low + dp
+	vapor := STEAM_HEADER_VOLUME * (steam.SaturatedVapor(high).Density - steam.SaturatedVapor(low).Density) / dp
+	sensible := waterMass * (steam.SaturatedLiquid(high).Enthalpy - steam.SaturatedLiquid(low).Enthalpy) / dp
+	latent := steam.SaturatedVapor(pressure).Enthalpy - steam.SaturatedLiquid(pressure).Enthalpy
+	return vapor + sensible/latent
+}
+
+func (sl *SecondaryLoop) saturationTemperature() float64 {
+	return steam.SaturationTemperature(math.Max(sl.steamPressure, steam.ATMOSPHERIC_PRESSURE))
+}
+
+// MPa
+func (sl *SecondaryLoop) SteamPressure() float64 {
+	return sl.steamPressure
+}
+
+// Celsius
+func (sl *SecondaryLoop) SteamTemperature() float64 {
+	return sl.steamTemperature
+}
+
+// Celsius
+func (sl *SecondaryLoop) FeedwaterTemperature() float64 {
+	return sl.feedwaterTemperature
+}
+
+// kg/m³, at steam line pressure
+func (sl *SecondaryLoop) FeedwaterDensity() float64 {
+	return steam.Density(math.Max(sl.steamPressure, steam.ATMOSPHERIC_PRESSURE), sl.feedwaterTemperature)
 }
 
 func (sl *SecondaryLoop) FeedwaterVolume() float64 {
@@ -97,22 +270,82 @@ func (sl *SecondaryLoop) TargetFeedwaterTemperature() float64 {
 	return TARGET_FEEDWATER_TEMPERATURE
 }
 
+// the heaters from the hotwell up to the steam generators
+func (sl *SecondaryLoop) FeedwaterHeaters() []*FeedwaterHeater {
+	return sl.feedwaterHeaters
+}
+
+// the heater with the given number, 1 and up from the hotwell; nil if there is none
+func (sl *SecondaryLoop) FeedwaterHeater(number int) *FeedwaterHeater {
+	if number < 1 || number > len(sl.feedwaterHeaters) {
+		return nil
+	}
+	return sl.feedwaterHeaters[number-1]
+}
+
+// whether every heater in the train is in service
+func (sl *SecondaryLoop) FeedheatersOn() bool {
+	for _, heater := range sl.feedwaterHeaters {
+		if !heater.inService {
+			return false
+		}
+	}
+	return true
+}
+
+// OpenPowerOperatedReliefValue opens the relief valve until the steam
+// pressure comes down to the target
 func (sl *SecondaryLoop) OpenPowerOperatedReliefValue(targetPressure float64) {
+	if targetPressure <= 0 || targetPressure >= STEAM_LINE_PORV_SETPOINT {
+		fmt.Printf("Target pressure must be between 0 and %.1f MPa. You requested %f.\n", STEAM_LINE_PORV_SETPOINT, targetPressure)
+		return
+	}
 	sl.openPowerOperatedReliefValve = true
 	sl.targetSteamPressure = targetPressure
 }
 
+// ClosePowerOperatedReliefValve takes back an operator's opening of the relief valve; it still
+// lifts on its own at its setpoint
+func (sl *SecondaryLoop) ClosePowerOperatedReliefValve() {
+	sl.openPowerOperatedReliefValve = false
+}
+
+func (sl *SecondaryLoop) PowerOperatedReliefValveOpen() bool {
+	return sl.powerOperatedReliefValveOpened
+}
+
+// kg/s from the steam generators
+func (sl *SecondaryLoop) SteamInflowRate() float64 {
+	return sl.steamInflowRate
+}
+
+// kg/s relieved to atmosphere through the relief and safety valves
+func (sl *SecondaryLoop) SteamReliefFlowRate() float64 {
+	return sl.powerOperatedReliefValveFlow + sl.mainSteamSafetyValveFlowRate
+}
+
 func (sl *SecondaryLoop) Status() map[string]interface{} {
+	heaters := make([]map[string]interface{}, len(sl.feedwaterHeaters))
+	for i, heater := range sl.feedwaterHeaters {
+		heaters[i] = heater.Status()
+	}
 	return map[string]interface{}{
 		"name":                       sl.Name,
 		"steamTemperature":           sl.steamTemperature,
 		"steamPressure":              sl.steamPressure,
 		"mainSteamSafetyValveOpened": sl.mainSteamSafetyValveOpened,
+		"mainSteamSafetyValvesOpen":  sl.mainSteamSafetyValvesOpen,
+		"mainSteamSafetyValveFlow":   sl.mainSteamSafetyValveFlowRate,
+		"porvOpen":                   sl.powerOperatedReliefValveOpened,
+		"porvFlow":                   sl.powerOperatedReliefValveFlow,
+		"steamInflowRate":            sl.steamInflowRate,
+		"steamDemandRate":            sl.steamDemandRate,
 		"feedwaterTemperature":       sl.feedwaterTemperature,
 		"feedwaterPumpOn":            sl.feedwaterPumpOn,
 		"feedwaterFlowRate":          sl.feedwaterFlowRate,
 		"feedwaterVolume":            sl.FeedwaterVolume(),
-		"feedwaterHeatersOn":         sl.feedheatersOn,
+		"feedwaterHeatersOn":         sl.FeedheatersOn(),
+		"feedwaterHeaters":           heaters,
 	}
 }
 
@@ -120,12 +353,16 @@ func (sl *SecondaryLoop) PrintStatus() {
 	fmt.Printf("Secondary Loop: %s\n", sl.Name)
 	fmt.Printf("\tSteam Temperature: %.2f °C\n", sl.steamTemperature)
 	fmt.Printf("\tSteam Pressure: %.2f MPa\n", sl.steamPressure)
-	fmt.Printf("\tMain Steam Safety Valve Released: %t\n", sl.mainSteamSafetyValveOpened)
+	fmt.Printf("\tSteam In: %.1f kg/s, Taken: %.1f kg/s\n", sl.steamInflowRate, sl.steamDemandRate)
+	fmt.Printf("\tMain Steam Safety Valve Released: %t (%.1f kg/s)\n", sl.mainSteamSafetyValveOpened, sl.mainSteamSafetyValveFlowRate)
+	fmt.Printf("\tPower-Operated Relief Valve: %t (%.1f kg/s)\n", sl.powerOperatedReliefValveOpened, sl.powerOperatedReliefValveFlow)
 	fmt.Printf("\tFeedwater Temperature: %.2f °C\n", sl.feedwaterTemperature)
 	fmt.Printf("\tFeedwater Pump: %s\n", boolToString(sl.feedwaterPumpOn))
 	fmt.Printf("\tFeedwater Flow Rate: %.2f m³/s\n", sl.feedwaterFlowRate)
 	fmt.Printf("\tFeedwater Volume: %.2f m³/min\n", sl.FeedwaterVolume())
-	fmt.Printf("\tFeedwater Heaters: %s\n", boolToString(sl.feedheatersOn))
+	for _, heater := range sl.feedwaterHeaters {
+		heater.PrintStatus()
+	}
 }
 
 func boolToString(b bool) string {
@@ -141,7 +378,6 @@ func (sl *SecondaryLoop) EmergencyMSSVReleased() bool {
 
 func (sl *SecondaryLoop) SwitchOnFeedwaterPump() {
 	sl.feedwaterPumpOn = true
-	sl.feedwaterFlowRate = 2.0 // Reset to default flow rate when switched on
 }
 
 func (sl *SecondaryLoop) SwitchOffFeedwaterPump() {
@@ -149,30 +385,27 @@ func (sl *SecondaryLoop) SwitchOffFeedwaterPump() {
 	sl.feedwaterFlowRate = 0.0 // No flow when pump is off
 }
 
-func (sl *SecondaryLoop) AdjustFeedwaterFlowRate(rate float64) {
-	if !sl.feedwaterPumpOn {
-		fmt.Println("Cannot adjust flow rate. Feedwater pump is off.")
-		return
-	}
-	if rate < 0 {
-		fmt.Println("Flow rate cannot be negative. Setting to 0.")
-		sl.feedwaterFlowRate = 0
-	} else {
-		sl.feedwaterFlowRate = rate
+// SwitchOnFeedheaters puts every heater in the train in service
+func (sl *SecondaryLoop) SwitchOnFeedheaters() {
+	for _, heater := range sl.feedwaterHeaters {
+		heater.ReturnToService()
 	}
 }
 
-func (sl *SecondaryLoop) SwitchOnFeedheaters() {
-	sl.feedheatersOn = true
-	// Optionally, we could add some logic here to gradually increase the temperature
-	// of the feedwater over time, simulating the heating process.
-	// For example:
-	// sl.returnWaterTemperature += 10 // Increase temperature by 10 degrees
-	// This would depend on how often this method is called and how we want to model the heating process.
+// SwitchOffFeedheaters bypasses every heater in the train
+func (sl *SecondaryLoop) SwitchOffFeedheaters() {
+	for _, heater := range sl.feedwaterHeaters {
+		heater.TakeOutOfService()
+	}
 }
 
-func (sl *SecondaryLoop) SwitchOffFeedheaters() {
-	sl.feedheatersOn = false
-	// Similarly, we could add logic here to gradually decrease the temperature
-	// of the feedwater over time, simulating the cooling process when heaters are off.
+func (sl *SecondaryLoop) AlarmConditions() []*AlarmCondition {
+	return []*AlarmCondition{
+		NewAlarmCondition("mainSteamSafetyValveOpen", "MAIN STEAM SAFETY VALVE OPEN", ALARM_PRIORITY_HIGH, func() bool {
+			return sl.mainSteamSafetyValveOpened
+		}),
+		NewAlarmCondition("steamLineReliefValveOpen", "STEAM LINE PORV OPEN", ALARM_PRIORITY_MEDIUM, func() bool {
+			return sl.powerOperatedReliefValveOpened
+		}),
+	}
 }
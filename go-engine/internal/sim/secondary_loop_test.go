@@ -2,6 +2,8 @@ package sim
 
 import (
 	"testing"
+
+	"won/sim-lab/go-engine/internal/steam"
 )
 
 // setupSimulationEnvironment creates and returns a new Simulation and Environment
@@ -20,8 +22,8 @@ func TestSecondaryLoopInitialConditions(t *testing.T) {
 		t.Errorf("Feedwater pump should be off initially, got %t", sl.feedwaterPumpOn)
 	}
 
-	if sl.feedheatersOn {
-		t.Errorf("Feedheaters should be off initially, got %t", sl.feedheatersOn)
+	if sl.FeedheatersOn() {
+		t.Errorf("Feedheaters should be off initially, got %t", sl.FeedheatersOn())
 	}
 
 	sl.SwitchOnFeedwaterPump()
@@ -50,17 +52,23 @@ func TestSecondaryLoopFeedwaterPump(t *testing.T) {
 		t.Errorf("Initial feedwater volume should be 0, got %f", sl.FeedwaterVolume())
 	}
 
-	// Turn the pump on
+	// with no steam generator to feed, the pump delivers nothing
 	sl.SwitchOnFeedwaterPump()
 	sl.Update(env, sim)
+	if sl.FeedwaterVolume() != 0 {
+		t.Errorf("Feedwater volume should stay 0 with no steam generator, got %f", sl.FeedwaterVolume())
+	}
 
-	// Check that FeedwaterVolume is > 0 after pump is turned on
-	if sl.FeedwaterVolume() <= 0 {
-		t.Errorf("Feedwater volume should be greater than 0 when pump is on, got %f", sl.FeedwaterVolume())
+	// the header carries what the steam generators' feed valves let through
+	sim, env = setUpSteamCycle()
+	sl = sim.FindSecondaryLoop()
+	fed := sim.FindSteamGenerator().FeedwaterFlowRate() / sl.FeedwaterDensity() * 60
+	if fed <= 0 || !almostEqual(sl.FeedwaterVolume(), fed, 1e-3*fed) {
+		t.Errorf("Feedwater volume should match the feed valve's %f m³/min, got %f", fed, sl.FeedwaterVolume())
 	}
 
 	sl.SwitchOffFeedwaterPump()
-	sl.Update(env, sim)
+	updateSteamCycle(sim, env)
 
 	// Check that FeedwaterVolume returns to 0
 	if sl.FeedwaterVolume() != 0 {
@@ -75,53 +83,99 @@ func TestSecondaryLoopFeedheaters(t *testing.T) {
 
 	// Turn on the pump and leave it on for the whole test
 	sl.SwitchOnFeedwaterPump()
-	sl.Update(env, sim)
-
-	// Check initial feedwater temperature
-	initialTemp := sl.feedwaterTemperature
-	if initialTemp != BASE_FEEDWATER_TEMPERATURE {
-		t.Errorf("Initial feedwater temperature should be %f, got %f", BASE_FEEDWATER_TEMPERATURE, initialTemp)
-	}
-
-	// Turn on feedheaters
 	sl.SwitchOnFeedheaters()
-
-	// Run update multiple times to allow temperature to increase
 	sl.Update(env, sim)
 
-	// Check that feedwater temperature has increased
-	if sl.feedwaterTemperature == initialTemp {
-		t.Errorf("Feedwater temperature should have increased. Got %f, expected > %f", sl.feedwaterTemperature, initialTemp)
-	}
-
-	// Run update multiple times to allow temperature to increase
-	for i := 0; i < 30; i++ {
-		sl.Update(env, sim)
+	// with no turbine to bleed steam from, the heaters have nothing to heat with
+	if sl.feedwaterTemperature != BASE_FEEDWATER_TEMPERATURE {
+		t.Errorf("Feedwater temperature should stay at %f without extraction steam, got %f", BASE_FEEDWATER_TEMPERATURE, sl.feedwaterTemperature)
 	}
 
-	// Check that feedwater temperature is at or approaching target temperature
+	// with the turbine at rated load, the heaters bring the feedwater up to target
+	sim, env = setUpSteamCycle()
+	sl = sim.FindSecondaryLoop()
 	targetTemp := sl.TargetFeedwaterTemperature()
-	if sl.feedwaterTemperature < targetTemp {
-		t.Errorf("Feedwater temperature have reached target temperature. Got %f, expected close to %f", sl.feedwaterTemperature, targetTemp)
+	if !almostEqual(sl.feedwaterTemperature, targetTemp, 3) {
+		t.Errorf("Feedwater temperature should have reached target temperature. Got %f, expected close to %f", sl.feedwaterTemperature, targetTemp)
 	}
 
 	// Turn off feedheaters
 	sl.SwitchOffFeedheaters()
 	sl.Update(env, sim)
 
-	// Check that feedwater temperature has decreased
-	cooledTemp := sl.feedwaterTemperature
-	if cooledTemp >= targetTemp {
-		t.Errorf("Feedwater temperature should have dropped a little. Got %f, expected < %f", cooledTemp, targetTemp)
+	// bypassed, the feedwater comes straight from the hotwell
+	condensateTemp := sim.FindCondenser().exitTemperature
+	if sl.feedwaterTemperature != condensateTemp {
+		t.Errorf("Feedwater temperature should drop to the hotwell's. Got %f, expected %f", sl.feedwaterTemperature, condensateTemp)
 	}
+}
 
-	// Run update multiple times to allow temperature to decrease
-	for i := 0; i < 30; i++ {
-		sl.Update(env, sim)
+func TestSafetyValvesLiftAndReseat(t *testing.T) {
+	simulation, env := setUpSteamCycle()
+	sl := simulation.FindSecondaryLoop()
+	turbine := simulation.FindSteamTurbine()
+
+	// a trip with nowhere to dump the steam
+	turbine.Trip()
+	turbine.DisarmSteamDump()
+	updateSteamCycle(simulation, env)
+	if !sl.PowerOperatedReliefValveOpen() || !sl.EmergencyMSSVReleased() {
+		t.Errorf("Expected the relief and safety valves to lift at %f MPa", sl.SteamPressure())
+	}
+	// the valves hold the pressure down, lifting only as many as it takes
+	top := MAIN_STEAM_SAFETY_VALVE_SETPOINTS[len(MAIN_STEAM_SAFETY_VALVE_SETPOINTS)-1]
+	if sl.SteamPressure() < MAIN_STEAM_SAFETY_VALVE_SETPOINTS[0] || sl.SteamPressure() > top {
+		t.Errorf("Expected the safety valves to hold the pressure between %f and %f MPa, got %f", MAIN_STEAM_SAFETY_VALVE_SETPOINTS[0], top, sl.SteamPressure())
+	}
+	if sl.mainSteamSafetyValvesOpen[len(sl.mainSteamSafetyValvesOpen)-1] {
+		t.Errorf("Expected the last safety valve to stay seated, got %v", sl.mainSteamSafetyValvesOpen)
+	}
+	if !almostEqual(sl.SteamReliefFlowRate(), sl.SteamInflowRate(), 0.2*sl.SteamInflowRate()) {
+		t.Errorf("Expected the valves to relieve about the %f kg/s coming in, got %f", sl.SteamInflowRate(), sl.SteamReliefFlowRate())
 	}
 
-	// Check that feedwater temperature is approaching base temperature
-	if sl.feedwaterTemperature > BASE_FEEDWATER_TEMPERATURE {
-		t.Errorf("Feedwater temperature should be at base temperature. Got %f, expected close to %f", sl.feedwaterTemperature, BASE_FEEDWATER_TEMPERATURE)
+	// with the reactor down to decay heat, the valves reseat below their setpoints
+	simulation.FindReactorCore().heatEnergyRate = 0.05 * RATED_THERMAL_POWER
+	for i := 0; i < 3; i++ {
+		updateSteamCycle(simulation, env)
+	}
+	for i, open := range sl.mainSteamSafetyValvesOpen {
+		if open {
+			t.Errorf("Expected safety valve %d to reseat at %f MPa", i+1, sl.SteamPressure())
+		}
+	}
+	if sl.SteamPressure() > STEAM_LINE_PORV_SETPOINT+0.1 {
+		t.Errorf("Expected the relief valve to hold the pressure near %f MPa, got %f", STEAM_LINE_PORV_SETPOINT, sl.SteamPressure())
+	}
+
+	// the operator can bring the pressure down with the relief valve
+	// once it has cooled the primary down with it
+	sl.OpenPowerOperatedReliefValue(6.0)
+	for i := 0; i < 5; i++ {
+		updateSteamCycle(simulation, env)
+	}
+	if sl.SteamPressure() > 6.5 {
+		t.Errorf("Expected the relief valve to bring the pressure down toward 6 MPa, got %f", sl.SteamPressure())
+	}
+}
+
+func TestSteamPressureFallsWhenTheTurbineTakesMore(t *testing.T) {
+	simulation, env := setUpSteamCycle()
+	sl := simulation.FindSecondaryLoop()
+	turbine := simulation.FindSteamTurbine()
+
+	// half the heat, with the turbine still asking for all of its steam
+	simulation.FindReactorCore().heatEnergyRate = 0.5 * RATED_THERMAL_POWER
+	for i := 0; i < 5; i++ {
+		updateSteamCycle(simulation, env)
+	}
+	if sl.SteamPressure() > 0.6*RATED_STEAM_PRESSURE {
+		t.Errorf("Expected the header pressure to fall off, got %f MPa", sl.SteamPressure())
+	}
+	if !almostEqual(turbine.Load(), sl.SteamInflowRate()/RATED_STEAM_FLOW*100, 2) {
+		t.Errorf("Expected the turbine to take what the steam generator makes, %f kg/s, got %f%% load", sl.SteamInflowRate(), turbine.Load())
+	}
+	if !almostEqual(sl.SteamTemperature(), steam.SaturationTemperature(sl.SteamPressure()), 0.01) {
+		t.Errorf("Expected the steam saturated at %f MPa, got %f °C", sl.SteamPressure(), sl.SteamTemperature())
 	}
 }
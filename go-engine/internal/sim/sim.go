@@ -25,14 +25,16 @@ type Simulation struct {
 	components  []Component
 	clock       Clock
 	environment Environment
+	climate     *Climate
 	running     bool
 	verbose     bool
 	stopChan    chan struct{}
 	history     []map[string]interface{} // New field to store history
+	annunciator *Annunciator
 }
 
 func NewSimulation(name string, motto string) *Simulation {
-	return &Simulation{
+	s := &Simulation{
 		info: SimInfo{
 			ID:        fmt.Sprintf("sim-%s", generateRandomID(8)),
 			Name:      name,
@@ -43,13 +45,14 @@ func NewSimulation(name string, motto string) *Simulation {
 			startedAt:   time.Date(2000, 1, 1, 8, 0, 0, 0, time.FixedZone("EST", -5*60*60)),
 			currentIter: 0,
 		},
-		environment: Environment{
-			Weather: "Sunny", // Initialize with a default weather
-			PowerOn: true,
-		},
-		components: make([]Component, 0),
-		stopChan:   make(chan struct{}),
+		environment: *NewEnvironment(),
+		climate:     NewClimate(),
+		components:  make([]Component, 0),
+		stopChan:    make(chan struct{}),
+		annunciator: NewAnnunciator(),
 	}
+	s.updateEnvironment()
+	return s
 }
 
 type SimInfo struct {
@@ -61,6 +64,9 @@ type SimInfo struct {
 
 func (s *Simulation) AddComponent(p Component) {
 	s.components = append(s.components, p)
+	if source, ok := p.(AlarmSource); ok {
+		s.annunciator.Register(source.AlarmConditions()...)
+	}
 }
 
 func (s *Simulation) ID() string {
@@ -75,6 +81,10 @@ func (s *Simulation) Components() []Component {
 	return s.components
 }
 
+func (s *Simulation) Annunciator() *Annunciator {
+	return s.annunciator
+}
+
 func (s *Simulation) SetVerboseLogging(verbose bool) {
 	s.verbose = verbose
 }
@@ -88,6 +98,17 @@ func (s *Simulation) FindPrimaryLoop() *PrimaryLoop {
 	return nil
 }
 
+// every coolant loop, in the order they were added
+func (s *Simulation) FindPrimaryLoops() []*PrimaryLoop {
+	primaryLoops := make([]*PrimaryLoop, 0)
+	for _, component := range s.components {
+		if primaryLoop, ok := component.(*PrimaryLoop); ok {
+			primaryLoops = append(primaryLoops, primaryLoop)
+		}
+	}
+	return primaryLoops
+}
+
 func (s *Simulation) FindReactorCore() *ReactorCore {
 	for _, component := range s.components {
 		if reactorCore, ok := component.(*ReactorCore); ok {
@@ -115,6 +136,16 @@ func (s *Simulation) FindSteamGenerator() *SteamGenerator {
 	return nil
 }
 
+func (s *Simulation) FindSteamGenerators() []*SteamGenerator {
+	steamGenerators := make([]*SteamGenerator, 0)
+	for _, component := range s.components {
+		if steamGenerator, ok := component.(*SteamGenerator); ok {
+			steamGenerators = append(steamGenerators, steamGenerator)
+		}
+	}
+	return steamGenerators
+}
+
 func (s *Simulation) FindSecondaryLoop() *SecondaryLoop {
 	for _, component := range s.components {
 		if secondaryLoop, ok := component.(*SecondaryLoop); ok {
@@ -142,6 +173,15 @@ func (s *Simulation) FindCondenser() *Condenser {
 	return nil
 }
 
+func (s *Simulation) FindTertiaryLoop() *TertiaryLoop {
+	for _, component := range s.components {
+		if tertiaryLoop, ok := component.(*TertiaryLoop); ok {
+			return tertiaryLoop
+		}
+	}
+	return nil
+}
+
 func (s *Simulation) FindGenerator() *Generator {
 	for _, component := range s.components {
 		if generator, ok := component.(*Generator); ok {
@@ -151,23 +191,57 @@ func (s *Simulation) FindGenerator() *Generator {
 	return nil
 }
 
+func (s *Simulation) FindReactorProtection() *ReactorProtection {
+	for _, component := range s.components {
+		if reactorProtection, ok := component.(*ReactorProtection); ok {
+			return reactorProtection
+		}
+	}
+	return nil
+}
+
 func (s *Simulation) updateEnvironment() {
-	weathers := []string{"Sunny", "Cloudy", "Rainy", "Windy"}
-	s.environment.Weather = weathers[s.clock.currentIter%len(weathers)]
+	s.climate.apply(&s.environment, s.clock.SimTime())
+}
+
+func (s *Simulation) Climate() *Climate {
+	return s.climate
+}
+
+// UseWeatherFile has the climate follow the readings in the weather file at
+// the given path
+func (s *Simulation) UseWeatherFile(filePath string) error {
+	records, err := LoadWeatherFile(filePath)
+	if err != nil {
+		return err
+	}
+	s.UseWeatherRecords(records)
+	return nil
+}
+
+// UseWeatherRecords has the climate follow the given readings from now on
+func (s *Simulation) UseWeatherRecords(records []WeatherRecord) {
+	s.climate.UseWeatherRecords(records)
+	s.updateEnvironment()
 }
 
 func (s *Simulation) Status() map[string]interface{} {
 	status := map[string]interface{}{
-		"id":              s.info.ID,
-		"name":            s.info.Name,
-		"motto":           s.info.Motto,
-		"spawned_at":      s.info.SpawnedAt,
-		"simTime":         s.clock.SimTime(),
-		"iterationNumber": s.clock.currentIter,
-		"running":         s.running,
-		"powerOn":         s.environment.PowerOn,
-		"weather":         s.environment.Weather,
-		"components":      make([]map[string]interface{}, 0),
+		"id":                 s.info.ID,
+		"name":               s.info.Name,
+		"motto":              s.info.Motto,
+		"spawned_at":         s.info.SpawnedAt,
+		"simTime":            s.clock.SimTime(),
+		"iterationNumber":    s.clock.currentIter,
+		"running":            s.running,
+		"powerOn":            s.environment.PowerOn,
+		"weather":            s.environment.Weather,
+		"ambientTemperature": s.environment.AmbientTemperature,
+		"relativeHumidity":   s.environment.RelativeHumidity,
+		"windSpeed":          s.environment.WindSpeed,
+		"climate":            s.climate.Status(),
+		"components":         make([]map[string]interface{}, 0),
+		"alarms":             s.annunciator.Status(),
 	}
 	for _, component := range s.components {
 		componentStatus := component.Status()
@@ -186,7 +260,8 @@ func (s *Simulation) PrintStatus() {
 	fmt.Printf("Is running: %t\n", s.running)
 	fmt.Printf("Power On: %t\n", s.environment.PowerOn)
 	fmt.Printf("Last iteration %d\n", s.clock.currentIter)
-	fmt.Printf("Weather: %s\n\n", s.environment.Weather)
+	fmt.Printf("Weather: %s\n", s.environment.Weather)
+	fmt.Printf("Ambient: %.1f °C, %.0f%% humidity, wind %.1f m/s\n\n", s.environment.AmbientTemperature, s.environment.RelativeHumidity, s.environment.WindSpeed)
 	for _, component := range s.components {
 		component.PrintStatus()
 	}
@@ -225,6 +300,7 @@ func (s *Simulation) Run(ticks int) {
 			for _, component := range s.components {
 				component.Update(&s.environment, s)
 			}
+			s.annunciator.Scan(s.clock.SimTime())
 			s.logCurrentState() // Log the current state
 		}
 	}
@@ -3,8 +3,33 @@ package sim
 import (
 	"fmt"
 	"math"
+
+	"won/sim-lab/go-engine/internal/steam"
 )
 
+// The secondary side of the steam generator holds a pool of saturated water
+// over the tube bundle. Feedwater comes in subcooled and has to be brought up
+// to boiling before any of the heat makes steam, so the steam made is what is
+// left of the heat after warming the feed, divided by the latent heat.
+//
+// Narrow range level is read from the mixture, not the water alone. Steam
+// bubbles rising through the bundle take up room, so the level swells when
+// steaming picks up or pressure falls, and shrinks when cold feed comes in
+// and knocks the boiling back. The inventory only changes with feed, steam
+// and blowdown, but for a while the level moves the wrong way.
+//
+// The tubes take heat out of the primary in proportion to how much hotter the
+// coolant is than the water boiling around them, at the saturation temperature
+// for the steam line pressure. Coolant temperature, steaming and pressure all
+// settle within seconds of one another, so the boiling, and the feedwater
+// control that follows it, is stepped a second at a time along with the
+// steam header. Once the water drops below the top of the bundle, the dry
+// tubes stop taking heat out of the primary.
+//
+// In a multi-loop plant each steam generator sits on its own coolant loop and
+// has its own feedwater regulating valve and isolation valve off the common
+// feed header, and all of them steam into one header to the turbine.
+
 type SteamGenerator struct {
 	BaseComponent
 	primaryInletTemp    float64 // Temperature of water coming from reactor core (°C)
@@ -13,46 +38,196 @@ type SteamGenerator struct {
 	secondaryOutletTemp float64 // Temperature of steam to secondary loop (°C)
 	heatTransferRate    float64 // Rate of heat transfer from primary to secondary loop (MW)
 	steamFlowRate       float64 // Rate of steam production (kg/s)
+	heatTaken           float64 // MJ taken from the primary so far this tick
+	steamMade           float64 // kg boiled off so far this tick
+	feedTaken           float64 // kg of feed so far this tick
+	blownDown           float64 // kg of blowdown so far this tick
+	waterMass           float64 // kg of water on the secondary side
+	voidVolume          float64 // m³ of steam bubbles in the mixture
+	level               float64 // narrow range level on the secondary side, in percent
+	feedwaterFlowRate   float64 // kg/s
+	blowdownFlowRate    float64 // kg/s
+	feedwaterControl    *FeedwaterControl
+	feedwaterIsolated   bool
+	primaryLoop         *PrimaryLoop
 }
 
+const NORMAL_STEAM_GENERATOR_LEVEL = 50.0        // percent, narrow range
+const STEAM_GENERATOR_BUNDLE_VOLUME = 150.0      // m³ of secondary side below the narrow range span
+const STEAM_GENERATOR_NARROW_RANGE_VOLUME = 80.0 // m³ across the narrow range span
+const RISER_RESIDENCE_TIME = 0.5                 // seconds a bubble spends in the mixture
+const STEAM_GENERATOR_LOW_LEVEL_ALARM = 30.0     // percent, narrow range
+const STEAM_GENERATOR_LOW_LOW_LEVEL = 17.0       // percent, narrow range; trips the reactor
+const STEAM_GENERATOR_HIGH_LEVEL_ALARM = 75.0    // percent, narrow range
+
+// UA of the tube bundles, every steam generator in the plant together, in
+// MW/°C; a full core's heat goes across on about 22 °C between Tavg and the
+// boiling water at rated steam pressure
+const STEAM_GENERATOR_HEAT_TRANSFER_COEFFICIENT = 137.0
+
 func NewSteamGenerator(name string) *SteamGenerator {
-	return &SteamGenerator{
+	sg := &SteamGenerator{
 		BaseComponent:       BaseComponent{Name: name},
 		primaryInletTemp:    320.0, // Initial values, can be adjusted as needed
 		primaryOutletTemp:   280.0,
 		secondaryInletTemp:  220.0,
 		secondaryOutletTemp: 280.0,
-		heatTransferRate:    1000.0, // 1000 MW, for example
-		steamFlowRate:       500.0,  // 500 kg/s, for example
+		heatTransferRate:    0.0,
+		steamFlowRate:       0.0,
+		level:               NORMAL_STEAM_GENERATOR_LEVEL,
+		feedwaterControl:    NewFeedwaterControl(),
+	}
+	// filled cold to the normal level
+	sg.waterMass = sg.levelVolume(NORMAL_STEAM_GENERATOR_LEVEL) * steam.Density(steam.ATMOSPHERIC_PRESSURE, ROOM_TEMPERATURE)
+	return sg
+}
+
+func (sg *SteamGenerator) ConnectToPrimaryLoop(loop *PrimaryLoop) {
+	sg.primaryLoop = loop
+}
+
+// the loop this steam generator cools; a single-loop plant does not need to say
+func (sg *SteamGenerator) loop(s *Simulation) *PrimaryLoop {
+	if sg.primaryLoop != nil {
+		return sg.primaryLoop
+	}
+	return s.FindPrimaryLoop()
+}
+
+// m³ of mixture that reads the given narrow range level
+func (sg *SteamGenerator) levelVolume(level float64) float64 {
+	return STEAM_GENERATOR_BUNDLE_VOLUME + STEAM_GENERATOR_NARROW_RANGE_VOLUME*level/100
+}
+
+// in MW/°C; only the wetted part of the bundle takes heat
+func (sg *SteamGenerator) heatTransferCoefficient(s *Simulation, water steam.State) float64 {
+	wetted := math.Min(1, sg.waterMass/water.Density/STEAM_GENERATOR_BUNDLE_VOLUME)
+	return STEAM_GENERATOR_HEAT_TRANSFER_COEFFICIENT / float64(max(1, len(s.FindSteamGenerators()))) * wetted
+}
+
+// boil takes what heat the coolant gives up over the given seconds at the
+// given steam line pressure, brings the feed up to boiling with it and boils
+// off what is left, while feedwater control works the valve against the
+// level; it gives back the kg/s of steam made
+func (sg *SteamGenerator) boil(s *Simulation, pressure float64, seconds float64) float64 {
+	primaryLoop := sg.loop(s)
+	secondaryLoop := s.FindSecondaryLoop()
+	if primaryLoop == nil || secondaryLoop == nil {
+		return 0
+	}
+	water := steam.SaturatedLiquid(pressure)
+	vapor := steam.SaturatedVapor(pressure)
+
+	capacity := sg.maxFeedwaterFlowRate(s)
+	sg.feedwaterControl.update(seconds, sg, capacity)
+	sg.feedwaterFlowRate = 0
+	if secondaryLoop.feedwaterPumpOn && !sg.feedwaterIsolated {
+		sg.feedwaterFlowRate = sg.feedwaterControl.valvePosition * capacity
+	}
+	sg.blowdownFlowRate = 0
+	if sg.feedwaterControl.blowdownOpen {
+		sg.blowdownFlowRate = STEAM_GENERATOR_BLOWDOWN_FLOW_RATE
 	}
+
+	heat := primaryLoop.stepTemperature(sg.heatTransferCoefficient(s, water), water.Temperature, seconds)
+	feedEnthalpy := steam.Enthalpy(pressure, sg.secondaryInletTemp)
+
+	// heat goes first to bring the feed up to boiling; what is left boils water
+	sg.steamFlowRate = math.Max(0, (heat*1000-sg.feedwaterFlowRate*(water.Enthalpy-feedEnthalpy))/(vapor.Enthalpy-water.Enthalpy))
+	sg.steamFlowRate = math.Min(sg.steamFlowRate, sg.waterMass/seconds)
+
+	sg.waterMass += (sg.feedwaterFlowRate - sg.steamFlowRate - sg.blowdownFlowRate) * seconds
+	sg.waterMass = math.Max(0, sg.waterMass)
+	sg.updateLevel(water, vapor)
+
+	sg.heatTaken += heat * seconds
+	sg.steamMade += sg.steamFlowRate * seconds
+	sg.feedTaken += sg.feedwaterFlowRate * seconds
+	sg.blownDown += sg.blowdownFlowRate * seconds
+	return sg.steamFlowRate
+}
+
+// finishBoiling sets the tick's flows and heat from what went on over it
+func (sg *SteamGenerator) finishBoiling() {
+	sg.heatTransferRate = sg.heatTaken / SECONDS_PER_TICK
+	sg.steamFlowRate = sg.steamMade / SECONDS_PER_TICK
+	sg.feedwaterFlowRate = sg.feedTaken / SECONDS_PER_TICK
+	sg.blowdownFlowRate = sg.blownDown / SECONDS_PER_TICK
+	sg.heatTaken, sg.steamMade, sg.feedTaken, sg.blownDown = 0, 0, 0, 0
+}
+
+// bubbles on their way up swell the mixture
+func (sg *SteamGenerator) updateLevel(water steam.State, vapor steam.State) {
+	sg.voidVolume = math.Min(sg.steamFlowRate/vapor.Density*RISER_RESIDENCE_TIME, STEAM_GENERATOR_BUNDLE_VOLUME/2)
+	mixture := sg.waterMass/water.Density + sg.voidVolume
+	sg.level = (mixture - STEAM_GENERATOR_BUNDLE_VOLUME) / STEAM_GENERATOR_NARROW_RANGE_VOLUME * 100
 }
 
 func (sg *SteamGenerator) Update(env *Environment, s *Simulation) {
-	reactorCore := s.FindReactorCore()
+	primaryLoop := sg.loop(s)
 	secondaryLoop := s.FindSecondaryLoop()
 
-	if reactorCore == nil || secondaryLoop == nil {
-		fmt.Println("Error: Reactor Core or Secondary Loop not found")
+	if primaryLoop == nil || secondaryLoop == nil {
+		fmt.Println("Error: Primary Loop or Secondary Loop not found")
 		return
 	}
 
-	// Update primary inlet temperature based on reactor core heat
-	sg.primaryInletTemp = math.Min(reactorCore.temperature, 350) // Max temp 350°C
+	// primary coolant comes in on the hot leg and goes back on the cold leg
+	sg.primaryInletTemp = primaryLoop.HotLegTemperature()
+	sg.primaryOutletTemp = primaryLoop.ColdLegTemperature()
+
+	// feedwater comes in subcooled and leaves as saturated steam at steam line pressure
+	sg.secondaryInletTemp = secondaryLoop.FeedwaterTemperature()
+	sg.secondaryOutletTemp = secondaryLoop.SteamTemperature()
+
+	// the boiling itself goes on with the steam header, see boil
+}
+
+// kg/s through this steam generator's regulating valve, wide open; the
+// header capacity is split among the steam generators on it
+func (sg *SteamGenerator) maxFeedwaterFlowRate(s *Simulation) float64 {
+	return MAX_FEEDWATER_FLOW_RATE / float64(max(1, len(s.FindSteamGenerators())))
+}
+
+// IsolateFeedwater shuts the feedwater isolation valve to this steam generator
+func (sg *SteamGenerator) IsolateFeedwater() {
+	sg.feedwaterIsolated = true
+}
+
+func (sg *SteamGenerator) RestoreFeedwater() {
+	sg.feedwaterIsolated = false
+}
+
+func (sg *SteamGenerator) FeedwaterIsolated() bool {
+	return sg.feedwaterIsolated
+}
+
+// kg/s
+func (sg *SteamGenerator) SteamFlowRate() float64 {
+	return sg.steamFlowRate
+}
 
-	// Calculate heat transfer
-	sg.heatTransferRate = reactorCore.HeatEnergyRate() * 0.95 // Assume 95% efficiency
+// kg/s
+func (sg *SteamGenerator) FeedwaterFlowRate() float64 {
+	return sg.feedwaterFlowRate
+}
 
-	// Update temperatures
-	tempDiff := sg.primaryInletTemp - sg.secondaryInletTemp
-	sg.primaryOutletTemp = sg.primaryInletTemp - (tempDiff * 0.2)
-	sg.secondaryOutletTemp = sg.secondaryInletTemp + (tempDiff * 0.8)
+// kg
+func (sg *SteamGenerator) WaterMass() float64 {
+	return sg.waterMass
+}
 
-	// Calculate steam flow rate based on heat transfer
-	// This is a simplified calculation and should be replaced with a more accurate model
-	sg.steamFlowRate = sg.heatTransferRate * 0.5 // Arbitrary factor
+// narrow range, in percent; reads off scale below 0 and above 100
+func (sg *SteamGenerator) Level() float64 {
+	return math.Max(0, math.Min(100, sg.level))
+}
+
+func (sg *SteamGenerator) LowLowLevel() bool {
+	return sg.Level() < STEAM_GENERATOR_LOW_LOW_LEVEL
+}
 
-	// Update secondary loop water flow rate
-	secondaryLoop.feedwaterFlowRate = sg.steamFlowRate / 1000 // Convert kg/s to m³/s (assuming water density of 1000 kg/m³)
+func (sg *SteamGenerator) FeedwaterControl() *FeedwaterControl {
+	return sg.feedwaterControl
 }
 
 func (sg *SteamGenerator) Status() map[string]interface{} {
@@ -64,6 +239,14 @@ func (sg *SteamGenerator) Status() map[string]interface{} {
 		"secondaryOutletTemp": sg.secondaryOutletTemp,
 		"heatTransferRate":    sg.heatTransferRate,
 		"steamFlowRate":       sg.steamFlowRate,
+		"waterMass":           sg.waterMass,
+		"voidVolume":          sg.voidVolume,
+		"level":               sg.Level(),
+		"lowLowLevel":         sg.LowLowLevel(),
+		"feedwaterFlowRate":   sg.feedwaterFlowRate,
+		"blowdownFlowRate":    sg.blowdownFlowRate,
+		"feedwaterIsolated":   sg.feedwaterIsolated,
+		"feedwaterControl":    sg.feedwaterControl.Status(),
 	}
 }
 
@@ -75,4 +258,37 @@ func (sg *SteamGenerator) PrintStatus() {
 	fmt.Printf("\tSecondary Outlet Temperature: %.2f °C\n", sg.secondaryOutletTemp)
 	fmt.Printf("\tHeat Transfer Rate: %.2f MW\n", sg.heatTransferRate)
 	fmt.Printf("\tSteam Flow Rate: %.2f kg/s\n", sg.steamFlowRate)
+	fmt.Printf("\tFeedwater Flow Rate: %.2f kg/s\n", sg.feedwaterFlowRate)
+	fmt.Printf("\tBlowdown Flow Rate: %.2f kg/s\n", sg.blowdownFlowRate)
+	fmt.Printf("\tFeedwater Isolated: %t\n", sg.feedwaterIsolated)
+	fmt.Printf("\tWater Mass: %.0f kg\n", sg.waterMass)
+	fmt.Printf("\tLevel: %.1f%% (setpoint %.1f%%)\n", sg.Level(), sg.feedwaterControl.levelSetpoint)
+	fmt.Printf("\tFeedwater Control: %s, valve %.0f%%\n", sg.feedwaterControl.mode, sg.feedwaterControl.valvePosition*100)
+}
+
+// alarm windows are labeled with the loop in a multi-loop plant, like
+// steamGeneratorLowLevel2 and SG 2 LEVEL LOW
+func (sg *SteamGenerator) alarmLabels(name string, message string) (string, string) {
+	if sg.primaryLoop == nil || sg.primaryLoop.loopNumber == 0 {
+		return name, fmt.Sprintf(message, "SG")
+	}
+	number := sg.primaryLoop.loopNumber
+	return fmt.Sprintf("%s%d", name, number), fmt.Sprintf(message, fmt.Sprintf("SG %d", number))
+}
+
+func (sg *SteamGenerator) AlarmConditions() []*AlarmCondition {
+	lowName, lowMessage := sg.alarmLabels("steamGeneratorLowLevel", "%s LEVEL LOW")
+	lowLowName, lowLowMessage := sg.alarmLabels("steamGeneratorLowLowLevel", "%s LEVEL LOW LOW")
+	highName, highMessage := sg.alarmLabels("steamGeneratorHighLevel", "%s LEVEL HIGH")
+	return []*AlarmCondition{
+		NewAlarmCondition(lowName, lowMessage, ALARM_PRIORITY_MEDIUM, func() bool {
+			return sg.Level() < STEAM_GENERATOR_LOW_LEVEL_ALARM
+		}),
+		NewAlarmCondition(lowLowName, lowLowMessage, ALARM_PRIORITY_HIGH, func() bool {
+			return sg.LowLowLevel()
+		}),
+		NewAlarmCondition(highName, highMessage, ALARM_PRIORITY_MEDIUM, func() bool {
+			return sg.Level() > STEAM_GENERATOR_HIGH_LEVEL_ALARM
+		}),
+	}
 }
@@ -0,0 +1,123 @@
+package sim
+
+import (
+	"testing"
+
+	"won/sim-lab/go-engine/internal/steam"
+)
+
+func TestFeedwaterControlHoldsLevelAtRatedPower(t *testing.T) {
+	simulation, env := setUpSteamCycle()
+	steamGenerator := simulation.FindSteamGenerator()
+
+	if !almostEqual(steamGenerator.Level(), NORMAL_STEAM_GENERATOR_LEVEL, 2) {
+		t.Errorf("Expected level back at %f%% after heatup, got %f", NORMAL_STEAM_GENERATOR_LEVEL, steamGenerator.Level())
+	}
+	if !almostEqual(steamGenerator.FeedwaterFlowRate(), steamGenerator.SteamFlowRate(), 0.01*steamGenerator.SteamFlowRate()) {
+		t.Errorf("Expected feed to match steam, got %f kg/s feed and %f kg/s steam", steamGenerator.FeedwaterFlowRate(), steamGenerator.SteamFlowRate())
+	}
+
+	// load comes off; steam flow leads the feed down so the level hardly moves
+	simulation.FindReactorCore().heatEnergyRate = RATED_THERMAL_POWER / 2
+	runTurbineOnline(simulation.FindSteamTurbine(), 50)
+	for i := 0; i < 10; i++ {
+		updateSteamCycle(simulation, env)
+	}
+	if !almostEqual(steamGenerator.Level(), NORMAL_STEAM_GENERATOR_LEVEL, 2) {
+		t.Errorf("Expected level held at half power, got %f%%", steamGenerator.Level())
+	}
+	if steamGenerator.FeedwaterControl().ValvePosition() > 60 {
+		t.Errorf("Expected the feedwater valve to close in with load, at %f%%", steamGenerator.FeedwaterControl().ValvePosition())
+	}
+}
+
+func TestLevelSwellsWithSteaming(t *testing.T) {
+	simulation, env := setUpSteamCycle()
+	steamGenerator := simulation.FindSteamGenerator()
+	steamGenerator.FeedwaterControl().SwitchToManual()
+
+	secondaryLoop := simulation.FindSecondaryLoop()
+	collapsedLevel := func() float64 {
+		water := steamGenerator.WaterMass() / steam.SaturatedLiquid(secondaryLoop.SteamPressure()).Density
+		return (water - STEAM_GENERATOR_BUNDLE_VOLUME) / STEAM_GENERATOR_NARROW_RANGE_VOLUME * 100
+	}
+	swell := steamGenerator.Level() - collapsedLevel()
+	if swell <= 0 {
+		t.Errorf("Expected the bubbles to read above the water alone while steaming, got %f%%", swell)
+	}
+
+	// boiling knocked back leaves less mixture for the same water
+	simulation.FindReactorCore().heatEnergyRate = RATED_THERMAL_POWER / 2
+	simulation.FindPrimaryLoop().Update(env, simulation)
+	steamGenerator.Update(env, simulation)
+	secondaryLoop.Update(env, simulation)
+	if steamGenerator.Level()-collapsedLevel() >= swell {
+		t.Errorf("Expected the level to shrink with less steaming, went from %f%% to %f%% over the water", swell, steamGenerator.Level()-collapsedLevel())
+	}
+}
+
+func TestLossOfFeedwaterTripsTheReactor(t *testing.T) {
+	simulation, env := setUpSteamCycle()
+	steamGenerator := simulation.FindSteamGenerator()
+	reactorProtection := NewReactorProtection("Test Reactor Protection")
+	simulation.AddComponent(reactorProtection)
+	simulation.FindReactorCore().kinetics = NewPointKinetics(1.0)
+	simulation.FindPrimaryLoop().Update(env, simulation)
+
+	simulation.FindSecondaryLoop().SwitchOffFeedwaterPump()
+	steamGenerator.Update(env, simulation)
+	simulation.FindSecondaryLoop().Update(env, simulation)
+	if steamGenerator.FeedwaterFlowRate() != 0 {
+		t.Errorf("Expected no feed without the pump, got %f kg/s", steamGenerator.FeedwaterFlowRate())
+	}
+	if !steamGenerator.LowLowLevel() {
+		t.Errorf("Expected a minute of full power steaming to boil the level down, at %f%%", steamGenerator.Level())
+	}
+
+	reactorProtection.Update(env, simulation)
+	if !reactorProtection.IsTripped() || reactorProtection.FirstOut() != STEAM_GENERATOR_LOW_LEVEL_TRIP {
+		t.Errorf("Expected a low-low level trip, got tripped %t, first out %s", reactorProtection.IsTripped(), reactorProtection.FirstOut())
+	}
+}
+
+func TestSteamGeneratorTubesDryOut(t *testing.T) {
+	simulation, env := setUpSteamCycle()
+	steamGenerator := simulation.FindSteamGenerator()
+
+	simulation.FindSecondaryLoop().SwitchOffFeedwaterPump()
+	for i := 0; i < 10; i++ {
+		simulation.FindPrimaryLoop().Update(env, simulation)
+		steamGenerator.Update(env, simulation)
+		simulation.FindSecondaryLoop().Update(env, simulation)
+	}
+	if steamGenerator.heatTransferRate > 0.01*RATED_THERMAL_POWER {
+		t.Errorf("Expected a dry steam generator to stop taking heat, got %f MW", steamGenerator.heatTransferRate)
+	}
+}
+
+func TestSteamGeneratorTakesHeatOnTheTemperatureDifference(t *testing.T) {
+	simulation, env := setUpSteamCycle()
+	primaryLoop := simulation.FindPrimaryLoop()
+	steamGenerator := simulation.FindSteamGenerator()
+
+	boiling := steam.SaturationTemperature(simulation.FindSecondaryLoop().SteamPressure())
+	expected := STEAM_GENERATOR_HEAT_TRANSFER_COEFFICIENT * (primaryLoop.AverageTemperature() - boiling)
+	if !almostEqual(steamGenerator.heatTransferRate, expected, 0.02*expected) {
+		t.Errorf("Expected UA (Tavg - Tsat) = %f MW across the tubes, got %f", expected, steamGenerator.heatTransferRate)
+	}
+
+	// with no heat from the core, the tubes take the pump heat and what the
+	// coolant gives up as the turbine draws the header down, and the coolant
+	// follows just above the boiling water instead of running away
+	simulation.FindReactorCore().heatEnergyRate = 0
+	for i := 0; i < 30; i++ {
+		updateSteamCycle(simulation, env)
+	}
+	boiling = steam.SaturationTemperature(simulation.FindSecondaryLoop().SteamPressure())
+	if primaryLoop.AverageTemperature() < boiling || primaryLoop.AverageTemperature() > boiling+1 {
+		t.Errorf("Expected Tavg just above the %f °C boiling water, got %f", boiling, primaryLoop.AverageTemperature())
+	}
+	if steamGenerator.heatTransferRate < primaryLoop.PumpPower() {
+		t.Errorf("Expected the tubes to take at least the %f MW of pump heat, got %f", primaryLoop.PumpPower(), steamGenerator.heatTransferRate)
+	}
+}
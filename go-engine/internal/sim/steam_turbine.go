@@ -3,66 +3,660 @@ package sim
 import (
 	"fmt"
 	"math"
+
+	"won/sim-lab/go-engine/internal/steam"
 )
 
 type SteamTurbine struct {
 	BaseComponent
-	rpm           int     // Revolutions per minute
-	maxRPM        int     // Maximum RPM the turbine can handle
-	efficiency    float64 // Turbine efficiency (0-1)
-	steamPressure float64 // Current steam pressure from SteamGenerator (in Pascal)
+	speed                 float64 // rpm
+	efficiency            float64 // Turbine efficiency (0-1); actual over isentropic enthalpy drop, across the stages
+	stages                []*TurbineStage
+	steamPressure         float64 // Current steam pressure at the inlet, from the secondary loop (in MPa)
+	steamFlowRate         float64 // kg/s through the governor valves
+	crossoverPressure     float64 // MPa between the HP and LP turbines
+	exhaustPressure       float64 // MPa, set by the condenser
+	exhaustFlowRate       float64 // kg/s out of the last LP stage
+	exhaustQuality        float64 // mass fraction of vapor leaving the last stage
+	exhaustEnthalpy       float64 // kJ/kg
+	power                 float64 // MW of shaft power
+	load                  float64 // percent of rated steam flow
+	tripped               bool    // stop valves shut; no steam gets to the blades
+	tripCause             string
+	online                bool    // tied to the grid, which holds the shaft at synchronous speed
+	gridSpeed             float64 // rpm the grid holds the shaft at, on line
+	speedReference        float64 // rpm the governor is bringing the shaft to
+	loadSetpoint          float64 // percent of rated steam flow the operator wants
+	loadReference         float64 // percent, ramping toward the setpoint
+	loadRampRate          float64 // percent per minute
+	governorValvePosition float64 // fraction open
+	steamDumpArmed        bool
+	steamDumpFlowRate     float64 // kg/s bypassing the turbine to the condenser
+	steamDumpEnthalpy     float64 // kJ/kg
+	heaterDrainFlowRate   float64 // kg/s of feedwater heater drains to the condenser
+	heaterDrainEnthalpy   float64 // kJ/kg
+	msr                   *MoistureSeparatorReheater
 }
 
+// The steam comes in through the stop valves, which are either wide open or
+// shut, and then the governor valves, which set how much steam the turbine
+// takes. Flow through the governor valves goes with their opening and the
+// inlet pressure.
+//
+// The governor works on speed droop. Its demand is the load reference plus
+// the speed error over the droop, so a 5% drop in speed opens the valves all
+// the way. The demand is for steam flow: with the throttle pressure above
+// rated, the valves pass the same steam less open. Off line, nothing holds
+// the shaft but its own inertia; the governor brings it up to synchronous
+// speed at a set acceleration and holds it there. Once the generator breaker
+// closes, the grid holds the speed, and the load reference ramps toward the
+// setpoint at the ramp rate. Should the grid frequency sag, the droop opens
+// the valves to help hold it up.
+//
+// If the unit comes off line under load, the load reference drops to zero
+// and droop closes the valves as the shaft speeds up. Should the shaft still
+// reach the overspeed setpoint, the turbine trips. It also trips should the
+// condenser lose its vacuum. A trip shuts the stop valves, takes the unit off
+// line and arms the steam dump, which bypasses the steam the turbine is not
+// taking straight to the condenser, so long as the condenser can take it.
+//
+// On its way through the stages, the steam is bled off to the feedwater
+// heaters, and between the HP and LP stages it goes through the moisture
+// separator reheater. The pressure at each extraction point falls off with
+// the steam flow, so the heaters get less out of the steam at part load.
+// Steam bled off does no more work, so the turbine makes less power per kg of
+// throttle steam with the heaters in service; what the cycle gains is the
+// heat that would otherwise go to the condenser.
+
+// kg/s, what the steam generator makes at rated thermal power, with saturated
+// steam at TARGET_STEAM_TEMPERATURE and feedwater at TARGET_FEEDWATER_TEMPERATURE
+const RATED_STEAM_FLOW = 1690.0
+const RATED_STEAM_PRESSURE = 6.91        // MPa, saturation pressure at TARGET_STEAM_TEMPERATURE
+const DESIGN_CONDENSER_PRESSURE = 0.0074 // MPa, saturation pressure at 40 °C
+
+const TURBINE_SYNCHRONOUS_SPEED = float64(TURBINE_MAX_RPM)
+const TURBINE_RATED_POWER = 1100.0                                   // MW of shaft power at rated steam flow
+const TURBINE_INERTIA_CONSTANT = 5.0                                 // seconds of rated power stored in the shaft at synchronous speed
+const TURBINE_WINDAGE_LOSS = 0.01                                    // fraction of rated power lost to windage and bearings at synchronous speed
+const TURBINE_ACCELERATION = 180.0                                   // rpm per minute, bringing the shaft up to speed
+const TURBINE_OVERSPEED_TRIP_SPEED = 1.1 * TURBINE_SYNCHRONOUS_SPEED // rpm
+const GOVERNOR_DROOP = 0.05                                          // fraction of speed change for the full valve stroke
+const GOVERNOR_VALVE_STROKE_TIME = 0.3                               // seconds, fully open to closed
+const DEFAULT_LOAD_RAMP_RATE = 5.0                                   // percent per minute
+const MAX_LOAD_RAMP_RATE = 20.0                                      // percent per minute
+const STEAM_DUMP_CAPACITY = 0.4 * RATED_STEAM_FLOW                   // kg/s
+const STEAM_DUMP_PRESSURE_SETPOINT = RATED_STEAM_PRESSURE + 0.2      // MPa the steam dump starts to open at
+const STEAM_DUMP_PROPORTIONAL_BAND = 0.3                             // MPa over the setpoint to open it all the way
+const TURBINE_TIME_STEP = 0.1                                        // seconds
+const BAUMANN_FACTOR = 1.0                                           // fraction of stage efficiency lost per fraction of moisture
+const MAX_EXTRACTION_SHARE = 0.15                                    // most of the steam flow one extraction point can give up
+const MAX_FEED_RATIO = 1.5                                           // most feedwater per kg of steam the heaters will try to bring up
+
+const (
+	TURBINE_TRIP_MANUAL     = "manual"
+	TURBINE_TRIP_OVERSPEED  = "overspeed"
+	TURBINE_TRIP_LOW_VACUUM = "lowVacuum"
+)
+
 func NewSteamTurbine(name string) *SteamTurbine {
 	return &SteamTurbine{
-		BaseComponent: BaseComponent{Name: name},
-		rpm:           0,
-		maxRPM:        TURBINE_MAX_RPM,
-		efficiency:    0.9, // 90% efficiency, can be adjusted
-		steamPressure: 0,
+		BaseComponent:   BaseComponent{Name: name},
+		speed:           0,
+		stages:          newTurbineStages(),
+		steamPressure:   0,
+		exhaustPressure: DESIGN_CONDENSER_PRESSURE,
+		exhaustQuality:  1,
+		loadRampRate:    DEFAULT_LOAD_RAMP_RATE,
+		gridSpeed:       TURBINE_SYNCHRONOUS_SPEED,
+		msr:             NewMoistureSeparatorReheater(),
 	}
 }
 
 func (st *SteamTurbine) Update(env *Environment, s *Simulation) {
-	steamGen := s.FindSteamGenerator()
-	if steamGen == nil {
-		fmt.Println("Error: Steam Generator not found")
+	secondaryLoop := s.FindSecondaryLoop()
+	if s.FindSteamGenerator() == nil || secondaryLoop == nil {
+		fmt.Println("Error: Steam Generator or Secondary Loop not found")
 		return
 	}
 
-	// Update steam pressure based on SteamGenerator's output
-	st.steamPressure = steamGen.steamFlowRate * 1000 // Simple conversion, adjust as needed
+	// the steam dump only opens to a condenser that can take the steam
+	condenserAvailable := true
+	if condenser := s.FindCondenser(); condenser != nil {
+		st.exhaustPressure = condenser.Pressure()
+		condenserAvailable = condenser.Available()
+	}
+	// backpressure that high would overheat the last stage blades
+	if !st.tripped && st.exhaustPressure >= LOW_VACUUM_TRIP_PRESSURE {
+		st.trip(TURBINE_TRIP_LOW_VACUUM)
+	}
+
+	if generator := s.FindGenerator(); generator != nil {
+		st.gridSpeed = TURBINE_SYNCHRONOUS_SPEED * generator.Grid().Frequency() / NOMINAL_GRID_FREQUENCY
+	}
+
+	st.steamPressure = secondaryLoop.SteamPressure()
+	feedRatio := 0.0
+	if st.steamFlowRate > 0 {
+		feedwater := 0.0
+		for _, steamGenerator := range s.FindSteamGenerators() {
+			feedwater += steamGenerator.FeedwaterFlowRate()
+		}
+		feedRatio = math.Min(MAX_FEED_RATIO, feedwater/st.steamFlowRate)
+	}
+	heaters := secondaryLoop.FeedwaterHeaters()
+	path := st.expandSteam(heaters, feedRatio)
+	work := path.work
+
+	steamUsed := 0.0
+	for elapsed := 0.0; elapsed < SECONDS_PER_TICK; elapsed += TURBINE_TIME_STEP {
+		st.govern(TURBINE_TIME_STEP)
+
+		// the header may not have had all the valves ask for
+		flow := st.steamDemand(st.steamPressure) * secondaryLoop.steamSupplyFraction
+		st.turnShaft(flow*work/1000, TURBINE_TIME_STEP)
+		steamUsed += flow * TURBINE_TIME_STEP
+	}
+
+	st.steamFlowRate = steamUsed / SECONDS_PER_TICK
+	st.load = st.steamFlowRate / RATED_STEAM_FLOW * 100
+	st.power = st.steamFlowRate * work / 1000
+
+	st.exhaustFlowRate = st.steamFlowRate * path.exhaustShare
+	for _, stage := range st.stages {
+		stage.run(st.steamFlowRate)
+	}
+	for i, heater := range heaters {
+		heater.extractionFlowRate = st.steamFlowRate * path.extractionShares[i]
+		heater.drainFlowRate = st.steamFlowRate * path.drainShares[i]
+	}
+	st.msr.separatorDrainFlow = st.steamFlowRate * path.separatorShare
+	st.msr.reheatingSteamFlow = st.steamFlowRate * path.reheatingShare
+	st.heaterDrainFlowRate = st.steamFlowRate * path.drainShare
+	st.heaterDrainEnthalpy = path.drainEnthalpy
+
+	st.steamDumpFlowRate = st.steamDumpDemand(st.steamPressure, condenserAvailable) * secondaryLoop.steamSupplyFraction
+	st.steamDumpEnthalpy = steam.SaturatedVapor(math.Max(st.steamPressure, steam.ATMOSPHERIC_PRESSURE)).Enthalpy
+}
 
-	// Calculate RPM based on steam pressure
-	// This is a simplified calculation and should be replaced with a more accurate model
-	targetRPM := int(st.steamPressure / 10000 * float64(st.maxRPM) * st.efficiency)
-	
-	// Gradually adjust RPM (turbines don't instantly change speed)
-	rpmDiff := targetRPM - st.rpm
-	st.rpm += int(float64(rpmDiff) * 0.1) // Adjust 10% of the difference
+// steamDemand is the kg/s the governor valves pass at the given inlet
+// pressure, as they stand
+func (st *SteamTurbine) steamDemand(pressure float64) float64 {
+	if st.tripped {
+		return 0
+	}
+	return st.governorValvePosition * RATED_STEAM_FLOW * pressure / RATED_STEAM_PRESSURE
+}
 
-	// Ensure RPM stays within bounds
-	st.rpm = int(math.Max(0, math.Min(float64(st.rpm), float64(st.maxRPM))))
+// steamDumpDemand is the kg/s the steam dump passes at the given header
+// pressure; armed, it opens over its proportional band above the setpoint
+func (st *SteamTurbine) steamDumpDemand(pressure float64, condenserAvailable bool) float64 {
+	if !st.steamDumpArmed || !condenserAvailable {
+		return 0
+	}
+	opening := (pressure - STEAM_DUMP_PRESSURE_SETPOINT) / STEAM_DUMP_PROPORTIONAL_BAND
+	return STEAM_DUMP_CAPACITY * math.Max(0, math.Min(1, opening))
+}
+
+// govern ramps the references and strokes the governor valves toward the
+// droop demand
+func (st *SteamTurbine) govern(seconds float64) {
+	if st.tripped {
+		st.governorValvePosition = 0
+		return
+	}
+
+	if st.online {
+		st.speedReference = TURBINE_SYNCHRONOUS_SPEED
+		step := st.loadRampRate / 60 * seconds
+		st.loadReference += math.Max(-step, math.Min(step, st.loadSetpoint-st.loadReference))
+	} else {
+		// the reference leads the shaft by no more than a minute's acceleration,
+		// so steam coming back after a wait does not slam the valves open
+		step := TURBINE_ACCELERATION / 60 * seconds
+		st.speedReference = math.Min(TURBINE_SYNCHRONOUS_SPEED, math.Min(st.speedReference+step, st.speed+TURBINE_ACCELERATION))
+		st.loadReference = 0
+	}
+
+	demand := st.loadReference/100 + (st.speedReference-st.speed)/(GOVERNOR_DROOP*TURBINE_SYNCHRONOUS_SPEED)
+	// the demand is for steam flow, so the valves make up for the throttle
+	// pressure being off rated
+	if demand > 0 && st.steamPressure > 0 {
+		demand *= RATED_STEAM_PRESSURE / st.steamPressure
+	}
+	demand = math.Max(0, math.Min(1, demand))
+	stroke := seconds / GOVERNOR_VALVE_STROKE_TIME
+	st.governorValvePosition += math.Max(-stroke, math.Min(stroke, demand-st.governorValvePosition))
+}
+
+// turnShaft speeds the shaft up or slows it down by what the steam gives it
+// over what it loses, while off line; on line the grid holds its speed and
+// takes the power
+func (st *SteamTurbine) turnShaft(power float64, seconds float64) {
+	if st.online {
+		st.speed = st.gridSpeed
+		return
+	}
+
+	// 2H s ds/dt = (Pm - Ploss) / Prated, all per unit
+	relativeSpeed := st.speed / TURBINE_SYNCHRONOUS_SPEED
+	loss := TURBINE_WINDAGE_LOSS * math.Max(relativeSpeed, relativeSpeed*relativeSpeed*relativeSpeed)
+	acceleration := (power/TURBINE_RATED_POWER - loss) / (2 * TURBINE_INERTIA_CONSTANT * math.Max(relativeSpeed, 0.1))
+	st.speed = math.Max(0, st.speed+acceleration*TURBINE_SYNCHRONOUS_SPEED*seconds)
+
+	if st.speed >= TURBINE_OVERSPEED_TRIP_SPEED {
+		st.trip(TURBINE_TRIP_OVERSPEED)
+	}
+}
+
+// what each kg of steam through the governor valves does on its way through
+// the turbine
+type steamPath struct {
+	work             float64   // kJ/kg the blades take out
+	exhaustShare     float64   // kg leaving the last LP stage
+	extractionShares []float64 // kg bled to each feedwater heater
+	drainShares      []float64 // kg of drains leaving each feedwater heater
+	separatorShare   float64   // kg of water wrung out by the moisture separator
+	reheatingShare   float64   // kg bled to the reheater
+	drainShare       float64   // kg of heater drains cascading to the condenser
+	drainEnthalpy    float64   // kJ/kg
+}
+
+// expandSteam follows the steam through the stages: through the HP stage,
+// where it gives up steam to the high-pressure heaters and the reheater,
+// through the moisture separator reheater, and on through the LP stages,
+// which give up steam to the low-pressure heaters, to the exhaust. The stage
+// pressures along the way fall off with the steam flow; the heaters take what
+// bleed steam they need to bring up the given kg of feedwater per kg of steam.
+func (st *SteamTurbine) expandSteam(heaters []*FeedwaterHeater, feedRatio float64) steamPath {
+	path := steamPath{exhaustShare: 1, extractionShares: make([]float64, len(heaters)), drainShares: make([]float64, len(heaters))}
+	for _, heater := range heaters {
+		heater.shellPressure, heater.extractionEnthalpy, heater.dutyLimit = 0, 0, math.Inf(1)
+	}
+	if st.steamPressure <= st.exhaustPressure {
+		for _, stage := range st.stages {
+			stage.reset(st.exhaustPressure)
+		}
+		st.efficiency = 0
+		st.exhaustQuality = 1
+		st.exhaustEnthalpy = steam.SaturatedVapor(st.exhaustPressure).Enthalpy
+		st.crossoverPressure = st.exhaustPressure
+		return path
+	}
+
+	flowFraction := st.steamFlowRate / RATED_STEAM_FLOW
+	stagePressure := func(design float64) float64 {
+		return math.Max(st.exhaustPressure, math.Min(st.steamPressure, design*flowFraction))
+	}
+
+	// the HP stage, down to crossover pressure
+	hp, lpStages := st.stages[0], st.stages[1:]
+	hp.inlet = steam.SaturatedVapor(st.steamPressure)
+	hp.bleed = st.expand(hp.inlet, stagePressure(hp.designBleedPressure), hp.efficiency)
+	hp.outlet = st.expand(hp.bleed, stagePressure(hp.designOutletPressure), hp.efficiency)
+	st.crossoverPressure = hp.outlet.Pressure
+
+	// across the crossover, and through the LP stages to the exhaust
+	lpInlet, carriedOn, heatingSteam := st.msr.condition(hp.outlet, hp.bleed)
+	state := lpInlet
+	for i, stage := range lpStages {
+		outletPressure := st.exhaustPressure
+		if i < len(lpStages)-1 {
+			outletPressure = stagePressure(stage.designOutletPressure)
+		}
+		stage.inlet = state
+		stage.outlet = st.expand(state, outletPressure, stage.efficiency)
+		stage.bleed = stage.outlet
+		state = stage.outlet
+	}
+	st.exhaustEnthalpy = state.Enthalpy
+	st.exhaustQuality = math.Min(1, state.Quality)
+
+	// each heater bleeds from its stage, if there is a drop to it
+	top, crossover := -1, -1
+	sources := make([]*TurbineStage, len(heaters))
+	for i, heater := range heaters {
+		sources[i] = st.stages[heater.bleedStage]
+		extraction := sources[i].extractionState(heater)
+		heater.shellPressure = extraction.Pressure
+		if heater.shellPressure > st.exhaustPressure {
+			heater.extractionEnthalpy = extraction.Enthalpy
+		}
+		if sources[i] == hp && heater.bledPartway {
+			top = i
+		}
+		if sources[i] == hp && !heater.bledPartway {
+			crossover = i
+		}
+	}
+
+	// the bleed steam each heater takes depends on the drains cascading into
+	// it, which depend on what the heaters above it took; a few passes settle it
+	for pass := 0; pass < 10; pass++ {
+		hpExtraction := 0.0
+		for i := range heaters {
+			if sources[i] == hp {
+				hpExtraction += path.extractionShares[i]
+			}
+		}
+		path.reheatingShare = heatingSteam * (1 - hpExtraction) / (1 + heatingSteam)
+		toSeparator := 1 - hpExtraction - path.reheatingShare
+		path.separatorShare = toSeparator * (1 - carriedOn)
+
+		drainFlow, drainEnergy := 0.0, 0.0
+		for i := len(heaters) - 1; i >= 0; i-- {
+			heater := heaters[i]
+			if i == top {
+				drainFlow += path.reheatingShare
+				drainEnergy += path.reheatingShare * steam.SaturatedLiquid(hp.bleed.Pressure).Enthalpy
+			}
+			if i == crossover {
+				drainFlow += path.separatorShare
+				drainEnergy += path.separatorShare * steam.SaturatedLiquid(st.crossoverPressure).Enthalpy
+			}
+			path.extractionShares[i] = 0
+			if heater.steaming() {
+				drainOut := heater.drainEnthalpy()
+				needed := feedRatio*heater.duty() - (drainEnergy - drainFlow*drainOut)
+				path.extractionShares[i] = math.Max(0, math.Min(MAX_EXTRACTION_SHARE, needed/(heater.extractionEnthalpy-drainOut)))
+				// all it can bleed is not enough, so the feedwater comes out colder next time
+				heater.dutyLimit = math.Inf(1)
+				if path.extractionShares[i] == MAX_EXTRACTION_SHARE && feedRatio > 0 {
+					heater.dutyLimit = (MAX_EXTRACTION_SHARE*(heater.extractionEnthalpy-drainOut) + drainEnergy - drainFlow*drainOut) / feedRatio
+				}
+				drainFlow += path.extractionShares[i]
+				drainEnergy = drainFlow * drainOut
+			}
+			path.drainShares[i] = drainFlow
+		}
+		path.drainShare = drainFlow
+		path.drainEnthalpy = 0
+		if drainFlow > 0 {
+			path.drainEnthalpy = drainEnergy / drainFlow
+		}
+	}
+
+	// work done by the steam left in each stage
+	for _, stage := range st.stages {
+		stage.extractionShare = 0
+	}
+	for i, source := range sources {
+		source.extractionShare += path.extractionShares[i]
+	}
+	bledPartway := path.reheatingShare
+	for i, heater := range heaters {
+		if sources[i] == hp && heater.bledPartway {
+			bledPartway += path.extractionShares[i]
+		}
+	}
+	hp.extractionShare += path.reheatingShare
+	hp.flowShare = 1
+	hp.work = hp.inlet.Enthalpy - hp.bleed.Enthalpy + (1-bledPartway)*(hp.bleed.Enthalpy-hp.outlet.Enthalpy)
+	hp.idealWork = hp.inlet.Enthalpy - steam.FromEntropy(hp.bleed.Pressure, hp.inlet.Entropy).Enthalpy +
+		(1-bledPartway)*(hp.bleed.Enthalpy-steam.FromEntropy(hp.outlet.Pressure, hp.bleed.Entropy).Enthalpy)
+
+	flow := 1 - hp.extractionShare - path.separatorShare
+	for _, stage := range lpStages {
+		stage.flowShare = flow
+		stage.work = flow * (stage.inlet.Enthalpy - stage.outlet.Enthalpy)
+		stage.idealWork = flow * (stage.inlet.Enthalpy - steam.FromEntropy(stage.outlet.Pressure, stage.inlet.Entropy).Enthalpy)
+		flow -= stage.extractionShare
+	}
+	path.exhaustShare = math.Max(0, flow)
+
+	idealWork := 0.0
+	for _, stage := range st.stages {
+		path.work += stage.work
+		idealWork += stage.idealWork
+	}
+	st.efficiency = 0
+	if idealWork > 0 {
+		st.efficiency = path.work / idealWork
+	}
+	return path
+}
+
+// expand takes steam through a stage of the given isentropic efficiency down
+// to the given pressure. An ideal stage would keep its entropy constant, and
+// the efficiency says how much of that enthalpy drop the blades actually get;
+// each percent of moisture in the stage costs about a percent of that.
+func (st *SteamTurbine) expand(inlet steam.State, pressure float64, dryEfficiency float64) steam.State {
+	if pressure >= inlet.Pressure {
+		return inlet
+	}
+	drop := inlet.Enthalpy - steam.FromEntropy(pressure, inlet.Entropy).Enthalpy
+	efficiency := dryEfficiency
+	outlet := steam.FromEnthalpy(pressure, inlet.Enthalpy-efficiency*drop)
+	for i := 0; i < 3; i++ {
+		moisture := (2 - math.Min(1, inlet.Quality) - math.Min(1, outlet.Quality)) / 2
+		efficiency = dryEfficiency * (1 - BAUMANN_FACTOR*moisture)
+		outlet = steam.FromEnthalpy(pressure, inlet.Enthalpy-efficiency*drop)
+	}
+	return outlet
 }
 
 func (st *SteamTurbine) Status() map[string]interface{} {
+	stages := make([]map[string]interface{}, len(st.stages))
+	for i, stage := range st.stages {
+		stages[i] = stage.Status()
+	}
 	return map[string]interface{}{
-		"name":          st.Name,
-		"rpm":           st.rpm,
-		"maxRPM":        st.maxRPM,
-		"efficiency":    st.efficiency,
-		"steamPressure": st.steamPressure,
+		"name":                      st.Name,
+		"rpm":                       st.Rpm(),
+		"synchronousSpeed":          TURBINE_SYNCHRONOUS_SPEED,
+		"efficiency":                st.efficiency,
+		"steamPressure":             st.steamPressure,
+		"steamFlowRate":             st.steamFlowRate,
+		"exhaustPressure":           st.exhaustPressure,
+		"exhaustQuality":            st.exhaustQuality,
+		"power":                     st.power,
+		"load":                      st.load,
+		"tripped":                   st.tripped,
+		"tripCause":                 st.tripCause,
+		"online":                    st.online,
+		"loadSetpoint":              st.loadSetpoint,
+		"loadReference":             st.loadReference,
+		"loadRampRate":              st.loadRampRate,
+		"governorValvePosition":     st.governorValvePosition * 100,
+		"steamDumpArmed":            st.steamDumpArmed,
+		"steamDumpFlowRate":         st.steamDumpFlowRate,
+		"crossoverPressure":         st.crossoverPressure,
+		"exhaustFlowRate":           st.exhaustFlowRate,
+		"heaterDrainFlowRate":       st.heaterDrainFlowRate,
+		"moistureSeparatorReheater": st.msr.Status(),
+		"stages":                    stages,
 	}
 }
 
 func (st *SteamTurbine) PrintStatus() {
 	fmt.Printf("Steam Turbine: %s\n", st.Name)
-	fmt.Printf("\tRPM: %d\n", st.rpm)
-	fmt.Printf("\tMax RPM: %d\n", st.maxRPM)
+	fmt.Printf("\tRPM: %d\n", st.Rpm())
+	fmt.Printf("\tOn Line: %t\n", st.online)
 	fmt.Printf("\tEfficiency: %.2f\n", st.efficiency)
-	fmt.Printf("\tSteam Pressure: %.2f Pa\n", st.steamPressure)
+	fmt.Printf("\tSteam Pressure: %.2f MPa\n", st.steamPressure)
+	fmt.Printf("\tSteam Flow Rate: %.2f kg/s\n", st.steamFlowRate)
+	fmt.Printf("\tCrossover Pressure: %.3f MPa\n", st.crossoverPressure)
+	for i, stage := range st.stages {
+		stage.PrintStatus()
+		if i == 0 {
+			st.msr.PrintStatus()
+		}
+	}
+	fmt.Printf("\tExhaust Pressure: %.4f MPa\n", st.exhaustPressure)
+	fmt.Printf("\tExhaust Flow Rate: %.2f kg/s\n", st.exhaustFlowRate)
+	fmt.Printf("\tExhaust Quality: %.3f\n", st.exhaustQuality)
+	fmt.Printf("\tPower: %.1f MW\n", st.power)
+	fmt.Printf("\tLoad: %.1f%% (setpoint %.1f%%, reference %.1f%%)\n", st.load, st.loadSetpoint, st.loadReference)
+	fmt.Printf("\tGovernor Valves: %.1f%%\n", st.governorValvePosition*100)
+	fmt.Printf("\tTripped: %t %s\n", st.tripped, st.tripCause)
+	fmt.Printf("\tSteam Dump: armed %t, %.1f kg/s\n", st.steamDumpArmed, st.steamDumpFlowRate)
 }
 
 func (st *SteamTurbine) Rpm() int {
-	return st.rpm
+	return int(math.Round(st.speed))
+}
+
+// MW of shaft power
+func (st *SteamTurbine) Power() float64 {
+	return st.power
+}
+
+// kg/s of wet steam going to the condenser
+func (st *SteamTurbine) ExhaustFlowRate() float64 {
+	return st.exhaustFlowRate
+}
+
+// kJ/kg
+func (st *SteamTurbine) ExhaustEnthalpy() float64 {
+	return st.exhaustEnthalpy
+}
+
+// kg/s of feedwater heater drains flashing into the condenser
+func (st *SteamTurbine) HeaterDrainFlowRate() float64 {
+	return st.heaterDrainFlowRate
+}
+
+// kJ/kg
+func (st *SteamTurbine) HeaterDrainEnthalpy() float64 {
+	return st.heaterDrainEnthalpy
+}
+
+// the stages from the throttle to the condenser, the HP stage first
+func (st *SteamTurbine) Stages() []*TurbineStage {
+	return st.stages
+}
+
+func (st *SteamTurbine) MoistureSeparatorReheater() *MoistureSeparatorReheater {
+	return st.msr
+}
+
+// MPa between the HP and LP turbines
+func (st *SteamTurbine) CrossoverPressure() float64 {
+	return st.crossoverPressure
+}
+
+// percent of rated steam flow
+func (st *SteamTurbine) Load() float64 {
+	return st.load
+}
+
+// true while the grid holds the shaft at synchronous speed
+func (st *SteamTurbine) IsOnline() bool {
+	return st.online
+}
+
+// SetLoadSetpoint gives the load, in percent of rated steam flow, the
+// governor ramps toward once the unit is on line
+func (st *SteamTurbine) SetLoadSetpoint(percent float64) {
+	if percent < 0 || percent > 100 {
+		fmt.Printf("Load setpoint must be between 0 and 100 percent. You requested %f.\n", percent)
+		return
+	}
+	st.loadSetpoint = percent
+}
+
+func (st *SteamTurbine) LoadSetpoint() float64 {
+	return st.loadSetpoint
+}
+
+// SetLoadRampRate sets how fast, in percent per minute, the load follows its setpoint
+func (st *SteamTurbine) SetLoadRampRate(rate float64) {
+	if rate <= 0 || rate > MAX_LOAD_RAMP_RATE {
+		fmt.Printf("Load ramp rate must be above 0 and at most %.0f percent per minute. You requested %f.\n", MAX_LOAD_RAMP_RATE, rate)
+		return
+	}
+	st.loadRampRate = rate
+}
+
+func (st *SteamTurbine) LoadRampRate() float64 {
+	return st.loadRampRate
+}
+
+// percent open
+func (st *SteamTurbine) GovernorValvePosition() float64 {
+	return st.governorValvePosition * 100
+}
+
+// synchronize puts the unit on line, with the generator breaker closed onto
+// a grid at the given frequency
+func (st *SteamTurbine) synchronize(frequency float64) {
+	st.online = true
+	st.gridSpeed = TURBINE_SYNCHRONOUS_SPEED * frequency / NOMINAL_GRID_FREQUENCY
+	st.speed = st.gridSpeed
+}
+
+// takeOffline opens the unit from the grid; the governor drops the load
+// reference and catches the shaft as it speeds up
+func (st *SteamTurbine) takeOffline() {
+	if st.online {
+		st.online = false
+		st.loadReference = 0
+		st.loadSetpoint = 0
+		st.steamDumpArmed = true
+	}
+}
+
+func (st *SteamTurbine) Trip() {
+	st.trip(TURBINE_TRIP_MANUAL)
+}
+
+func (st *SteamTurbine) trip(cause string) {
+	if !st.tripped {
+		st.tripCause = cause
+	}
+	st.tripped = true
+	st.takeOffline()
+	st.governorValvePosition = 0
+	st.steamDumpArmed = true
+}
+
+// ResetTrip relatches the turbine and opens the stop valves; the governor
+// valves stay shut until the governor calls for steam
+func (st *SteamTurbine) ResetTrip() {
+	st.tripped = false
+	st.tripCause = ""
+	st.speedReference = st.speed
+}
+
+func (st *SteamTurbine) IsTripped() bool {
+	return st.tripped
+}
+
+func (st *SteamTurbine) TripCause() string {
+	return st.tripCause
+}
+
+func (st *SteamTurbine) ArmSteamDump() {
+	st.steamDumpArmed = true
+}
+
+func (st *SteamTurbine) DisarmSteamDump() {
+	st.steamDumpArmed = false
+}
+
+func (st *SteamTurbine) SteamDumpArmed() bool {
+	return st.steamDumpArmed
+}
+
+// kg/s of steam bypassing the turbine to the condenser
+func (st *SteamTurbine) SteamDumpFlowRate() float64 {
+	return st.steamDumpFlowRate
+}
+
+// kJ/kg of the steam going through the dump valves
+func (st *SteamTurbine) SteamDumpEnthalpy() float64 {
+	return st.steamDumpEnthalpy
+}
+
+func (st *SteamTurbine) AlarmConditions() []*AlarmCondition {
+	return []*AlarmCondition{
+		NewAlarmCondition("turbineTrip", "TURBINE TRIP", ALARM_PRIORITY_HIGH, func() bool {
+			return st.tripped
+		}),
+		NewAlarmCondition("turbineOverspeed", "TURBINE OVERSPEED", ALARM_PRIORITY_HIGH, func() bool {
+			return st.tripCause == TURBINE_TRIP_OVERSPEED
+		}),
+		NewAlarmCondition("steamDumpOpen", "STEAM DUMP OPEN", ALARM_PRIORITY_MEDIUM, func() bool {
+			return st.steamDumpFlowRate > 0
+		}),
+	}
 }
@@ -0,0 +1,295 @@
+package sim
+
+import (
+	"testing"
+
+	"won/sim-lab/go-engine/internal/steam"
+)
+
+// a plant with the secondary side at operating pressure and the core at rated power
+func setUpSteamCycle() (*Simulation, *Environment) {
+	simulation, env := setupSimulationEnvironment()
+	primaryLoop := NewPrimaryLoop("Test Primary Loop")
+	primaryLoop.SwitchOnPump()
+	simulation.AddComponent(primaryLoop)
+	reactorCore := NewReactorCore("Test Reactor Core")
+	reactorCore.ConnectToPrimaryLoop(primaryLoop)
+	simulation.AddComponent(reactorCore)
+	secondaryLoop := NewSecondaryLoop("Test Secondary Loop")
+	secondaryLoop.SwitchOnFeedwaterPump()
+	secondaryLoop.SwitchOnFeedheaters()
+	simulation.AddComponent(secondaryLoop)
+	steamGenerator := NewSteamGenerator("Test Steam Generator")
+	simulation.AddComponent(steamGenerator)
+	turbine := NewSteamTurbine("Test Turbine")
+	runTurbineOnline(turbine, 100)
+	simulation.AddComponent(turbine)
+	simulation.AddComponent(NewCondenser("Test Condenser"))
+
+	reactorCore.heatEnergyRate = RATED_THERMAL_POWER
+	// give the steam header time to come up to pressure, feedwater control
+	// time to bring the level back after heatup, and the heaters time to
+	// bring the feedwater up with steam from the turbine
+	for i := 0; i < 60; i++ {
+		updateSteamCycle(simulation, env)
+	}
+	return simulation, env
+}
+
+// puts the turbine on line at the given load, as if it had been run up and loaded
+func runTurbineOnline(turbine *SteamTurbine, load float64) {
+	turbine.speed = TURBINE_SYNCHRONOUS_SPEED
+	turbine.speedReference = TURBINE_SYNCHRONOUS_SPEED
+	turbine.online = true
+	turbine.loadSetpoint = load
+	turbine.loadReference = load
+	turbine.governorValvePosition = load / 100
+}
+
+func TestSteamLeavesTheSteamGeneratorSaturated(t *testing.T) {
+	simulation, _ := setUpSteamCycle()
+	secondaryLoop := simulation.FindSecondaryLoop()
+
+	if !almostEqual(secondaryLoop.SteamTemperature(), steam.SaturationTemperature(secondaryLoop.SteamPressure()), 0.01) {
+		t.Errorf("Expected saturated steam at %f MPa, got %f °C", secondaryLoop.SteamPressure(), secondaryLoop.SteamTemperature())
+	}
+	// the header settles where the turbine takes what the steam generator makes
+	if !almostEqual(secondaryLoop.SteamPressure(), RATED_STEAM_PRESSURE, 0.05) {
+		t.Errorf("Expected the steam line near rated pressure %f MPa, got %f", RATED_STEAM_PRESSURE, secondaryLoop.SteamPressure())
+	}
+	if !almostEqual(secondaryLoop.SteamTemperature(), TARGET_STEAM_TEMPERATURE, 0.5) {
+		t.Errorf("Expected steam near %f °C, got %f", TARGET_STEAM_TEMPERATURE, secondaryLoop.SteamTemperature())
+	}
+	if secondaryLoop.EmergencyMSSVReleased() || secondaryLoop.PowerOperatedReliefValveOpen() {
+		t.Errorf("Expected normal steam pressure to stay below the relief and safety valves")
+	}
+}
+
+func TestSteamCycleAtRatedPower(t *testing.T) {
+	simulation, env := setUpSteamCycle()
+	steamGenerator := simulation.FindSteamGenerator()
+	turbine := simulation.FindSteamTurbine()
+	condenser := simulation.FindCondenser()
+	for i := 0; i < 3; i++ {
+		steamGenerator.Update(env, simulation)
+		turbine.Update(env, simulation)
+		condenser.Update(env, simulation)
+	}
+
+	if !almostEqual(turbine.Load(), 100, 1) {
+		t.Errorf("Expected rated steam flow at rated power, got %f%% (%f kg/s)", turbine.Load(), steamGenerator.SteamFlowRate())
+	}
+
+	// a saturated-steam cycle turns about a third of its heat into work
+	efficiency := turbine.Power() / steamGenerator.heatTransferRate
+	if efficiency < 0.30 || efficiency > 0.38 {
+		t.Errorf("Expected about a third of the heat to reach the shaft, got %f (%f MW)", efficiency, turbine.Power())
+	}
+	if turbine.exhaustQuality < 0.6 || turbine.exhaustQuality >= 1 {
+		t.Errorf("Expected wet steam at the exhaust, got quality %f", turbine.exhaustQuality)
+	}
+
+	// what the turbine does not use, the condenser takes away
+	rejected := condenser.heatTransferRate / 1e6
+	if !almostEqual(rejected+turbine.Power(), steamGenerator.heatTransferRate, 0.1*steamGenerator.heatTransferRate) {
+		t.Errorf("Expected the heat to balance: %f MW in, %f MW work, %f MW rejected", steamGenerator.heatTransferRate, turbine.Power(), rejected)
+	}
+	// the shell holds the steam at saturation and what little air the vacuum pumps leave
+	if !almostEqual(condenser.Pressure(), steam.SaturationPressure(condenser.entryTemperature)+condenser.airPressure(), 1e-9) {
+		t.Errorf("Expected the condenser at saturation pressure for %f °C, got %f MPa", condenser.entryTemperature, condenser.Pressure())
+	}
+	if condenser.Pressure() > 1.2*DESIGN_CONDENSER_PRESSURE {
+		t.Errorf("Expected the condenser near design vacuum at rated load, got %f MPa", condenser.Pressure())
+	}
+}
+
+func TestTrippedTurbineMakesNoPower(t *testing.T) {
+	simulation, env := setUpSteamCycle()
+	turbine := simulation.FindSteamTurbine()
+	simulation.FindSteamGenerator().Update(env, simulation)
+
+	turbine.Trip()
+	turbine.Update(env, simulation)
+	if turbine.Power() != 0 || turbine.Load() != 0 {
+		t.Errorf("Expected no power with the stop valves shut, got %f MW at %f%% load", turbine.Power(), turbine.Load())
+	}
+}
+
+func updateSteamCycle(simulation *Simulation, env *Environment) {
+	simulation.FindPrimaryLoop().Update(env, simulation)
+	simulation.FindSteamGenerator().Update(env, simulation)
+	simulation.FindSecondaryLoop().Update(env, simulation)
+	simulation.FindSteamTurbine().Update(env, simulation)
+	simulation.FindCondenser().Update(env, simulation)
+	if generator := simulation.FindGenerator(); generator != nil {
+		generator.Update(env, simulation)
+	}
+}
+
+func TestTurbineRunsUpAndLoads(t *testing.T) {
+	simulation, env := setUpSteamCycle()
+	turbine := NewSteamTurbine("Cold Turbine")
+	simulation.components[len(simulation.components)-2] = turbine
+	generator := NewGenerator("Test Generator")
+	simulation.AddComponent(generator)
+	turbine.SetLoadSetpoint(20)
+
+	// the governor brings the shaft up at its set acceleration
+	updateSteamCycle(simulation, env)
+	if !almostEqual(turbine.speed, TURBINE_ACCELERATION, 10) {
+		t.Errorf("Expected the shaft to come up at %f rpm per minute, got %f rpm", TURBINE_ACCELERATION, turbine.speed)
+	}
+	for i := 0; i < 20; i++ {
+		updateSteamCycle(simulation, env)
+	}
+	if turbine.IsOnline() || turbine.Load() > 2 {
+		t.Errorf("Expected the unit to hold at speed, off line, until the breaker closes, got %f%% load", turbine.Load())
+	}
+	if !almostEqual(turbine.speed, TURBINE_SYNCHRONOUS_SPEED, 0.001*TURBINE_SYNCHRONOUS_SPEED) {
+		t.Errorf("Expected the governor to hold synchronous speed, got %f rpm", turbine.speed)
+	}
+
+	if err := generator.CloseBreaker(simulation); err != nil {
+		t.Fatalf("Expected the generator in sync at speed: %v", err)
+	}
+	updateSteamCycle(simulation, env)
+	if !turbine.IsOnline() {
+		t.Fatalf("Expected the unit on line once the breaker closes")
+	}
+
+	// then the load follows the setpoint at the ramp rate
+	for i := 0; i < 5; i++ {
+		updateSteamCycle(simulation, env)
+	}
+	if !almostEqual(turbine.Load(), 20, 0.5) {
+		t.Errorf("Expected the load at its setpoint, got %f%%", turbine.Load())
+	}
+	if turbine.Rpm() != TURBINE_MAX_RPM {
+		t.Errorf("Expected the grid to hold the shaft at synchronous speed, got %d rpm", turbine.Rpm())
+	}
+}
+
+func TestColdPlantLeavesTheTurbineStill(t *testing.T) {
+	simulation, env := setupSimulationEnvironment()
+	primaryLoop := NewPrimaryLoop("Cold Primary Loop")
+	primaryLoop.SwitchOnPump()
+	simulation.AddComponent(primaryLoop)
+	reactorCore := NewReactorCore("Cold Reactor Core")
+	reactorCore.ConnectToPrimaryLoop(primaryLoop)
+	simulation.AddComponent(reactorCore)
+	secondaryLoop := NewSecondaryLoop("Cold Secondary Loop")
+	secondaryLoop.SwitchOnFeedwaterPump()
+	simulation.AddComponent(secondaryLoop)
+	simulation.AddComponent(NewSteamGenerator("Cold Steam Generator"))
+	turbine := NewSteamTurbine("Cold Turbine")
+	turbine.SetLoadSetpoint(20)
+	simulation.AddComponent(turbine)
+	simulation.AddComponent(NewCondenser("Cold Condenser"))
+
+	// the governor opens up to run the shaft up, but there is no steam to be had
+	for i := 0; i < 30; i++ {
+		updateSteamCycle(simulation, env)
+	}
+	if turbine.speed != 0 || turbine.steamFlowRate != 0 {
+		t.Errorf("Expected the turbine still without steam, got %f rpm on %f kg/s", turbine.speed, turbine.steamFlowRate)
+	}
+	if secondaryLoop.SteamPressure() > steam.ATMOSPHERIC_PRESSURE {
+		t.Errorf("Expected the header at atmospheric pressure, got %f MPa", secondaryLoop.SteamPressure())
+	}
+}
+
+func TestLoadRampsTowardSetpoint(t *testing.T) {
+	simulation, env := setUpSteamCycle()
+	turbine := simulation.FindSteamTurbine()
+	turbine.SetLoadSetpoint(80)
+	turbine.SetLoadRampRate(4)
+
+	updateSteamCycle(simulation, env)
+	if !almostEqual(turbine.loadReference, 96, 1e-6) {
+		t.Errorf("Expected the load reference to come down 4%% in a minute, got %f", turbine.loadReference)
+	}
+	for i := 0; i < 5; i++ {
+		updateSteamCycle(simulation, env)
+	}
+	if !almostEqual(turbine.Load(), 80, 0.5) {
+		t.Errorf("Expected the load at its setpoint, got %f%%", turbine.Load())
+	}
+
+	turbine.SetLoadRampRate(MAX_LOAD_RAMP_RATE + 1)
+	if turbine.LoadRampRate() != 4 {
+		t.Errorf("Expected the ramp rate to stay at 4, got %f", turbine.LoadRampRate())
+	}
+}
+
+func TestGovernorCatchesLoadRejection(t *testing.T) {
+	simulation, env := setUpSteamCycle()
+	turbine := simulation.FindSteamTurbine()
+	updateSteamCycle(simulation, env)
+
+	turbine.takeOffline()
+	updateSteamCycle(simulation, env)
+	// with the valves shut back, the header pressure climbs and opens the dump
+	updateSteamCycle(simulation, env)
+	if turbine.IsTripped() {
+		t.Errorf("Expected the governor to hold the shaft below the overspeed trip, tripped on %s", turbine.TripCause())
+	}
+	if !almostEqual(turbine.speed, TURBINE_SYNCHRONOUS_SPEED, 0.01*TURBINE_SYNCHRONOUS_SPEED) {
+		t.Errorf("Expected the shaft back near synchronous speed, got %f rpm", turbine.speed)
+	}
+	if turbine.SteamDumpFlowRate() != STEAM_DUMP_CAPACITY {
+		t.Errorf("Expected the steam dump wide open, got %f kg/s", turbine.SteamDumpFlowRate())
+	}
+}
+
+func TestOverspeedTripsTheTurbine(t *testing.T) {
+	simulation, env := setUpSteamCycle()
+	turbine := simulation.FindSteamTurbine()
+	turbine.online = false
+	turbine.loadSetpoint = 0
+
+	// as in an overspeed trip test, with the speed reference run up past the trip
+	turbine.speedReference = 1.2 * TURBINE_SYNCHRONOUS_SPEED
+	turbine.steamPressure = RATED_STEAM_PRESSURE
+	turbine.expandSteam(simulation.FindSecondaryLoop().FeedwaterHeaters(), 1)
+	for elapsed := 0.0; elapsed < SECONDS_PER_TICK && !turbine.IsTripped(); elapsed += TURBINE_TIME_STEP {
+		turbine.governorValvePosition = 1
+		turbine.turnShaft(TURBINE_RATED_POWER, TURBINE_TIME_STEP)
+	}
+	if turbine.TripCause() != TURBINE_TRIP_OVERSPEED {
+		t.Errorf("Expected an overspeed trip, got %s at %d rpm", turbine.TripCause(), turbine.Rpm())
+	}
+	if turbine.speed > 1.11*TURBINE_SYNCHRONOUS_SPEED {
+		t.Errorf("Expected the trip at %f rpm, got to %f", TURBINE_OVERSPEED_TRIP_SPEED, turbine.speed)
+	}
+
+	updateSteamCycle(simulation, env)
+	if turbine.GovernorValvePosition() != 0 || turbine.Load() != 0 {
+		t.Errorf("Expected the valves shut after the trip, got %f%% open at %f%% load", turbine.GovernorValvePosition(), turbine.Load())
+	}
+}
+
+func TestTurbineTripDumpsSteamToTheCondenser(t *testing.T) {
+	simulation, env := setUpSteamCycle()
+	turbine := simulation.FindSteamTurbine()
+	condenser := simulation.FindCondenser()
+	updateSteamCycle(simulation, env)
+	ratedHeat := condenser.heatTransferRate
+
+	turbine.Trip()
+	updateSteamCycle(simulation, env)
+	if !turbine.SteamDumpArmed() || turbine.SteamDumpFlowRate() != STEAM_DUMP_CAPACITY {
+		t.Errorf("Expected the trip to open the steam dump, got %f kg/s", turbine.SteamDumpFlowRate())
+	}
+	if condenser.heatTransferRate <= 0.3*ratedHeat || condenser.heatTransferRate >= ratedHeat {
+		t.Errorf("Expected the condenser to take the dumped steam, %f W of %f", condenser.heatTransferRate, ratedHeat)
+	}
+	if turbine.IsOnline() {
+		t.Errorf("Expected the trip to take the unit off line")
+	}
+
+	turbine.ResetTrip()
+	updateSteamCycle(simulation, env)
+	if turbine.IsTripped() || turbine.TripCause() != "" {
+		t.Errorf("Expected the reset to relatch the turbine, cause %s", turbine.TripCause())
+	}
+}
@@ -0,0 +1,200 @@
+package sim
+
+import (
+	"fmt"
+	"math"
+)
+
+// The tertiary loop carries the condenser's heat out of the plant. The
+// circulating water pumps push cooling water through the condenser tubes and
+// on to the heat sink, which is either a river, taking in water at whatever
+// temperature the river runs, or a cooling tower.
+//
+// A river follows the weather slowly; there is so much of it that the heat
+// from the plant hardly matters. A cooling tower cools its water by
+// evaporating some of it into the air going through, so the best it can do
+// is the wet-bulb temperature of that air. How close it gets, the approach,
+// widens with the heat it has to get rid of, and with a crosswind upsetting
+// the draft. Its basin holds enough water that the cold water temperature
+// takes a while to follow. In winter, warm water is bypassed around the fill
+// to keep the basin from icing up.
+
+const (
+	HEAT_SINK_COOLING_TOWER = "coolingTower"
+	HEAT_SINK_RIVER         = "river"
+)
+
+const CIRCULATING_WATER_PUMPS = 3
+const CIRCULATING_WATER_PUMP_FLOW = 15000.0 // kg/s from each pump
+const COOLING_WATER_SPECIFIC_HEAT = 4.186   // kJ/kg/°C
+const COOLING_TOWER_DESIGN_APPROACH = 6.0   // °C over wet bulb, at the design range
+const COOLING_TOWER_DESIGN_RANGE = 11.0     // °C the water cools going through the tower, at rated heat load
+const COOLING_TOWER_BASIN_TIME = 1200.0     // seconds for the basin to follow
+const RIVER_RESPONSE_TIME = 12 * 3600.0     // seconds for the river to follow the air
+const COOLING_TOWER_CALM_WIND = 5.0         // m/s the draft shrugs off
+const COOLING_TOWER_WIND_PENALTY = 0.05     // fraction more approach for each m/s beyond that
+const COOLING_TOWER_MIN_TEMPERATURE = 5.0   // °C the winter bypass holds the basin above
+const RIVER_MIN_TEMPERATURE = 0.0           // °C, the river freezes over rather than going colder
+
+type TertiaryLoop struct {
+	BaseComponent
+	heatSink             string
+	pumpsRunning         []bool
+	flowRate             float64 // kg/s of cooling water
+	coldWaterTemperature float64 // °C, going to the condenser
+	hotWaterTemperature  float64 // °C, coming back from the condenser
+	heatLoad             float64 // in Watts, taken from the condenser
+	condenserLoad        float64 // in Watts, of steam the condenser has to condense
+	wetBulbTemperature   float64 // °C
+}
+
+func NewTertiaryLoop(name string, heatSink string) *TertiaryLoop {
+	if heatSink != HEAT_SINK_COOLING_TOWER && heatSink != HEAT_SINK_RIVER {
+		fmt.Printf("Unknown heat sink %s; using a cooling tower.\n", heatSink)
+		heatSink = HEAT_SINK_COOLING_TOWER
+	}
+	return &TertiaryLoop{
+		BaseComponent:        BaseComponent{Name: name},
+		heatSink:             heatSink,
+		pumpsRunning:         make([]bool, CIRCULATING_WATER_PUMPS),
+		coldWaterTemperature: ROOM_TEMPERATURE,
+		hotWaterTemperature:  ROOM_TEMPERATURE,
+	}
+}
+
+func (tl *TertiaryLoop) Update(env *Environment, s *Simulation) {
+	// the circulating water pumps need station power
+	if !env.PowerOn {
+		tl.StopAllPumps()
+	}
+
+	tl.heatLoad, tl.condenserLoad = 0, 0
+	if condenser := s.FindCondenser(); condenser != nil {
+		tl.heatLoad = condenser.heatTransferRate
+		tl.condenserLoad = condenser.SteamLoad()
+	}
+	waterRise := 0.0
+	if tl.flowRate > 0 {
+		waterRise = tl.heatLoad / 1000 / (tl.flowRate * COOLING_WATER_SPECIFIC_HEAT)
+	}
+
+	tl.wetBulbTemperature = wetBulbTemperature(env.AmbientTemperature, env.RelativeHumidity)
+	target, responseTime := math.Max(RIVER_MIN_TEMPERATURE, env.AmbientTemperature), RIVER_RESPONSE_TIME
+	if tl.heatSink == HEAT_SINK_COOLING_TOWER {
+		approach := COOLING_TOWER_DESIGN_APPROACH * waterRise / COOLING_TOWER_DESIGN_RANGE
+		approach *= 1 + COOLING_TOWER_WIND_PENALTY*math.Max(0, env.WindSpeed-COOLING_TOWER_CALM_WIND)
+		target = math.Max(COOLING_TOWER_MIN_TEMPERATURE, tl.wetBulbTemperature+approach)
+		responseTime = COOLING_TOWER_BASIN_TIME
+	}
+	tl.coldWaterTemperature = target + (tl.coldWaterTemperature-target)*math.Exp(-SECONDS_PER_TICK/responseTime)
+	tl.hotWaterTemperature = tl.coldWaterTemperature + waterRise
+}
+
+// countFlow sets the flow from the pumps running, as soon as they start or stop
+func (tl *TertiaryLoop) countFlow() {
+	tl.flowRate = 0
+	for _, running := range tl.pumpsRunning {
+		if running {
+			tl.flowRate += CIRCULATING_WATER_PUMP_FLOW
+		}
+	}
+}
+
+// wetBulbTemperature is how cold evaporation can bring water in air at the
+// given temperature and relative humidity, by Stull's fit, good to within a
+// degree over ordinary weather
+func wetBulbTemperature(dryBulb float64, relativeHumidity float64) float64 {
+	rh := relativeHumidity
+	return dryBulb*math.Atan(0.151977*math.Sqrt(rh+8.313659)) +
+		math.Atan(dryBulb+rh) - math.Atan(rh-1.676331) +
+		0.00391838*math.Pow(rh, 1.5)*math.Atan(0.023101*rh) - 4.686035
+}
+
+// the pump with the given number, 1 and up
+func (tl *TertiaryLoop) StartPump(number int) {
+	if number < 1 || number > len(tl.pumpsRunning) {
+		fmt.Printf("No circulating water pump %d.\n", number)
+		return
+	}
+	tl.pumpsRunning[number-1] = true
+	tl.countFlow()
+}
+
+func (tl *TertiaryLoop) StopPump(number int) {
+	if number < 1 || number > len(tl.pumpsRunning) {
+		fmt.Printf("No circulating water pump %d.\n", number)
+		return
+	}
+	tl.pumpsRunning[number-1] = false
+	tl.countFlow()
+}
+
+func (tl *TertiaryLoop) StartAllPumps() {
+	for i := range tl.pumpsRunning {
+		tl.pumpsRunning[i] = true
+	}
+	tl.countFlow()
+}
+
+func (tl *TertiaryLoop) StopAllPumps() {
+	for i := range tl.pumpsRunning {
+		tl.pumpsRunning[i] = false
+	}
+	tl.countFlow()
+}
+
+func (tl *TertiaryLoop) PumpCount() int {
+	return len(tl.pumpsRunning)
+}
+
+func (tl *TertiaryLoop) HeatSink() string {
+	return tl.heatSink
+}
+
+// kg/s of cooling water through the condenser
+func (tl *TertiaryLoop) FlowRate() float64 {
+	return tl.flowRate
+}
+
+// °C, going to the condenser
+func (tl *TertiaryLoop) ColdWaterTemperature() float64 {
+	return tl.coldWaterTemperature
+}
+
+// °C, back from the condenser
+func (tl *TertiaryLoop) HotWaterTemperature() float64 {
+	return tl.hotWaterTemperature
+}
+
+func (tl *TertiaryLoop) Status() map[string]interface{} {
+	return map[string]interface{}{
+		"name":                 tl.Name,
+		"heatSink":             tl.heatSink,
+		"pumpsRunning":         tl.pumpsRunning,
+		"flowRate":             tl.flowRate,
+		"coldWaterTemperature": tl.coldWaterTemperature,
+		"hotWaterTemperature":  tl.hotWaterTemperature,
+		"wetBulbTemperature":   tl.wetBulbTemperature,
+		"heatLoad":             tl.heatLoad,
+	}
+}
+
+func (tl *TertiaryLoop) PrintStatus() {
+	fmt.Printf("Tertiary Loop: %s\n", tl.Name)
+	fmt.Printf("\tHeat Sink: %s\n", tl.heatSink)
+	for i, running := range tl.pumpsRunning {
+		fmt.Printf("\tCirculating Water Pump %d: %s\n", i+1, boolToString(running))
+	}
+	fmt.Printf("\tFlow Rate: %.0f kg/s\n", tl.flowRate)
+	fmt.Printf("\tCold Water Temperature: %.2f °C\n", tl.coldWaterTemperature)
+	fmt.Printf("\tHot Water Temperature: %.2f °C\n", tl.hotWaterTemperature)
+	fmt.Printf("\tWet Bulb Temperature: %.2f °C\n", tl.wetBulbTemperature)
+}
+
+func (tl *TertiaryLoop) AlarmConditions() []*AlarmCondition {
+	return []*AlarmCondition{
+		NewAlarmCondition("circulatingWaterLost", "CIRC WATER FLOW LOST", ALARM_PRIORITY_HIGH, func() bool {
+			return tl.flowRate == 0 && tl.condenserLoad > 0
+		}),
+	}
+}
@@ -0,0 +1,63 @@
+package sim
+
+import (
+	"testing"
+)
+
+func TestCoolingTowerApproachesWetBulb(t *testing.T) {
+	simulation, env := setUpCondensingPlant()
+	tertiaryLoop := simulation.FindTertiaryLoop()
+	for i := 0; i < 120; i++ {
+		updateCondensingPlant(simulation, env)
+	}
+
+	// the tower cools its water to within the approach of the wet bulb, and
+	// the condenser warms it back up by the range
+	wetBulb := wetBulbTemperature(env.AmbientTemperature, env.RelativeHumidity)
+	approach := tertiaryLoop.ColdWaterTemperature() - wetBulb
+	waterRange := tertiaryLoop.HotWaterTemperature() - tertiaryLoop.ColdWaterTemperature()
+	if approach <= 0 || approach > 2*COOLING_TOWER_DESIGN_APPROACH {
+		t.Errorf("Expected cold water a few degrees over the %f °C wet bulb, got %f", wetBulb, tertiaryLoop.ColdWaterTemperature())
+	}
+	if !almostEqual(approach, COOLING_TOWER_DESIGN_APPROACH*waterRange/COOLING_TOWER_DESIGN_RANGE, 0.1) {
+		t.Errorf("Expected the approach to follow the range, %f °C for a %f °C range", approach, waterRange)
+	}
+}
+
+func TestHotterDayRaisesBackpressure(t *testing.T) {
+	simulation, env := setUpCondensingPlant()
+	condenser := simulation.FindCondenser()
+	for i := 0; i < 60; i++ {
+		updateCondensingPlant(simulation, env)
+	}
+	mildPressure := condenser.Pressure()
+	mildPower := simulation.FindSteamTurbine().Power()
+
+	env.AmbientTemperature = 35
+	for i := 0; i < 120; i++ {
+		updateCondensingPlant(simulation, env)
+	}
+	if condenser.Pressure() <= mildPressure {
+		t.Errorf("Expected more backpressure on a hot day, %f MPa against %f", condenser.Pressure(), mildPressure)
+	}
+	if simulation.FindSteamTurbine().Power() >= mildPower {
+		t.Errorf("Expected the turbine to lose power to the backpressure, %f MW against %f", simulation.FindSteamTurbine().Power(), mildPower)
+	}
+}
+
+func TestLossOfPowerStopsCirculatingWater(t *testing.T) {
+	tertiaryLoop := NewTertiaryLoop("Test Tertiary Loop", HEAT_SINK_RIVER)
+	simulation, env := setupSimulationEnvironment()
+	simulation.AddComponent(tertiaryLoop)
+	tertiaryLoop.StartPump(1)
+	tertiaryLoop.Update(env, simulation)
+	if tertiaryLoop.FlowRate() != CIRCULATING_WATER_PUMP_FLOW {
+		t.Errorf("Expected one pump's flow, got %f kg/s", tertiaryLoop.FlowRate())
+	}
+
+	env.PowerOn = false
+	tertiaryLoop.Update(env, simulation)
+	if tertiaryLoop.FlowRate() != 0 {
+		t.Errorf("Expected the pumps to stop without station power, got %f kg/s", tertiaryLoop.FlowRate())
+	}
+}
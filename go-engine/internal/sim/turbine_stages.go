@@ -0,0 +1,158 @@
+package sim
+
+import (
+	"fmt"
+	"math"
+
+	"won/sim-lab/go-engine/internal/steam"
+)
+
+// The turbine is one HP stage and several LP stages on the one shaft. Each
+// stage takes the steam from its inlet pressure down to its outlet pressure,
+// and the work it does is the enthalpy drop across it times the steam going
+// through. An ideal stage would keep the entropy constant; a real one gets
+// its isentropic efficiency of that drop, less about a percent for each
+// percent of moisture in the steam.
+//
+// The HP stage is bled partway through for the top high-pressure heater and
+// the reheater, and exhausts to the crossover, where the lower high-pressure
+// heater takes its steam before the moisture separator reheater. Each LP
+// stage but the last is bled at its outlet for a low-pressure heater; the
+// last exhausts to the condenser. Steam bled off does no work in the stages
+// after it.
+
+const (
+	TURBINE_STAGE_HIGH_PRESSURE = "highPressure"
+	TURBINE_STAGE_LOW_PRESSURE  = "lowPressure"
+)
+
+const HP_STAGE_EFFICIENCY = 0.87 // isentropic, with dry steam
+const LP_STAGE_EFFICIENCY = 0.9  // isentropic, with dry steam
+
+// outlet pressures of the LP stages at rated steam flow, in MPa; every one
+// but the last is an extraction point, and the last exhausts to the condenser
+var DESIGN_LP_STAGE_PRESSURES = []float64{0.5, 0.22, 0.09, 0.035, DESIGN_CONDENSER_PRESSURE}
+
+type TurbineStage struct {
+	name                 string
+	kind                 string
+	efficiency           float64     // isentropic, with dry steam
+	designBleedPressure  float64     // MPa at rated steam flow of a bleed point partway through; 0 if none
+	designOutletPressure float64     // MPa at rated steam flow
+	inlet                steam.State // steam coming in
+	bleed                steam.State // steam at the bleed point
+	outlet               steam.State // steam going out
+	flowShare            float64     // kg coming in per kg of throttle steam
+	extractionShare      float64     // kg bled off per kg of throttle steam
+	work                 float64     // kJ per kg of throttle steam
+	idealWork            float64     // kJ per kg of throttle steam, were the stage isentropic
+	flowRate             float64     // kg/s coming in
+	extractionFlowRate   float64     // kg/s bled off
+	power                float64     // MW
+}
+
+func NewTurbineStage(name string, kind string, efficiency float64, designBleedPressure float64, designOutletPressure float64) *TurbineStage {
+	return &TurbineStage{
+		name:                 name,
+		kind:                 kind,
+		efficiency:           efficiency,
+		designBleedPressure:  designBleedPressure,
+		designOutletPressure: designOutletPressure,
+	}
+}
+
+// newTurbineStages lines up the stages the steam goes through, from the
+// throttle to the condenser
+func newTurbineStages() []*TurbineStage {
+	stages := []*TurbineStage{
+		NewTurbineStage("HP", TURBINE_STAGE_HIGH_PRESSURE, HP_STAGE_EFFICIENCY, DESIGN_HP_BLEED_PRESSURE, DESIGN_CROSSOVER_PRESSURE),
+	}
+	for i, pressure := range DESIGN_LP_STAGE_PRESSURES {
+		stages = append(stages, NewTurbineStage(fmt.Sprintf("LP%d", i+1), TURBINE_STAGE_LOW_PRESSURE, LP_STAGE_EFFICIENCY, 0, pressure))
+	}
+	return stages
+}
+
+// extractionState is the steam the stage gives the heater, partway through
+// or at its outlet
+func (ts *TurbineStage) extractionState(heater *FeedwaterHeater) steam.State {
+	if heater.bledPartway {
+		return ts.bleed
+	}
+	return ts.outlet
+}
+
+// run sets the flows and power for the given steam flow through the throttle
+func (ts *TurbineStage) run(steamFlowRate float64) {
+	ts.flowRate = steamFlowRate * ts.flowShare
+	ts.extractionFlowRate = steamFlowRate * ts.extractionShare
+	ts.power = steamFlowRate * ts.work / 1000
+}
+
+// reset leaves the stage with no steam going through at the given pressure
+func (ts *TurbineStage) reset(pressure float64) {
+	idle := steam.SaturatedVapor(pressure)
+	ts.inlet, ts.bleed, ts.outlet = idle, idle, idle
+	ts.flowShare, ts.extractionShare, ts.work, ts.idealWork = 0, 0, 0, 0
+}
+
+func (ts *TurbineStage) Name() string {
+	return ts.name
+}
+
+func (ts *TurbineStage) Kind() string {
+	return ts.kind
+}
+
+// MPa
+func (ts *TurbineStage) InletPressure() float64 {
+	return ts.inlet.Pressure
+}
+
+// MPa
+func (ts *TurbineStage) OutletPressure() float64 {
+	return ts.outlet.Pressure
+}
+
+// mass fraction of vapor leaving the stage
+func (ts *TurbineStage) OutletQuality() float64 {
+	return math.Min(1, ts.outlet.Quality)
+}
+
+// kg/s coming in
+func (ts *TurbineStage) FlowRate() float64 {
+	return ts.flowRate
+}
+
+// kg/s bled off for feedwater heating and reheat
+func (ts *TurbineStage) ExtractionFlowRate() float64 {
+	return ts.extractionFlowRate
+}
+
+// MW
+func (ts *TurbineStage) Power() float64 {
+	return ts.power
+}
+
+func (ts *TurbineStage) Status() map[string]interface{} {
+	return map[string]interface{}{
+		"name":               ts.name,
+		"kind":               ts.kind,
+		"efficiency":         ts.efficiency,
+		"inletPressure":      ts.inlet.Pressure,
+		"inletTemperature":   ts.inlet.Temperature,
+		"inletEnthalpy":      ts.inlet.Enthalpy,
+		"outletPressure":     ts.outlet.Pressure,
+		"outletTemperature":  ts.outlet.Temperature,
+		"outletEnthalpy":     ts.outlet.Enthalpy,
+		"outletQuality":      ts.OutletQuality(),
+		"flowRate":           ts.flowRate,
+		"extractionFlowRate": ts.extractionFlowRate,
+		"power":              ts.power,
+	}
+}
+
+func (ts *TurbineStage) PrintStatus() {
+	fmt.Printf("\tStage %s: %.3f to %.4f MPa, %.1f kg/s in, %.1f kg/s bled, quality %.3f out, %.1f MW\n",
+		ts.name, ts.inlet.Pressure, ts.outlet.Pressure, ts.flowRate, ts.extractionFlowRate, ts.OutletQuality(), ts.power)
+}
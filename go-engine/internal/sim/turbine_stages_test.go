@@ -0,0 +1,97 @@
+package sim
+
+import (
+	"testing"
+)
+
+func TestStagesShareTheWork(t *testing.T) {
+	simulation, env := setUpSteamCycle()
+	settleSteamCycle(simulation, env)
+	turbine := simulation.FindSteamTurbine()
+	stages := turbine.Stages()
+
+	if len(stages) != 1+len(DESIGN_LP_STAGE_PRESSURES) || stages[0].Kind() != TURBINE_STAGE_HIGH_PRESSURE {
+		t.Fatalf("Expected an HP stage and %d LP stages, got %d stages", len(DESIGN_LP_STAGE_PRESSURES), len(stages))
+	}
+	power := 0.0
+	for i, stage := range stages {
+		power += stage.Power()
+		if stage.Power() <= 0 {
+			t.Errorf("Expected stage %s to do work, got %f MW", stage.Name(), stage.Power())
+		}
+		if stage.OutletPressure() >= stage.InletPressure() {
+			t.Errorf("Expected the pressure to fall across stage %s, got %f to %f MPa", stage.Name(), stage.InletPressure(), stage.OutletPressure())
+		}
+		if i == 0 {
+			continue
+		}
+		previous := stages[i-1]
+		if !almostEqual(stage.InletPressure(), previous.OutletPressure(), 1e-9) {
+			t.Errorf("Expected stage %s to take steam at %f MPa from %s, got %f", stage.Name(), previous.OutletPressure(), previous.Name(), stage.InletPressure())
+		}
+		if i > 1 && !almostEqual(stage.FlowRate(), previous.FlowRate()-previous.ExtractionFlowRate(), 1e-6) {
+			t.Errorf("Expected %f kg/s into stage %s after bleeding, got %f", previous.FlowRate()-previous.ExtractionFlowRate(), stage.Name(), stage.FlowRate())
+		}
+	}
+	if !almostEqual(power, turbine.Power(), 1e-6) {
+		t.Errorf("Expected the stages to make the turbine's %f MW, got %f", turbine.Power(), power)
+	}
+
+	// the moisture separator takes its water between the HP and LP stages
+	wrungOut := turbine.MoistureSeparatorReheater().Status()["separatorDrainFlow"].(float64)
+	if !almostEqual(stages[1].FlowRate(), stages[0].FlowRate()-stages[0].ExtractionFlowRate()-wrungOut, 1e-6) {
+		t.Errorf("Expected the LP stages to get the HP exhaust less the water separated, got %f kg/s", stages[1].FlowRate())
+	}
+
+	// the last stage exhausts to the condenser
+	last := stages[len(stages)-1]
+	if last.OutletPressure() != turbine.exhaustPressure || last.ExtractionFlowRate() != 0 {
+		t.Errorf("Expected the last stage to exhaust everything to the condenser at %f MPa, got %f with %f kg/s bled", turbine.exhaustPressure, last.OutletPressure(), last.ExtractionFlowRate())
+	}
+	if !almostEqual(last.FlowRate(), turbine.ExhaustFlowRate(), 1e-6) {
+		t.Errorf("Expected the exhaust flow %f kg/s to be what goes through the last stage, got %f", turbine.ExhaustFlowRate(), last.FlowRate())
+	}
+}
+
+func TestStagesFeedTheHeaters(t *testing.T) {
+	simulation, env := setUpSteamCycle()
+	settleSteamCycle(simulation, env)
+	turbine := simulation.FindSteamTurbine()
+
+	bled := 0.0
+	for _, stage := range turbine.Stages() {
+		bled += stage.ExtractionFlowRate()
+	}
+	extracted := turbine.MoistureSeparatorReheater().Status()["reheatingSteamFlow"].(float64)
+	for _, heater := range simulation.FindSecondaryLoop().FeedwaterHeaters() {
+		extracted += heater.ExtractionFlowRate()
+	}
+	if !almostEqual(bled, extracted, 1e-6) {
+		t.Errorf("Expected the stages to bleed what the heaters and reheater take, %f kg/s, got %f", extracted, bled)
+	}
+
+	// every stage but the last feeds a heater at its outlet, and the HP stage
+	// the top heater from partway through as well
+	heaters := simulation.FindSecondaryLoop().FeedwaterHeaters()
+	if len(heaters) != len(turbine.Stages()) {
+		t.Fatalf("Expected a heater for each of the %d bleed points, got %d", len(turbine.Stages()), len(heaters))
+	}
+	for _, heater := range heaters {
+		stage := turbine.Stages()[heater.bleedStage]
+		if heater.shellPressure != stage.extractionState(heater).Pressure {
+			t.Errorf("Expected heater %d at the %f MPa of its bleed point on %s, got %f", heater.Number(), stage.extractionState(heater).Pressure, stage.Name(), heater.shellPressure)
+		}
+	}
+	if top := heaters[len(heaters)-1]; top.bleedStage != 0 || !top.bledPartway {
+		t.Errorf("Expected the top heater to bleed from partway through the HP stage")
+	}
+
+	// the stage pressures fall off with the steam flow
+	crossover := turbine.CrossoverPressure()
+	turbine.SetLoadSetpoint(50)
+	turbine.SetLoadRampRate(MAX_LOAD_RAMP_RATE)
+	settleSteamCycle(simulation, env)
+	if turbine.CrossoverPressure() > 0.6*crossover {
+		t.Errorf("Expected the crossover pressure to fall off with the load from %f MPa, got %f", crossover, turbine.CrossoverPressure())
+	}
+}
@@ -0,0 +1,250 @@
+package sim
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// The climate sets the weather the plant sees. Left to itself it follows the
+// seasons and the days: coldest in the third week of January and warmest six
+// months on, coolest just before dawn and warmest in mid-afternoon. Relative
+// humidity goes the other way over the day, since the air holds about the
+// same moisture while it warms up, and the wind picks up in the afternoon.
+//
+// It can instead follow a weather file, a CSV of readings through the year,
+// such as a typical meteorological year for the site. The readings are taken
+// by time of year, whatever year they were recorded in, and the weather
+// between them is interpolated, wrapping from the end of the year back to the
+// start.
+//
+// Heat sinks care about the weather. A cooling tower can cool its water no
+// lower than the wet bulb, which rises with temperature and humidity, and a
+// river follows the air; either way a hot, muggy afternoon means warmer
+// cooling water, more backpressure and less power from the turbine.
+
+const (
+	WEATHER_SUNNY  = "Sunny"
+	WEATHER_CLOUDY = "Cloudy"
+	WEATHER_RAINY  = "Rainy"
+	WEATHER_WINDY  = "Windy"
+)
+
+const DEFAULT_MEAN_TEMPERATURE = 12.0 // °C over the year
+const DEFAULT_SEASONAL_SWING = 12.0   // °C from the yearly mean to midsummer
+const DEFAULT_DAILY_SWING = 6.0       // °C from the daily mean to the afternoon high
+const DEFAULT_MEAN_HUMIDITY = 65.0    // percent
+const DAILY_HUMIDITY_SWING = 15.0     // percent from the daily mean to the pre-dawn high
+const DEFAULT_MEAN_WIND_SPEED = 4.0   // m/s
+const DAILY_WIND_SWING = 0.3          // fraction of the mean wind speed
+const COLDEST_DAY_OF_YEAR = 20
+const WARMEST_HOUR = 15.0
+const MIN_MEAN_TEMPERATURE = -30.0 // °C
+const MAX_MEAN_TEMPERATURE = 40.0  // °C
+const CLOUDY_HUMIDITY = 85.0       // percent
+const RAINY_HUMIDITY = 95.0        // percent
+const WINDY_SPEED = 10.0           // m/s
+
+const minutesPerYear = 365 * DAY_OF_MINUTES
+
+// a reading from a weather file
+type WeatherRecord struct {
+	Time             time.Time
+	Temperature      float64 // °C
+	RelativeHumidity float64 // percent
+	WindSpeed        float64 // m/s
+}
+
+type Climate struct {
+	meanTemperature float64
+	seasonalSwing   float64
+	dailySwing      float64
+	meanHumidity    float64
+	meanWindSpeed   float64
+	records         []WeatherRecord // by time of year; the profile is used when there are none
+}
+
+func NewClimate() *Climate {
+	return &Climate{
+		meanTemperature: DEFAULT_MEAN_TEMPERATURE,
+		seasonalSwing:   DEFAULT_SEASONAL_SWING,
+		dailySwing:      DEFAULT_DAILY_SWING,
+		meanHumidity:    DEFAULT_MEAN_HUMIDITY,
+		meanWindSpeed:   DEFAULT_MEAN_WIND_SPEED,
+	}
+}
+
+// SetProfile sets the seasons and days the climate follows, and stops
+// following any weather file
+func (c *Climate) SetProfile(meanTemperature, seasonalSwing, dailySwing, meanHumidity, meanWindSpeed float64) {
+	if meanTemperature < MIN_MEAN_TEMPERATURE || meanTemperature > MAX_MEAN_TEMPERATURE {
+		fmt.Printf("Mean temperature must be between %.0f and %.0f °C. You requested %f.\n", MIN_MEAN_TEMPERATURE, MAX_MEAN_TEMPERATURE, meanTemperature)
+		return
+	}
+	if seasonalSwing < 0 || dailySwing < 0 {
+		fmt.Printf("Temperature swings cannot be negative. You requested %f and %f °C.\n", seasonalSwing, dailySwing)
+		return
+	}
+	if meanHumidity < 0 || meanHumidity > 100 {
+		fmt.Printf("Humidity must be between 0 and 100 percent. You requested %f.\n", meanHumidity)
+		return
+	}
+	if meanWindSpeed < 0 {
+		fmt.Printf("Wind speed cannot be negative. You requested %f m/s.\n", meanWindSpeed)
+		return
+	}
+	c.meanTemperature = meanTemperature
+	c.seasonalSwing = seasonalSwing
+	c.dailySwing = dailySwing
+	c.meanHumidity = meanHumidity
+	c.meanWindSpeed = meanWindSpeed
+	c.records = nil
+}
+
+// UseWeatherRecords has the climate follow the given readings
+func (c *Climate) UseWeatherRecords(records []WeatherRecord) {
+	if len(records) == 0 {
+		fmt.Println("A weather file needs at least one reading.")
+		return
+	}
+	c.records = append([]WeatherRecord(nil), records...)
+	sort.SliceStable(c.records, func(i, j int) bool {
+		return minuteOfYear(c.records[i].Time) < minuteOfYear(c.records[j].Time)
+	})
+}
+
+func (c *Climate) FollowsWeatherFile() bool {
+	return len(c.records) > 0
+}
+
+// apply sets the weather in the environment for the given time
+func (c *Climate) apply(env *Environment, t time.Time) {
+	if c.FollowsWeatherFile() {
+		c.applyRecords(env, t)
+	} else {
+		c.applyProfile(env, t)
+	}
+	env.Weather = describeWeather(env.RelativeHumidity, env.WindSpeed)
+}
+
+func (c *Climate) applyProfile(env *Environment, t time.Time) {
+	season := -math.Cos(2 * math.Pi * float64(t.YearDay()-COLDEST_DAY_OF_YEAR) / 365)
+	hour := float64(t.Hour()) + float64(t.Minute())/60
+	day := math.Cos(2 * math.Pi * (hour - WARMEST_HOUR) / 24)
+
+	env.AmbientTemperature = c.meanTemperature + c.seasonalSwing*season + c.dailySwing*day
+	env.RelativeHumidity = math.Max(5, math.Min(100, c.meanHumidity-DAILY_HUMIDITY_SWING*day))
+	env.WindSpeed = c.meanWindSpeed * (1 + DAILY_WIND_SWING*day)
+}
+
+// applyRecords interpolates between the readings on either side of the
+// given time of year
+func (c *Climate) applyRecords(env *Environment, t time.Time) {
+	now := minuteOfYear(t)
+	next := sort.Search(len(c.records), func(i int) bool {
+		return minuteOfYear(c.records[i].Time) > now
+	})
+	before := c.records[(next-1+len(c.records))%len(c.records)]
+	after := c.records[next%len(c.records)]
+
+	span := (minuteOfYear(after.Time) - minuteOfYear(before.Time) + minutesPerYear) % minutesPerYear
+	fraction := 0.0
+	if span > 0 {
+		fraction = float64((now-minuteOfYear(before.Time)+minutesPerYear)%minutesPerYear) / float64(span)
+	}
+	between := func(a, b float64) float64 {
+		return a + (b-a)*fraction
+	}
+	env.AmbientTemperature = between(before.Temperature, after.Temperature)
+	env.RelativeHumidity = between(before.RelativeHumidity, after.RelativeHumidity)
+	env.WindSpeed = between(before.WindSpeed, after.WindSpeed)
+}
+
+// minuteOfYear counts the minutes into the year by the calendar, taking
+// every year as a common one so that leap years line up with the rest
+func minuteOfYear(t time.Time) int {
+	calendar := time.Date(2001, t.Month(), t.Day(), t.Hour(), t.Minute(), 0, 0, time.UTC)
+	return ((calendar.YearDay()-1)*DAY_OF_MINUTES + calendar.Hour()*HOUR_OF_MINUTES + calendar.Minute()) % minutesPerYear
+}
+
+func describeWeather(relativeHumidity float64, windSpeed float64) string {
+	switch {
+	case relativeHumidity >= RAINY_HUMIDITY:
+		return WEATHER_RAINY
+	case windSpeed >= WINDY_SPEED:
+		return WEATHER_WINDY
+	case relativeHumidity >= CLOUDY_HUMIDITY:
+		return WEATHER_CLOUDY
+	default:
+		return WEATHER_SUNNY
+	}
+}
+
+func (c *Climate) Status() map[string]interface{} {
+	return map[string]interface{}{
+		"meanTemperature":    c.meanTemperature,
+		"seasonalSwing":      c.seasonalSwing,
+		"dailySwing":         c.dailySwing,
+		"meanHumidity":       c.meanHumidity,
+		"meanWindSpeed":      c.meanWindSpeed,
+		"followsWeatherFile": c.FollowsWeatherFile(),
+		"weatherRecords":     len(c.records),
+	}
+}
+
+// ReadWeatherRecords reads a weather file: a header row, then one reading per
+// row of time ("2006-01-02 15:04"), temperature (°C), relative humidity
+// (percent) and wind speed (m/s)
+func ReadWeatherRecords(r io.Reader) ([]WeatherRecord, error) {
+	rows, err := csv.NewReader(r).ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	if len(rows) < 2 {
+		return nil, fmt.Errorf("weather file has no readings")
+	}
+
+	records := make([]WeatherRecord, 0, len(rows)-1)
+	for i, row := range rows[1:] {
+		if len(row) < 4 {
+			return nil, fmt.Errorf("line %d: expected time, temperature, humidity and wind speed", i+2)
+		}
+		when, err := time.Parse("2006-01-02 15:04", strings.TrimSpace(row[0]))
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", i+2, err)
+		}
+		values := make([]float64, 3)
+		for j := range values {
+			values[j], err = strconv.ParseFloat(strings.TrimSpace(row[j+1]), 64)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %w", i+2, err)
+			}
+		}
+		if values[1] < 0 || values[1] > 100 || values[2] < 0 {
+			return nil, fmt.Errorf("line %d: humidity must be 0 to 100 percent and wind speed not negative", i+2)
+		}
+		records = append(records, WeatherRecord{
+			Time:             when,
+			Temperature:      values[0],
+			RelativeHumidity: values[1],
+			WindSpeed:        values[2],
+		})
+	}
+	return records, nil
+}
+
+// LoadWeatherFile reads the weather file at the given path
+func LoadWeatherFile(filePath string) ([]WeatherRecord, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return ReadWeatherRecords(file)
+}
@@ -0,0 +1,97 @@
+package sim
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNewSimulationSetsTheWeather(t *testing.T) {
+	simulation := NewSimulation("Test Sim", "Early detection is the key.")
+
+	// a January morning comes in well below the yearly mean
+	if simulation.environment.AmbientTemperature >= DEFAULT_MEAN_TEMPERATURE-DEFAULT_SEASONAL_SWING/2 {
+		t.Errorf("Expected a cold January morning, got %f °C", simulation.environment.AmbientTemperature)
+	}
+	if simulation.environment.RelativeHumidity <= 0 || simulation.environment.WindSpeed <= 0 {
+		t.Errorf("Expected humidity and wind, got %f%% and %f m/s", simulation.environment.RelativeHumidity, simulation.environment.WindSpeed)
+	}
+}
+
+func TestClimateFollowsDaysAndSeasons(t *testing.T) {
+	climate := NewClimate()
+	weatherAt := func(month time.Month, hour int) *Environment {
+		env := NewEnvironment()
+		climate.apply(env, time.Date(2000, month, 15, hour, 0, 0, 0, time.UTC))
+		return env
+	}
+	summerAfternoon := weatherAt(time.July, 15)
+	summerNight := weatherAt(time.July, 3)
+	winterNight := weatherAt(time.January, 3)
+
+	if !almostEqual(summerAfternoon.AmbientTemperature, DEFAULT_MEAN_TEMPERATURE+DEFAULT_SEASONAL_SWING+DEFAULT_DAILY_SWING, 1) {
+		t.Errorf("Expected the year's high on a July afternoon, got %f °C", summerAfternoon.AmbientTemperature)
+	}
+	if !almostEqual(summerAfternoon.AmbientTemperature-summerNight.AmbientTemperature, 2*DEFAULT_DAILY_SWING, 0.01) {
+		t.Errorf("Expected the daily swing between afternoon and night, got %f and %f °C", summerAfternoon.AmbientTemperature, summerNight.AmbientTemperature)
+	}
+	if winterNight.AmbientTemperature >= summerNight.AmbientTemperature {
+		t.Errorf("Expected winter nights colder than summer nights, got %f and %f °C", winterNight.AmbientTemperature, summerNight.AmbientTemperature)
+	}
+	if summerAfternoon.RelativeHumidity >= summerNight.RelativeHumidity {
+		t.Errorf("Expected the humidity to fall as the day warms, got %f%% and %f%%", summerAfternoon.RelativeHumidity, summerNight.RelativeHumidity)
+	}
+}
+
+func TestClimateFollowsAWeatherFile(t *testing.T) {
+	file := `time,temperature,humidity,windSpeed
+1997-01-01 00:00,-4,80,3
+1997-07-01 12:00,30,40,2
+1997-07-01 18:00,24,60,12
+`
+	records, err := ReadWeatherRecords(strings.NewReader(file))
+	if err != nil {
+		t.Fatalf("Expected the weather file to read, got %v", err)
+	}
+	climate := NewClimate()
+	climate.UseWeatherRecords(records)
+	env := NewEnvironment()
+
+	// halfway between readings, in a different year than they were recorded
+	climate.apply(env, time.Date(2000, time.July, 1, 15, 0, 0, 0, time.UTC))
+	if !almostEqual(env.AmbientTemperature, 27, 1e-9) || !almostEqual(env.RelativeHumidity, 50, 1e-9) {
+		t.Errorf("Expected 27 °C at 50%%, got %f °C at %f%%", env.AmbientTemperature, env.RelativeHumidity)
+	}
+
+	// the last reading of the year carries on into the first
+	climate.apply(env, time.Date(2000, time.December, 31, 23, 0, 0, 0, time.UTC))
+	if env.AmbientTemperature <= -4 || env.AmbientTemperature >= 24 {
+		t.Errorf("Expected the weather to wrap around the new year, got %f °C", env.AmbientTemperature)
+	}
+
+	climate.apply(env, time.Date(2000, time.July, 1, 18, 0, 0, 0, time.UTC))
+	if env.Weather != WEATHER_WINDY {
+		t.Errorf("Expected windy weather at 12 m/s, got %s", env.Weather)
+	}
+
+	if _, err := ReadWeatherRecords(strings.NewReader("time,temperature,humidity,windSpeed\nnoon,20,50,3\n")); err == nil {
+		t.Errorf("Expected a bad time to be rejected")
+	}
+}
+
+func TestSummerAfternoonCostsMegawatts(t *testing.T) {
+	powerAt := func(when time.Time) float64 {
+		simulation, env := setUpCondensingPlant()
+		simulation.climate.apply(env, when)
+		for i := 0; i < 120; i++ {
+			updateCondensingPlant(simulation, env)
+		}
+		return simulation.FindSteamTurbine().Power()
+	}
+	winterMorning := powerAt(time.Date(2000, time.January, 20, 6, 0, 0, 0, time.UTC))
+	summerAfternoon := powerAt(time.Date(2000, time.July, 20, 15, 0, 0, 0, time.UTC))
+
+	if winterMorning-summerAfternoon < 5 {
+		t.Errorf("Expected a summer afternoon to cost megawatts, %f MW against %f in winter", summerAfternoon, winterMorning)
+	}
+}
@@ -0,0 +1,177 @@
+// Package steam gives the properties of water and steam from the IAPWS
+// Industrial Formulation 1997 (IAPWS-IF97), so components can agree on what
+// state the water is in.
+//
+// Units follow the rest of the simulator: pressure in MPa, temperature in °C,
+// specific enthalpy in kJ/kg, specific entropy in kJ/kg/K and density in kg/m³.
+//
+// Region 1 (compressed liquid), region 2 (superheated steam) and region 4 (the
+// saturation line) are implemented. Together they cover every state up to
+// 350 °C, and superheated steam beyond; that takes in everything a PWR's
+// secondary side sees and the primary side up to its normal operating
+// pressure. Region 3, the dense fluid near the critical point, is left out.
+// Saturated water and steam are found up to 360 °C (18.67 MPa), where the
+// pressurizer relief valves lift, by running regions 1 and 2 a little past
+// their boundary; that is good to a fraction of a percent. Saturation
+// properties are held there above it.
+package steam
+
+import (
+	"math"
+)
+
+const GAS_CONSTANT = 0.461526 // kJ/kg/K, specific gas constant of water
+
+const KELVIN = 273.15
+const ATMOSPHERIC_PRESSURE = 0.101325 // MPa
+
+// limits of what is implemented
+const MIN_TEMPERATURE = 0.0          // °C
+const REGION_1_MAX_TEMPERATURE = 350 // °C, boundary with region 3
+const MIN_SATURATION_PRESSURE = 0.000611213
+const MAX_SATURATION_TEMPERATURE = 360       // °C
+const MAX_SATURATION_PRESSURE = 18.666403421 // MPa, saturation pressure at 360 °C
+const MAX_SUPERHEAT_TEMPERATURE = 800.0      // °C, top of region 2
+
+// region 1: γ = Σ n (7.1 - π)^I (τ - 1.222)^J, with π = p / 16.53 MPa and τ = 1386 K / T
+var region1I = [34]float64{0, 0, 0, 0, 0, 0, 0, 0, 1, 1, 1, 1, 1, 1, 2, 2, 2, 2, 2, 3, 3, 3, 4, 4, 4, 5, 8, 8, 21, 23, 29, 30, 31, 32}
+var region1J = [34]float64{-2, -1, 0, 1, 2, 3, 4, 5, -9, -7, -1, 0, 1, 3, -3, 0, 1, 3, 17, -4, 0, 6, -5, -2, 10, -8, -11, -6, -29, -31, -38, -39, -40, -41}
+var region1N = [34]float64{
+	0.14632971213167, -0.84548187169114, -0.37563603672040e1, 0.33855169168385e1,
+	-0.95791963387872, 0.15772038513228, -0.16616417199501e-1, 0.81214629983568e-3,
+	0.28319080123804e-3, -0.60706301565874e-3, -0.18990068218419e-1, -0.32529748770505e-1,
+	-0.21841717175414e-1, -0.52838357969930e-4, -0.47184321073267e-3, -0.30001780793026e-3,
+	0.47661393906987e-4, -0.44141845330846e-5, -0.72694996297594e-15, -0.31679644845054e-4,
+	-0.28270797985312e-5, -0.85205128120103e-9, -0.22425281908000e-5, -0.65171222895601e-6,
+	-0.14341729937924e-12, -0.40516996860117e-6, -0.12734301741641e-8, -0.17424871230634e-9,
+	-0.68762131295531e-18, 0.14478307828521e-19, 0.26335781662795e-22, -0.11947622640071e-22,
+	0.18228094581404e-23, -0.93537087292458e-25,
+}
+
+// region 2, ideal gas part: γ° = ln π + Σ n° τ^J°, with π = p / 1 MPa and τ = 540 K / T
+var region2J0 = [9]float64{0, 1, -5, -4, -3, -2, -1, 2, 3}
+var region2N0 = [9]float64{
+	-0.96927686500217e1, 0.10086655968018e2, -0.56087911283020e-2, 0.71452738081455e-1,
+	-0.40710498223928, 0.14240819171444e1, -0.43839511319450e1, -0.28408632460772,
+	0.21268463753307e-1,
+}
+
+// region 2, residual part: γr = Σ n π^I (τ - 0.5)^J
+var region2I = [43]float64{1, 1, 1, 1, 1, 2, 2, 2, 2, 2, 3, 3, 3, 3, 3, 4, 4, 4, 5, 6, 6, 6, 7, 7, 7, 8, 8, 9, 10, 10, 10, 16, 16, 18, 20, 20, 20, 21, 22, 23, 24, 24, 24}
+var region2J = [43]float64{0, 1, 2, 3, 6, 1, 2, 4, 7, 36, 0, 1, 3, 6, 35, 1, 2, 3, 7, 3, 16, 35, 0, 11, 25, 8, 36, 13, 4, 10, 14, 29, 50, 57, 20, 35, 48, 21, 53, 39, 26, 40, 58}
+var region2N = [43]float64{
+	-0.17731742473213e-2, -0.17834862292358e-1, -0.45996013696365e-1, -0.57581259083432e-1,
+	-0.50325278727930e-1, -0.33032641670203e-4, -0.18948987516315e-3, -0.39392777243355e-2,
+	-0.43797295650573e-1, -0.26674547914087e-4, 0.20481737692309e-7, 0.43870667284435e-6,
+	-0.32277677238570e-4, -0.15033924542148e-2, -0.40668253562649e-1, -0.78847309559367e-9,
+	0.12790717852285e-7, 0.48225372718507e-6, 0.22922076337661e-5, -0.16714766451061e-10,
+	-0.21171472321355e-2, -0.23895741934104e2, -0.59059564324270e-17, -0.12621808899101e-5,
+	-0.38946842435739e-1, 0.11256211360459e-10, -0.82311340897998e1, 0.19809712802088e-7,
+	0.10406965210174e-18, -0.10234747095929e-12, -0.10018179379511e-8, -0.80882908646985e-10,
+	0.10693031879409, -0.33662250574171, 0.89185845355421e-24, 0.30629316876232e-12,
+	-0.42002467698208e-5, -0.59056029685639e-25, 0.37826947613457e-5, -0.12768608934681e-14,
+	0.73087610595061e-28, 0.55414715350778e-16, -0.94369707241210e-6,
+}
+
+// region 4, the saturation line
+var region4N = [11]float64{
+	0, // the standard counts from 1
+	0.11670521452767e4, -0.72421316703206e6, -0.17073846940092e2, 0.12020824702470e5,
+	-0.32325550322333e7, 0.14915108613530e2, -0.48232657361591e4, 0.40511340542057e6,
+	-0.23855557567849, 0.65017534844798e3,
+}
+
+// dimensionless Gibbs free energy and its derivatives
+type gibbs struct {
+	g, gPi, gTau, gTauTau float64
+}
+
+func region1(pressure, kelvin float64) gibbs {
+	pi := pressure / 16.53
+	tau := 1386 / kelvin
+	var g gibbs
+	for i := range region1N {
+		a := 7.1 - pi
+		b := tau - 1.222
+		n, I, J := region1N[i], region1I[i], region1J[i]
+		aI, bJ := math.Pow(a, I), math.Pow(b, J)
+		g.g += n * aI * bJ
+		g.gPi += -n * I * aI / a * bJ
+		g.gTau += n * aI * J * bJ / b
+		g.gTauTau += n * aI * J * (J - 1) * bJ / (b * b)
+	}
+	return g
+}
+
+func region2(pressure, kelvin float64) gibbs {
+	pi := pressure
+	tau := 540 / kelvin
+	g := gibbs{g: math.Log(pi), gPi: 1 / pi}
+	for i := range region2N0 {
+		n, J := region2N0[i], region2J0[i]
+		g.g += n * math.Pow(tau, J)
+		g.gTau += n * J * math.Pow(tau, J-1)
+		g.gTauTau += n * J * (J - 1) * math.Pow(tau, J-2)
+	}
+	for i := range region2N {
+		n, I, J := region2N[i], region2I[i], region2J[i]
+		b := tau - 0.5
+		piI, bJ := math.Pow(pi, I), math.Pow(b, J)
+		g.g += n * piI * bJ
+		g.gPi += n * I * piI / pi * bJ
+		g.gTau += n * piI * J * bJ / b
+		g.gTauTau += n * piI * J * (J - 1) * bJ / (b * b)
+	}
+	return g
+}
+
+// state from the Gibbs free energy of either region; tau is the region's reduced inverse temperature
+func fromGibbs(g gibbs, pressure, kelvin, pi, tau float64) State {
+	return State{
+		Pressure:     pressure,
+		Temperature:  kelvin - KELVIN,
+		Enthalpy:     tau * g.gTau * GAS_CONSTANT * kelvin,
+		Entropy:      (tau*g.gTau - g.g) * GAS_CONSTANT,
+		Density:      pressure * 1000 / (pi * g.gPi * GAS_CONSTANT * kelvin),
+		SpecificHeat: -tau * tau * g.gTauTau * GAS_CONSTANT,
+	}
+}
+
+func liquid(pressure, kelvin float64) State {
+	state := fromGibbs(region1(pressure, kelvin), pressure, kelvin, pressure/16.53, 1386/kelvin)
+	state.Quality = 0
+	return state
+}
+
+func vapor(pressure, kelvin float64) State {
+	state := fromGibbs(region2(pressure, kelvin), pressure, kelvin, pressure, 540/kelvin)
+	state.Quality = 1
+	return state
+}
+
+// SaturationPressure in MPa at the given temperature in °C
+func SaturationPressure(temperature float64) float64 {
+	t := clamp(temperature, MIN_TEMPERATURE, MAX_SATURATION_TEMPERATURE) + KELVIN
+	n := region4N
+	theta := t + n[9]/(t-n[10])
+	a := theta*theta + n[1]*theta + n[2]
+	b := n[3]*theta*theta + n[4]*theta + n[5]
+	c := n[6]*theta*theta + n[7]*theta + n[8]
+	return math.Pow(2*c/(-b+math.Sqrt(b*b-4*a*c)), 4)
+}
+
+// SaturationTemperature in °C at the given pressure in MPa
+func SaturationTemperature(pressure float64) float64 {
+	p := clamp(pressure, MIN_SATURATION_PRESSURE, MAX_SATURATION_PRESSURE)
+	n := region4N
+	beta := math.Pow(p, 0.25)
+	e := beta*beta + n[3]*beta + n[6]
+	f := n[1]*beta*beta + n[4]*beta + n[7]
+	g := n[2]*beta*beta + n[5]*beta + n[8]
+	d := 2 * g / (-f - math.Sqrt(f*f-4*e*g))
+	return (n[10]+d-math.Sqrt((n[10]+d)*(n[10]+d)-4*(n[9]+n[10]*d)))/2 - KELVIN
+}
+
+func clamp(value, low, high float64) float64 {
+	return math.Max(low, math.Min(high, value))
+}
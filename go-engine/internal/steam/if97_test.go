@@ -0,0 +1,136 @@
+package steam
+
+import (
+	"math"
+	"testing"
+)
+
+func almostEqual(a, b, relative float64) bool {
+	return math.Abs(a-b) <= relative*math.Abs(b)
+}
+
+// verification values from the IAPWS-IF97 release, tables 5, 15 and 35/36
+func TestRegion1(t *testing.T) {
+	cases := []struct {
+		pressure, kelvin, volume, enthalpy, entropy float64
+	}{
+		{3, 300, 0.100215168e-2, 0.115331273e3, 0.392294792},
+		{80, 300, 0.971180894e-3, 0.184142828e3, 0.368563852},
+		{3, 500, 0.120241800e-2, 0.975542239e3, 0.258041912e1},
+	}
+	for _, c := range cases {
+		state := liquid(c.pressure, c.kelvin)
+		if !almostEqual(1/state.Density, c.volume, 1e-8) {
+			t.Errorf("At %f MPa, %f K expected v = %e, got %e", c.pressure, c.kelvin, c.volume, 1/state.Density)
+		}
+		if !almostEqual(state.Enthalpy, c.enthalpy, 1e-8) {
+			t.Errorf("At %f MPa, %f K expected h = %e, got %e", c.pressure, c.kelvin, c.enthalpy, state.Enthalpy)
+		}
+		if !almostEqual(state.Entropy, c.entropy, 1e-8) {
+			t.Errorf("At %f MPa, %f K expected s = %e, got %e", c.pressure, c.kelvin, c.entropy, state.Entropy)
+		}
+	}
+}
+
+func TestRegion2(t *testing.T) {
+	cases := []struct {
+		pressure, kelvin, volume, enthalpy, entropy float64
+	}{
+		{0.0035, 300, 0.394913866e2, 0.254991145e4, 0.852238967e1},
+		{0.0035, 700, 0.923015898e2, 0.333568375e4, 0.101749996e2},
+		{30, 700, 0.542946619e-2, 0.263149474e4, 0.517540298e1},
+	}
+	for _, c := range cases {
+		state := vapor(c.pressure, c.kelvin)
+		if !almostEqual(1/state.Density, c.volume, 1e-8) {
+			t.Errorf("At %f MPa, %f K expected v = %e, got %e", c.pressure, c.kelvin, c.volume, 1/state.Density)
+		}
+		if !almostEqual(state.Enthalpy, c.enthalpy, 1e-8) {
+			t.Errorf("At %f MPa, %f K expected h = %e, got %e", c.pressure, c.kelvin, c.enthalpy, state.Enthalpy)
+		}
+		if !almostEqual(state.Entropy, c.entropy, 1e-8) {
+			t.Errorf("At %f MPa, %f K expected s = %e, got %e", c.pressure, c.kelvin, c.entropy, state.Entropy)
+		}
+	}
+}
+
+func TestSaturationLine(t *testing.T) {
+	pressures := []struct{ kelvin, pressure float64 }{
+		{300, 0.353658941e-2},
+		{500, 0.263889776e1},
+		{600, 0.123443146e2},
+	}
+	for _, c := range pressures {
+		if p := SaturationPressure(c.kelvin - KELVIN); !almostEqual(p, c.pressure, 1e-8) {
+			t.Errorf("At %f K expected saturation pressure %e MPa, got %e", c.kelvin, c.pressure, p)
+		}
+	}
+
+	temperatures := []struct{ pressure, kelvin float64 }{
+		{0.1, 0.372755919e3},
+		{1, 0.453035632e3},
+		{10, 0.584149488e3},
+	}
+	for _, c := range temperatures {
+		if T := SaturationTemperature(c.pressure) + KELVIN; !almostEqual(T, c.kelvin, 1e-8) {
+			t.Errorf("At %f MPa expected saturation temperature %e K, got %e", c.pressure, c.kelvin, T)
+		}
+	}
+}
+
+func TestWetSteam(t *testing.T) {
+	// steam tables: at 7 MPa, hf = 1267 kJ/kg, hg = 2772 kJ/kg
+	f := SaturatedLiquid(7)
+	g := SaturatedVapor(7)
+	if !almostEqual(f.Enthalpy, 1267, 0.002) || !almostEqual(g.Enthalpy, 2772, 0.002) {
+		t.Errorf("Expected hf about 1267 and hg about 2772 kJ/kg at 7 MPa, got %f and %f", f.Enthalpy, g.Enthalpy)
+	}
+
+	wet := WetSteam(7, 0.9)
+	if !almostEqual(Quality(7, wet.Enthalpy), 0.9, 1e-9) {
+		t.Errorf("Expected quality to round trip through enthalpy, got %f", Quality(7, wet.Enthalpy))
+	}
+	if !almostEqual(QualityFromEntropy(7, wet.Entropy), 0.9, 1e-9) {
+		t.Errorf("Expected quality to round trip through entropy, got %f", QualityFromEntropy(7, wet.Entropy))
+	}
+	if wet.Density <= g.Density || wet.Density >= f.Density {
+		t.Errorf("Expected wet steam density %f between steam %f and water %f", wet.Density, g.Density, f.Density)
+	}
+}
+
+func TestStateFromEnthalpyAndEntropy(t *testing.T) {
+	wet := WetSteam(1.1, 0.88)
+	if state := FromEnthalpy(1.1, wet.Enthalpy); !almostEqual(state.Quality, 0.88, 1e-9) {
+		t.Errorf("Expected wet steam back from its enthalpy, got quality %f", state.Quality)
+	}
+
+	// steam tables: at 1 MPa and 250 °C, h = 2943 kJ/kg and s = 6.926 kJ/kg/K
+	hot := At(1, 250)
+	if state := FromEnthalpy(1, hot.Enthalpy); !almostEqual(state.Temperature, 250, 1e-6) || state.Quality != 1 {
+		t.Errorf("Expected superheated steam at 250 °C from its enthalpy, got %f °C", state.Temperature)
+	}
+	if state := FromEntropy(1, hot.Entropy); !almostEqual(state.Enthalpy, 2943, 0.002) {
+		t.Errorf("Expected about 2943 kJ/kg from the entropy at 250 °C, got %f", state.Enthalpy)
+	}
+}
+
+func TestAtPicksThePhase(t *testing.T) {
+	if state := At(15.5, 290); state.Quality != 0 || state.Density < 700 {
+		t.Errorf("Expected compressed liquid in the cold leg, got quality %f and density %f", state.Quality, state.Density)
+	}
+	if state := At(ATMOSPHERIC_PRESSURE, 150); state.Quality != 1 || state.Density > 1 {
+		t.Errorf("Expected steam at 150 °C and atmospheric pressure, got quality %f and density %f", state.Quality, state.Density)
+	}
+}
+
+func TestSaturationNearTheReliefValves(t *testing.T) {
+	// steam tables: at 18 MPa, Tsat = 357.0 °C, hf = 1732.0 kJ/kg, hg = 2509.5 kJ/kg
+	if T := SaturationTemperature(18); !almostEqual(T, 357.0, 0.001) {
+		t.Errorf("Expected to boil at about 357 °C at 18 MPa, got %f", T)
+	}
+	f := SaturatedLiquid(18)
+	g := SaturatedVapor(18)
+	if !almostEqual(f.Enthalpy, 1732.0, 0.002) || !almostEqual(g.Enthalpy, 2509.5, 0.002) {
+		t.Errorf("Expected hf about 1732 and hg about 2509.5 kJ/kg at 18 MPa, got %f and %f", f.Enthalpy, g.Enthalpy)
+	}
+}
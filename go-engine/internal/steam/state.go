@@ -0,0 +1,132 @@
+package steam
+
+import (
+	"math"
+)
+
+// State of water or steam at a point
+type State struct {
+	Pressure     float64 // MPa
+	Temperature  float64 // °C
+	Enthalpy     float64 // kJ/kg
+	Entropy      float64 // kJ/kg/K
+	Density      float64 // kg/m³
+	SpecificHeat float64 // kJ/kg/K, at constant pressure; not defined inside the dome
+	Quality      float64 // mass fraction of vapor; 0 for liquid, 1 for steam
+}
+
+// At gives the single-phase state at a pressure and temperature: liquid below
+// the saturation temperature, steam above it.
+func At(pressure, temperature float64) State {
+	if temperature < SaturationTemperature(pressure) {
+		kelvin := math.Min(temperature, REGION_1_MAX_TEMPERATURE) + KELVIN
+		return liquid(pressure, kelvin)
+	}
+	return vapor(pressure, temperature+KELVIN)
+}
+
+// Liquid gives water at the given temperature as if it were held below its
+// boiling point, for coolant that stays liquid in the loop whatever the
+// pressure reading says.
+func Liquid(pressure, temperature float64) State {
+	t := clamp(temperature, MIN_TEMPERATURE, REGION_1_MAX_TEMPERATURE)
+	p := math.Max(pressure, SaturationPressure(t))
+	return liquid(p, t+KELVIN)
+}
+
+// SaturatedLiquid is water just at its boiling point for the given pressure
+func SaturatedLiquid(pressure float64) State {
+	p := clamp(pressure, MIN_SATURATION_PRESSURE, MAX_SATURATION_PRESSURE)
+	return liquid(p, SaturationTemperature(p)+KELVIN)
+}
+
+// SaturatedVapor is dry steam just at its condensing point for the given pressure
+func SaturatedVapor(pressure float64) State {
+	p := clamp(pressure, MIN_SATURATION_PRESSURE, MAX_SATURATION_PRESSURE)
+	return vapor(p, SaturationTemperature(p)+KELVIN)
+}
+
+// WetSteam is a mixture of saturated liquid and vapor with the given quality
+func WetSteam(pressure, quality float64) State {
+	x := clamp(quality, 0, 1)
+	f := SaturatedLiquid(pressure)
+	g := SaturatedVapor(pressure)
+	return State{
+		Pressure:    f.Pressure,
+		Temperature: f.Temperature,
+		Enthalpy:    f.Enthalpy + x*(g.Enthalpy-f.Enthalpy),
+		Entropy:     f.Entropy + x*(g.Entropy-f.Entropy),
+		Density:     1 / ((1-x)/f.Density + x/g.Density),
+		Quality:     x,
+	}
+}
+
+// Quality of a mixture at the given pressure and enthalpy; below 0 means
+// subcooled liquid, above 1 superheated steam
+func Quality(pressure, enthalpy float64) float64 {
+	f := SaturatedLiquid(pressure)
+	g := SaturatedVapor(pressure)
+	return (enthalpy - f.Enthalpy) / (g.Enthalpy - f.Enthalpy)
+}
+
+// QualityFromEntropy is the quality reached by expanding to the given pressure
+// at the given entropy, as in an ideal turbine stage
+func QualityFromEntropy(pressure, entropy float64) float64 {
+	f := SaturatedLiquid(pressure)
+	g := SaturatedVapor(pressure)
+	return (entropy - f.Entropy) / (g.Entropy - f.Entropy)
+}
+
+// LatentHeat to boil water at the given pressure, in kJ/kg
+func LatentHeat(pressure float64) float64 {
+	return SaturatedVapor(pressure).Enthalpy - SaturatedLiquid(pressure).Enthalpy
+}
+
+// Enthalpy of single-phase water or steam, in kJ/kg
+func Enthalpy(pressure, temperature float64) float64 {
+	return At(pressure, temperature).Enthalpy
+}
+
+// Entropy of single-phase water or steam, in kJ/kg/K
+func Entropy(pressure, temperature float64) float64 {
+	return At(pressure, temperature).Entropy
+}
+
+// Density of single-phase water or steam, in kg/m³
+func Density(pressure, temperature float64) float64 {
+	return At(pressure, temperature).Density
+}
+
+// FromEnthalpy gives wet or superheated steam at the given pressure and
+// enthalpy, as leaves a real turbine stage
+func FromEnthalpy(pressure, enthalpy float64) State {
+	if x := Quality(pressure, enthalpy); x <= 1 {
+		return WetSteam(pressure, x)
+	}
+	return superheated(pressure, func(s State) float64 { return s.Enthalpy }, enthalpy)
+}
+
+// FromEntropy gives wet or superheated steam at the given pressure and
+// entropy, as leaves an ideal turbine stage
+func FromEntropy(pressure, entropy float64) State {
+	if x := QualityFromEntropy(pressure, entropy); x <= 1 {
+		return WetSteam(pressure, x)
+	}
+	return superheated(pressure, func(s State) float64 { return s.Entropy }, entropy)
+}
+
+// superheated finds the steam at the given pressure whose property, one that
+// rises with temperature, has the given value
+func superheated(pressure float64, property func(State) float64, value float64) State {
+	p := clamp(pressure, MIN_SATURATION_PRESSURE, MAX_SATURATION_PRESSURE)
+	low, high := SaturationTemperature(p), MAX_SUPERHEAT_TEMPERATURE
+	for i := 0; i < 60; i++ {
+		mid := (low + high) / 2
+		if property(vapor(p, mid+KELVIN)) < value {
+			low = mid
+		} else {
+			high = mid
+		}
+	}
+	return vapor(p, (low+high)/2+KELVIN)
+}